@@ -0,0 +1,191 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"slices"
+	"time"
+)
+
+// ACCOUNT DELETION KEYMAPS
+type adKeyMap struct {
+	Back key.Binding
+	Quit key.Binding
+}
+
+func (k adKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Back, k.Quit}
+}
+
+func (k adKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{
+		k.Back,
+	}}
+}
+
+var adKeys = adKeyMap{
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// ACCOUNT DELETION MODEL
+
+// AdModel schedules the account to be deleted once its grace period is
+// over, or cancels a deletion that's been scheduled.
+type AdModel struct {
+	session *session
+	// due is when the account will be deleted, or zero when it won't be.
+	due    time.Time
+	loaded bool
+	form   *huh.Form
+	width  int
+	km     adKeyMap
+	banner errorBanner
+}
+
+// ACCOUNT DELETION INITIALIZATION
+
+func (m *AdModel) newForm() *huh.Form {
+	s := m.session
+	confirm := huh.NewConfirm().
+		Key("confirm").
+		Title(s.T("Delete Your Account?")).
+		Description(s.T("Your birthdays, gifts, lists and settings will be deleted in %d days. You won't get reminders in the meantime, and you can change your mind here until then.", int(store.DeletionGracePeriod/(24*time.Hour)))).
+		Affirmative(s.T("Delete")).
+		Negative(s.T("Keep"))
+	if !m.due.IsZero() {
+		confirm = huh.NewConfirm().
+			Key("confirm").
+			Title(s.T("Keep Your Account?")).
+			Description(s.T("Your account will be deleted on %s.", s.printer.Date(m.due.Year(), m.due.Month(), m.due.Day()))).
+			Affirmative(s.T("Keep")).
+			Negative(s.T("Nope"))
+	}
+	return huh.NewForm(huh.NewGroup(confirm)).
+		WithShowHelp(false).WithShowErrors(false).WithTheme(s.styles.Form).WithKeyMap(formKeys(s.printer))
+}
+
+func EmptyAccountDeletion(s *session) AdModel {
+	m := AdModel{
+		session: s,
+		km:      localizeKeys(s.printer, adKeys),
+	}
+	m.form = m.newForm()
+	return m
+}
+
+// ACCOUNT DELETION COMMANDS
+
+type deletionDueMsg struct {
+	due time.Time
+}
+
+// getDeletionDue loads when the account will be deleted, in the account's
+// timezone.
+func getDeletionDue(st store.Store, phoneNumber string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		loc, err := userLocation(ctx, st, phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		due, err := st.DeletionDue(ctx, phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		if !due.IsZero() {
+			due = due.In(loc)
+		}
+		return deletionDueMsg{due}
+	}
+}
+
+func scheduleDeletion(ctx context.Context, st store.DeletionStore, phoneNumber string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := st.ScheduleDeletion(ctx, phoneNumber); err != nil {
+			return dbErrMsg{err}
+		}
+		return dbSuccessMsg{}
+	}
+}
+
+func cancelDeletion(ctx context.Context, st store.DeletionStore, phoneNumber string) tea.Cmd {
+	return func() tea.Msg {
+		if err := st.CancelDeletion(ctx, phoneNumber); err != nil {
+			return dbErrMsg{err}
+		}
+		return dbSuccessMsg{}
+	}
+}
+
+// ACCOUNT DELETION UPDATE-VIEW LOOP
+
+func (m *AdModel) Init() tea.Cmd {
+	return getDeletionDue(m.session.store, m.session.phoneNumber)
+}
+
+func (m *AdModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 80) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		if key.Matches(msg, m.km.Back) {
+			return m, popScreen()
+		}
+	case deletionDueMsg:
+		m.due = msg.due
+		m.loaded = true
+		m.form = m.newForm()
+		return m, m.form.Init()
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	case dbSuccessMsg:
+		return m, popScreen()
+	}
+	if !m.loaded {
+		return m, nil
+	}
+	f, cmd := m.form.Update(msg)
+	m.form = f.(*huh.Form)
+	if m.form.State == huh.StateCompleted {
+		switch {
+		case !m.form.GetBool("confirm"):
+			return m, popScreen()
+		case m.due.IsZero():
+			return m, scheduleDeletion(m.session.ctx(), m.session.store, m.session.phoneNumber)
+		default:
+			return m, cancelDeletion(m.session.ctx(), m.session.store, m.session.phoneNumber)
+		}
+	}
+	return m, cmd
+}
+
+func (m *AdModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("Delete Account")))
+	body := m.session.styles.Base.Render(m.form.View() + m.session.styles.FormErrors(m.form, m.session.printer))
+	footer := m.appBoundaryView(m.form.Help().ShortHelpView(slices.Concat(m.km.ShortHelp(), m.form.KeyBinds())))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *AdModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Center,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"slices"
+	"strings"
+)
+
+// API TOKEN FORM KEYMAPS
+type tfKeyMap struct {
+	Back key.Binding
+	Quit key.Binding
+}
+
+func (k tfKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Back, k.Quit}
+}
+
+func (k tfKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{
+		k.Back,
+	}}
+}
+
+var tfKeys = tfKeyMap{
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// API TOKEN FORM MODEL
+
+// TfModel names a new API token, then shows its secret. The secret isn't
+// stored, so this is the only time it can be seen.
+type TfModel struct {
+	session *session
+	form    *huh.Form
+	secret  string
+	width   int
+	km      tfKeyMap
+	banner  errorBanner
+}
+
+// API TOKEN FORM INITIALIZATION
+
+func EmptyAPITokenForm(s *session) TfModel {
+	f := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Key("name").
+				Title(s.T("Name")).
+				Description(s.T("What will use the token, e.g. \"Home Assistant\"?")).
+				Validate(validateName),
+			huh.NewConfirm().
+				Key("confirm").
+				Title(s.T("Create Token?")).
+				Affirmative(s.T("Yep")).
+				Negative(s.T("Nope")),
+		),
+	).WithShowHelp(false).WithShowErrors(false).WithTheme(s.styles.Form).WithKeyMap(formKeys(s.printer))
+	return TfModel{
+		session: s,
+		form:    f,
+		km:      localizeKeys(s.printer, tfKeys),
+	}
+}
+
+// API TOKEN FORM COMMANDS
+
+type apiTokenCreatedMsg struct {
+	secret string
+}
+
+func createAPIToken(ctx context.Context, st store.TokenStore, phoneNumber string, name string) tea.Cmd {
+	return func() tea.Msg {
+		_, secret, err := st.CreateAPIToken(ctx, phoneNumber, name)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return apiTokenCreatedMsg{secret}
+	}
+}
+
+// API TOKEN FORM UPDATE-VIEW LOOP
+
+func (m *TfModel) Init() tea.Cmd {
+	return m.form.PrevField()
+}
+
+func (m *TfModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 80) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		if key.Matches(msg, m.km.Back) {
+			return m, popScreen()
+		}
+		if m.secret != "" {
+			if key.Matches(msg, m.km.Quit) {
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	case apiTokenCreatedMsg:
+		m.secret = msg.secret
+		return m, nil
+	}
+	if m.secret != "" {
+		return m, nil
+	}
+	f, cmd := m.form.Update(msg)
+	m.form = f.(*huh.Form)
+	if m.form.State == huh.StateCompleted {
+		if !m.form.GetBool("confirm") {
+			return m, popScreen()
+		}
+		return m, createAPIToken(m.session.ctx(), m.session.store, m.session.phoneNumber, strings.TrimSpace(m.form.GetString("name")))
+	}
+	return m, cmd
+}
+
+func (m *TfModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("New API Token")))
+	if m.secret != "" {
+		secret := m.session.styles.StatusHeader.Render(m.session.T("Your New Token")) + "\n" + m.secret + "\n" +
+			m.session.styles.Help.Render(m.session.T("Copy it now; it won't be shown again. Send it as a bearer token in the Authorization header."))
+		body := m.session.styles.Base.Render(m.session.styles.Status.Width(72).Render(secret) + "\n")
+		footer := m.appBoundaryView(m.form.Help().ShortHelpView(m.km.ShortHelp()))
+		return header + "\n" + body + "\n" + footer
+	}
+	body := m.session.styles.Base.Render(m.form.View() + m.session.styles.FormErrors(m.form, m.session.printer))
+	footer := m.appBoundaryView(m.form.Help().ShortHelpView(slices.Concat(m.km.ShortHelp(), m.form.KeyBinds())))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *TfModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Center,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+	"time"
+)
+
+// API TOKENS KEYMAPS
+type tkKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	New    key.Binding
+	Revoke key.Binding
+	Back   key.Binding
+	Help   key.Binding
+	Quit   key.Binding
+}
+
+func (k tkKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.New, k.Revoke, k.Back, k.Help, k.Quit}
+}
+
+func (k tkKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.New},      // first column
+		{k.Revoke, k.Back, k.Quit}, // second column
+	}
+}
+
+var tkKeys = tkKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	New: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new token"),
+	),
+	Revoke: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "revoke"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Help: rootKeys.Help,
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c", "q"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// API TOKENS MODEL
+
+// TkModel lists the tokens other tools use to reach the account through
+// the API, and creates and revokes them.
+type TkModel struct {
+	session *session
+	table   table.Model
+	tokens  []store.APIToken
+	loaded  bool
+	width   int
+	help    help.Model
+	km      tkKeyMap
+	banner  errorBanner
+}
+
+// API TOKENS INITIALIZATION
+
+func tkColumns(p i18n.Printer) []table.Column {
+	return []table.Column{
+		{Title: p.T("Name"), Width: 30},
+		{Title: p.T("Created"), Width: 16},
+		{Title: p.T("Last Used"), Width: 16},
+	}
+}
+
+func EmptyAPITokens(s *session) TkModel {
+	t := table.New(
+		table.WithColumns(tkColumns(s.printer)),
+		table.WithFocused(true),
+		table.WithHeight(10),
+	)
+	t.SetStyles(s.styles.Table)
+
+	return TkModel{
+		session: s,
+		table:   t,
+		help:    s.styles.NewHelp(),
+		km:      localizeKeys(s.printer, tkKeys),
+	}
+}
+
+// API TOKENS COMMANDS
+
+type apiTokensRetrievalMsg struct {
+	tokens []store.APIToken
+}
+
+func getAPITokens(st store.TokenStore, phoneNumber string) tea.Cmd {
+	return func() tea.Msg {
+		tokens, err := st.ListAPITokens(context.Background(), phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return apiTokensRetrievalMsg{tokens}
+	}
+}
+
+func revokeAPIToken(ctx context.Context, st store.TokenStore, phoneNumber string, id int) tea.Cmd {
+	return func() tea.Msg {
+		if err := st.RevokeAPIToken(ctx, phoneNumber, id); err != nil {
+			return dbErrMsg{err}
+		}
+		return dbSuccessMsg{}
+	}
+}
+
+func (m *TkModel) setRows() {
+	p := m.session.printer
+	date := func(t time.Time) string {
+		return p.Date(t.Year(), t.Month(), t.Day())
+	}
+	var rows []table.Row
+	for _, tok := range m.tokens {
+		lastUsed := p.T("Never")
+		if !tok.LastUsedAt.IsZero() {
+			lastUsed = date(tok.LastUsedAt)
+		}
+		rows = append(rows, []string{tok.Name, date(tok.CreatedAt), lastUsed})
+	}
+	m.table.SetRows(rows)
+}
+
+// selected returns the highlighted token, if there is one.
+func (m *TkModel) selected() (store.APIToken, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.tokens) {
+		return store.APIToken{}, false
+	}
+	return m.tokens[i], true
+}
+
+// API TOKENS UPDATE-VIEW LOOP
+
+func (m *TkModel) Init() tea.Cmd {
+	m.table.SetStyles(m.session.styles.Table)
+	m.help.Styles = m.session.styles.KeyHelp
+	return getAPITokens(m.session.store, m.session.phoneNumber)
+}
+
+func (m *TkModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 120) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.km.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.km.Back):
+			return m, popScreen()
+		case key.Matches(msg, m.km.New):
+			tf := EmptyAPITokenForm(m.session)
+			return m, pushScreen(&tf)
+		case key.Matches(msg, m.km.Revoke):
+			tok, ok := m.selected()
+			if !ok {
+				return m, nil
+			}
+			return m, revokeAPIToken(m.session.ctx(), m.session.store, m.session.phoneNumber, tok.ID)
+		}
+	case apiTokensRetrievalMsg:
+		m.tokens = msg.tokens
+		m.loaded = true
+		m.setRows()
+		return m, nil
+	case dbSuccessMsg:
+		return m, getAPITokens(m.session.store, m.session.phoneNumber)
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m *TkModel) KeyMap() help.KeyMap {
+	return m.km
+}
+
+func (m *TkModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("API Tokens")))
+	var b strings.Builder
+	b.WriteString(m.session.styles.Help.Render(m.session.T("Tokens let other tools manage your birthdays and settings through the API. Revoke any you no longer use.")) + "\n\n")
+	if m.loaded && len(m.tokens) == 0 {
+		b.WriteString(m.session.styles.Help.Render(m.session.T("You don't have any API tokens yet.")) + "\n")
+	} else {
+		b.WriteString(m.table.View() + "\n")
+	}
+	body := m.session.styles.Base.Render(b.String())
+	footer := m.appBoundaryView(m.help.ShortHelpView(m.km.ShortHelp()))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *TkModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Left,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/store"
+	"fmt"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+	"time"
+)
+
+// BIRTHDAY CALENDAR KEYMAPS
+type bcKeyMap struct {
+	PrevDay    key.Binding
+	NextDay    key.Binding
+	PrevWeek   key.Binding
+	NextWeek   key.Binding
+	PrevMonth  key.Binding
+	NextMonth  key.Binding
+	NextPerson key.Binding
+	Edit       key.Binding
+	Back       key.Binding
+	Help       key.Binding
+	Quit       key.Binding
+}
+
+func (k bcKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.PrevDay, k.NextDay, k.PrevMonth, k.NextMonth, k.NextPerson, k.Edit, k.Back, k.Help, k.Quit}
+}
+
+func (k bcKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.PrevDay, k.NextDay, k.PrevWeek, k.NextWeek}, // first column
+		{k.PrevMonth, k.NextMonth, k.NextPerson},       // second column
+		{k.Edit, k.Back, k.Quit},                       // third column
+	}
+}
+
+var bcKeys = bcKeyMap{
+	PrevDay: key.NewBinding(
+		key.WithKeys("left"),
+		key.WithHelp("←", "prev day"),
+	),
+	NextDay: key.NewBinding(
+		key.WithKeys("right"),
+		key.WithHelp("→", "next day"),
+	),
+	PrevWeek: key.NewBinding(
+		key.WithKeys("up"),
+		key.WithHelp("↑", "prev week"),
+	),
+	NextWeek: key.NewBinding(
+		key.WithKeys("down"),
+		key.WithHelp("↓", "next week"),
+	),
+	PrevMonth: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "prev month"),
+	),
+	NextMonth: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "next month"),
+	),
+	NextPerson: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "next person"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e", "enter"),
+		key.WithHelp("e", "edit event"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Help: rootKeys.Help,
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c", "q"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// BIRTHDAY CALENDAR MODEL
+
+type BcModel struct {
+	session   *session
+	reminders []store.Event
+	lists     []store.List
+	loc       *time.Location
+	selected  time.Time
+	personIdx int
+	loaded    bool
+	width     int
+	help      help.Model
+	km        bcKeyMap
+	banner    errorBanner
+}
+
+// BIRTHDAY CALENDAR INITIALIZATION
+
+func EmptyBirthdayCalendar(s *session) BcModel {
+	nYear, nMonth, nDay := s.clock.Now().Date()
+	return BcModel{
+		session:  s,
+		selected: time.Date(nYear, nMonth, nDay, 0, 0, 0, 0, time.UTC),
+		loc:      time.Local,
+		help:     s.styles.NewHelp(),
+		km:       localizeKeys(s.printer, bcKeys),
+	}
+}
+
+// birthdaysOn returns the reminders that fall on the given date, converting
+// dates kept in other calendars to the Gregorian one. Leap day birthdays are
+// shown on February 28th in common years.
+func (m *BcModel) birthdaysOn(date time.Time) []store.Event {
+	var matches []store.Event
+	for _, r := range m.reminders {
+		if calendar.NextOccurrence(r.Calendar, r.Month, r.Day, date).Equal(date) {
+			matches = append(matches, r)
+		}
+	}
+	return matches
+}
+
+// moveSelection moves the selected day by days and months. Moving by months
+// keeps the day of the month, or lands on the last day of a shorter month,
+// so that no month gets skipped.
+func (m *BcModel) moveSelection(days int, months int) {
+	if months != 0 {
+		first := time.Date(m.selected.Year(), m.selected.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+		daysInMonth := first.AddDate(0, 1, -1).Day()
+		m.selected = first.AddDate(0, 0, min(m.selected.Day(), daysInMonth)-1)
+	}
+	m.selected = m.selected.AddDate(0, 0, days)
+	m.personIdx = 0
+}
+
+// BIRTHDAY CALENDAR UPDATE-VIEW LOOP
+
+func (m *BcModel) Init() tea.Cmd {
+	m.help.Styles = m.session.styles.KeyHelp
+	return tea.Batch(
+		getBirthdays(m.session.store, m.session.phoneNumber, m.session.clock),
+		getLists(m.session.store, m.session.phoneNumber),
+	)
+}
+
+func (m *BcModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 120) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.km.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.km.Back):
+			return m, popScreen()
+		case key.Matches(msg, m.km.PrevDay):
+			m.moveSelection(-1, 0)
+		case key.Matches(msg, m.km.NextDay):
+			m.moveSelection(1, 0)
+		case key.Matches(msg, m.km.PrevWeek):
+			m.moveSelection(-7, 0)
+		case key.Matches(msg, m.km.NextWeek):
+			m.moveSelection(7, 0)
+		case key.Matches(msg, m.km.PrevMonth):
+			m.moveSelection(0, -1)
+		case key.Matches(msg, m.km.NextMonth):
+			m.moveSelection(0, 1)
+		case key.Matches(msg, m.km.NextPerson):
+			if people := m.birthdaysOn(m.selected); len(people) > 0 {
+				m.personIdx = (m.personIdx + 1) % len(people)
+			}
+		case key.Matches(msg, m.km.Edit):
+			people := m.birthdaysOn(m.selected)
+			if len(people) == 0 {
+				return m, nil
+			}
+			return m, editEvent(m.session, m.lists, people[min(m.personIdx, len(people)-1)], &m.banner)
+		}
+	case getBirthdaysSuccessMsg:
+		m.reminders = msg.reminders
+		m.loc = msg.loc
+		if !m.loaded {
+			// Start on today in the account's time zone, which is the day
+			// the month view underlines.
+			nYear, nMonth, nDay := m.session.clock.Now().In(m.loc).Date()
+			m.selected = time.Date(nYear, nMonth, nDay, 0, 0, 0, 0, time.UTC)
+			m.loaded = true
+		}
+		// The selected day may have fewer people after a reload.
+		m.personIdx = max(min(m.personIdx, len(m.birthdaysOn(m.selected))-1), 0)
+	case listsRetrievalMsg:
+		m.lists = msg.lists
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+	}
+	return m, nil
+}
+
+func (m *BcModel) KeyMap() help.KeyMap {
+	return m.km
+}
+
+func (m *BcModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("Birthday Calendar")))
+	body := m.session.styles.Base.Render(
+		lipgloss.JoinHorizontal(lipgloss.Top, m.monthView(), m.dayView()),
+	)
+	footer := m.appBoundaryView(m.help.ShortHelpView(m.km.ShortHelp()))
+	return header + "\n" + body + "\n" + footer
+}
+
+// monthView renders the grid for the month containing the selected day,
+// with weeks starting on the locale's first day. Days with birthdays are
+// highlighted and carry a count of how many people celebrate on that day.
+func (m *BcModel) monthView() string {
+	p := m.session.printer
+	first := time.Date(m.selected.Year(), m.selected.Month(), 1, 0, 0, 0, 0, time.UTC)
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+	nYear, nMonth, nDay := m.session.clock.Now().In(m.loc).Date()
+	firstWeekday := p.FirstWeekday()
+	lastWeekday := (firstWeekday + 6) % 7
+
+	var b strings.Builder
+	b.WriteString(m.session.styles.StatusHeader.Render(p.MonthYear(first)) + "\n")
+	var weekdays []string
+	for i := range 7 {
+		d := (firstWeekday + time.Weekday(i)) % 7
+		weekdays = append(weekdays, m.session.styles.CalendarCell.Render(p.WeekdayAbbr(d)))
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, weekdays...) + "\n")
+
+	var week []string
+	for i := 0; i < int(first.Weekday()-firstWeekday+7)%7; i++ {
+		week = append(week, m.session.styles.CalendarCell.Render(""))
+	}
+	for day := 1; day <= daysInMonth; day++ {
+		date := first.AddDate(0, 0, day-1)
+		label := fmt.Sprintf("%2d", day)
+		style := m.session.styles.CalendarCell
+		if count := len(m.birthdaysOn(date)); count > 0 {
+			label = fmt.Sprintf("%2d·%d", day, count)
+			style = m.session.styles.CalendarBirthday
+		}
+		if date.Year() == nYear && date.Month() == nMonth && date.Day() == nDay {
+			style = style.Underline(true)
+		}
+		if date.Equal(m.selected) {
+			style = m.session.styles.CalendarSelected
+		}
+		week = append(week, style.Render(label))
+		if date.Weekday() == lastWeekday || day == daysInMonth {
+			b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, week...) + "\n")
+			week = nil
+		}
+	}
+	return b.String()
+}
+
+// dayView renders the side pane listing everyone celebrating on the
+// selected day.
+func (m *BcModel) dayView() string {
+	var b strings.Builder
+	b.WriteString(m.session.styles.StatusHeader.Render(m.session.printer.LongDate(m.selected)) + "\n\n")
+	people := m.birthdaysOn(m.selected)
+	if len(people) == 0 {
+		b.WriteString(m.session.styles.Help.Render(m.session.T("Nothing on this day")))
+	}
+	for i, r := range people {
+		line := fmt.Sprintf("%s: %s", r.Name, events.Occasion(m.session.printer, r.Type, r.Label, r.Year, m.selected.Year()))
+		if i == m.personIdx {
+			b.WriteString(m.session.styles.Highlight.Render("> "+line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	return m.session.styles.Status.Width(36).MarginLeft(2).Render(b.String())
+}
+
+func (m *BcModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Left,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
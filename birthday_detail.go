@@ -0,0 +1,278 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strconv"
+	"strings"
+)
+
+// BIRTHDAY DETAIL KEYMAPS
+type bdKeyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	Add     key.Binding
+	Edit    key.Binding
+	Delete  key.Binding
+	History key.Binding
+	Back    key.Binding
+	Help    key.Binding
+	Quit    key.Binding
+}
+
+func (k bdKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Add, k.Edit, k.Delete, k.History, k.Back, k.Help, k.Quit}
+}
+
+func (k bdKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Add, k.Edit},         // first column
+		{k.Delete, k.History, k.Back, k.Quit}, // second column
+	}
+}
+
+var bdKeys = bdKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Add: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "add gift"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e", "enter"),
+		key.WithHelp("e", "edit gift"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "delete gift"),
+	),
+	History: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "history"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Help: rootKeys.Help,
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c", "q"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// BIRTHDAY DETAIL MODEL
+
+type BdModel struct {
+	session    *session
+	birthdayId int
+	birthday   store.Event
+	lists      []store.List
+	table      table.Model
+	width      int
+	help       help.Model
+	km         bdKeyMap
+	banner     errorBanner
+}
+
+// BIRTHDAY DETAIL INITIALIZATION
+
+func bdColumns(p i18n.Printer) []table.Column {
+	return []table.Column{
+		{Title: "ID", Width: 0},
+		{Title: p.T("Gift"), Width: 32},
+		{Title: p.T("Status"), Width: 10},
+		{Title: p.T("Year"), Width: 6},
+		{Title: p.T("Price"), Width: 10},
+		{Title: p.T("Link"), Width: 32},
+	}
+}
+
+func EmptyBirthdayDetail(s *session, birthdayId int) BdModel {
+	t := table.New(
+		table.WithColumns(bdColumns(s.printer)),
+		table.WithFocused(true),
+		table.WithHeight(8),
+	)
+	t.SetStyles(s.styles.Table)
+
+	return BdModel{
+		session:    s,
+		birthdayId: birthdayId,
+		table:      t,
+		help:       s.styles.NewHelp(),
+		km:         localizeKeys(s.printer, bdKeys),
+	}
+}
+
+// BIRTHDAY DETAIL COMMANDS
+
+type giftsRetrievalMsg struct {
+	gifts []store.Gift
+}
+
+type giftDeletedMsg struct{}
+
+func getGifts(st store.BirthdayStore, phoneNumber string, birthdayId int) tea.Cmd {
+	return func() tea.Msg {
+		gifts, err := st.ListGifts(context.Background(), phoneNumber, birthdayId)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return giftsRetrievalMsg{gifts}
+	}
+}
+
+func deleteGift(st store.BirthdayStore, phoneNumber string, giftId int) tea.Cmd {
+	return func() tea.Msg {
+		if err := st.DeleteGift(context.Background(), phoneNumber, giftId); err != nil {
+			return dbErrMsg{err}
+		}
+		return giftDeletedMsg{}
+	}
+}
+
+// selectedGiftId returns the ID of the highlighted gift, or 0 when the list
+// is empty.
+func (m *BdModel) selectedGiftId() int {
+	row := m.table.SelectedRow()
+	if len(row) == 0 {
+		return 0
+	}
+	id, _ := strconv.Atoi(row[0])
+	return id
+}
+
+// readOnly returns the error to show when the event's list can only be
+// viewed, or nil when its gifts can be changed.
+func (m *BdModel) readOnly() error {
+	if l, ok := findList(m.lists, m.birthday.ListID); ok && !l.Role.CanEdit() {
+		return readOnlyError(m.session.printer, l)
+	}
+	return nil
+}
+
+// BIRTHDAY DETAIL UPDATE-VIEW LOOP
+
+func (m *BdModel) Init() tea.Cmd {
+	m.table.SetStyles(m.session.styles.Table)
+	m.help.Styles = m.session.styles.KeyHelp
+	return tea.Batch(
+		getBirthday(m.session.store, m.session.phoneNumber, m.birthdayId),
+		getGifts(m.session.store, m.session.phoneNumber, m.birthdayId),
+		getLists(m.session.store, m.session.phoneNumber),
+	)
+}
+
+func (m *BdModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 120) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.km.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.km.Back):
+			return m, popScreen()
+		case key.Matches(msg, m.km.History):
+			bh := EmptyBirthdayHistory(m.session, m.birthday)
+			return m, pushScreen(&bh)
+		case key.Matches(msg, m.km.Add, m.km.Edit, m.km.Delete) && m.readOnly() != nil:
+			return m, m.banner.Show(m.readOnly())
+		case key.Matches(msg, m.km.Add):
+			gf := EmptyGiftForm(m.session, m.birthdayId)
+			return m, pushScreen(&gf)
+		case key.Matches(msg, m.km.Edit):
+			if id := m.selectedGiftId(); id != 0 {
+				gf := EditGiftForm(m.session, m.birthdayId, id)
+				return m, pushScreen(&gf)
+			}
+			return m, nil
+		case key.Matches(msg, m.km.Delete):
+			if id := m.selectedGiftId(); id != 0 {
+				return m, deleteGift(m.session.store, m.session.phoneNumber, id)
+			}
+			return m, nil
+		}
+	case birthdayRetrievalMsg:
+		m.birthday = msg.birthday
+		return m, nil
+	case listsRetrievalMsg:
+		m.lists = msg.lists
+		return m, nil
+	case giftsRetrievalMsg:
+		var rows []table.Row
+		for _, g := range msg.gifts {
+			rows = append(rows, []string{
+				strconv.Itoa(g.ID),
+				g.Idea,
+				m.session.T(g.Status),
+				formatGiftYear(g.Year),
+				formatPrice(g.PriceCents),
+				g.Link,
+			})
+		}
+		m.table.SetRows(rows)
+		return m, nil
+	case giftDeletedMsg:
+		return m, getGifts(m.session.store, m.session.phoneNumber, m.birthdayId)
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m *BdModel) KeyMap() help.KeyMap {
+	return m.km
+}
+
+func (m *BdModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.birthday.Name))
+	var b strings.Builder
+	b.WriteString(m.session.styles.StatusHeader.Render(m.session.T(m.birthday.Type.Title())) + " ")
+	b.WriteString(formatEventDate(m.session.printer, m.birthday.Calendar, m.birthday.Month, m.birthday.Day, m.birthday.Year))
+	if m.birthday.Label != "" {
+		b.WriteString(" (" + m.birthday.Label + ")")
+	}
+	if len(m.birthday.Tags) > 0 {
+		b.WriteString("  " + m.session.styles.Highlight.Render(tagBadges(m.birthday.Tags)))
+	}
+	if l, ok := findList(m.lists, m.birthday.ListID); ok && len(m.lists) > 1 {
+		b.WriteString("  " + m.session.styles.Help.Render(listTitle(m.session.printer, l)))
+	}
+	b.WriteString("\n")
+	if m.birthday.Notes != "" {
+		b.WriteString(m.session.styles.Status.Width(72).Render(m.birthday.Notes) + "\n")
+	}
+	b.WriteString("\n" + m.table.View())
+	body := m.session.styles.Base.Render(b.String())
+	footer := m.appBoundaryView(m.help.ShortHelpView(m.km.ShortHelp()))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *BdModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Left,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
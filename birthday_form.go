@@ -1,15 +1,19 @@
 package main
 
 import (
-	"database/sql"
-	"fmt"
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"errors"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 	"slices"
 	"strconv"
-	"time"
+	"strings"
 )
 
 // BIRTHDAY FORM KEYMAPS
@@ -41,179 +45,217 @@ var bfKeys = bfKeyMap{
 // BIRTHDAY FORM MODEL
 
 type bfState struct {
-	phoneNumber string
-	editingId   int
+	editingId int
+	allTags   []string
+	lists     []store.List
 }
 
 type BfModel struct {
-	state  bfState
-	form   *huh.Form
-	width  int
-	styles *Styles
-	lg     *lipgloss.Renderer
-	db     *sql.DB
-	km     bfKeyMap
-	error  string
+	session *session
+	state   bfState
+	form    *huh.Form
+	width   int
+	km      bfKeyMap
+	banner  errorBanner
 }
 
 // BIRTHDAY FORM INITIALIZATION AND VALIDATION
 
-func validateDay(day string) error {
-	if day == "" {
-		return fmt.Errorf("day must be number between 1 and 31")
+// validateLabel checks the label against the event type picked in the same
+// form.
+func validateLabel(eventType *events.Type) func(string) error {
+	return func(label string) error {
+		return events.ValidateLabel(*eventType, label)
 	}
-	dayInt, err := strconv.Atoi(day)
-	if err != nil {
-		return fmt.Errorf("day must be number between 1 and 31")
-	}
-	if dayInt < 1 || dayInt > 31 {
-		return fmt.Errorf("day must be number between 1 and 31")
-	}
-	return nil
 }
 
-func validateYear(year string) error {
-	thisYear, _, _ := time.Now().Date()
-	if year == "" {
-		return fmt.Errorf("year must be number between 1 and %d", thisYear)
+// PopulatedForm builds the event form. lists are the lists the account can
+// add to; new events get a choice of list when there's more than one.
+func PopulatedForm(r store.Event, allTags []string, lists []store.List, thisYear int, styles *Styles, p i18n.Printer) *huh.Form {
+	eventType := r.Type
+	if eventType == "" {
+		eventType = events.Birthday
 	}
-	yearInt, err := strconv.Atoi(year)
-	if err != nil {
-		return fmt.Errorf("year must be number between 1 and %d", thisYear)
+	system := r.Calendar
+	if system == "" {
+		system = calendar.Gregorian
 	}
-	if yearInt < 1 || yearInt > thisYear {
-		return fmt.Errorf("year must be number between 1 and %d", thisYear)
+	month := max(r.Month, 1)
+	day, year := "", ""
+	if r.Day != 0 {
+		day = strconv.Itoa(r.Day)
 	}
-	return nil
-}
-
-func PopulatedForm(name string, month int, day string, year string) *huh.Form {
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewInput().
-				Key("name").
-				Title("Name").
-				Description("Enter the name of the person whose birthday you'd like to be reminded of").
-				Value(&name),
+	if r.Year != 0 {
+		year = strconv.Itoa(r.Year)
+	}
+	var systemOptions []huh.Option[calendar.System]
+	for _, s := range calendar.Systems {
+		systemOptions = append(systemOptions, huh.NewOption(p.T(s.Title()), s))
+	}
+	monthOptions := func() []huh.Option[int] {
+		var options []huh.Option[int]
+		for _, m := range system.Months() {
+			options = append(options, huh.NewOption(monthName(p, system, m.Number), m.Number))
+		}
+		return options
+	}
+	var typeOptions []huh.Option[events.Type]
+	for _, t := range events.Types {
+		typeOptions = append(typeOptions, huh.NewOption(p.T(t.Title()), t))
+	}
+	fields := []huh.Field{
+		huh.NewSelect[events.Type]().
+			Key("eventType").
+			Title(p.T("Occasion")).
+			Options(typeOptions...).
+			Value(&eventType).
+			Description(p.T("What kind of date is this?")),
+		huh.NewInput().
+			Key("name").
+			Title(p.T("Name")).
+			Description(p.T("Who is this for? For anniversaries, e.g. \"Ann & Bob\".")).
+			Value(&r.Name),
+		huh.NewInput().
+			Key("label").
+			Title(p.T("Label")).
+			Description(p.T("Optional for anniversaries (\"wedding\", \"work\"), required for custom occasions.")).
+			Value(&r.Label).
+			Validate(validateLabel(&eventType)),
+		huh.NewSelect[calendar.System]().
+			Key("calendar").
+			Title(p.T("Calendar")).
+			Options(systemOptions...).
+			Value(&system).
+			Description(p.T("Which calendar is the date kept in?")),
+		huh.NewSelect[int]().
+			Key("month").
+			Title(p.T("Month")).
+			// The options are also set up front so the cursor starts on the
+			// saved month. huh reloads them when the calendar changes, keeping
+			// the cursor's position, but on its own would start from the top
+			// and overwrite the month with January.
+			OptionsFunc(monthOptions, &system).
+			Options(monthOptions()...).
+			Value(&month).
+			Description(p.T("Enter the month of the occasion.")),
+		huh.NewInput().
+			Title(p.T("Day")).
+			Description(p.T("Enter the day of the occasion.")).
+			Key("day").
+			Value(&day).
+			CharLimit(2).
+			Validate(events.ValidateDay),
+		huh.NewInput().
+			Title(p.T("Year")).
+			Key("year").
+			Description(p.T("Enter the year they were born, married, started, etc.")).
+			Value(&year).
+			CharLimit(4).
+			Validate(events.ValidateYear(thisYear)),
+		// A single line input rather than huh's text area, whose styles can't
+		// be moved off the global renderer and whose ctrl+e editor would open
+		// on the server rather than for the SSH client.
+		huh.NewInput().
+			Key("notes").
+			Title(p.T("Notes")).
+			Description(p.T("Anything worth remembering, like what they mentioned wanting.")).
+			Value(&r.Notes),
+	}
+	if r.ID == 0 && len(lists) > 1 {
+		listID := lists[0].ID
+		var options []huh.Option[int]
+		for _, l := range lists {
+			options = append(options, huh.NewOption(listTitle(p, l), l.ID))
+		}
+		fields = append(fields,
 			huh.NewSelect[int]().
-				Key("month").
-				Title("Month").
-				Options(
-					huh.NewOption("January", 1),
-					huh.NewOption("February", 2),
-					huh.NewOption("March", 3),
-					huh.NewOption("April", 4),
-					huh.NewOption("May", 5),
-					huh.NewOption("June", 6),
-					huh.NewOption("July", 7),
-					huh.NewOption("August", 8),
-					huh.NewOption("September", 9),
-					huh.NewOption("October", 10),
-					huh.NewOption("November", 11),
-					huh.NewOption("December", 12),
-				).
-				Value(&month).
-				Description("Enter the month of their birthday."),
-			huh.NewInput().
-				Title("Day").
-				Description("Enter the day of their birthday.").
-				Key("day").
-				Value(&day).
-				CharLimit(2).
-				Validate(validateDay),
-			huh.NewInput().
-				Title("Year").
-				Key("year").
-				Description("Enter the year of their birthday.").
-				Value(&year).
-				CharLimit(4).
-				Validate(validateYear),
-			huh.NewConfirm().
-				Key("confirm").
-				Title("Save Changes?").
-				Affirmative("Yep").
-				Negative("Nope"),
-		),
+				Key("list").
+				Title(p.T("List")).
+				Description(p.T("Everyone on the list will be reminded.")).
+				Options(options...).
+				Value(&listID),
+		)
+	}
+	if len(allTags) > 0 {
+		var options []huh.Option[string]
+		for _, tag := range allTags {
+			options = append(options, huh.NewOption("#"+tag, tag).Selected(slices.Contains(r.Tags, tag)))
+		}
+		fields = append(fields,
+			huh.NewMultiSelect[string]().
+				Key("tags").
+				Title(p.T("Tags")).
+				Description(p.T("Select the groups this person belongs to.")).
+				Options(options...),
+		)
+	}
+	fields = append(fields,
+		huh.NewInput().
+			Key("newTags").
+			Title(p.T("New Tags")).
+			Description(p.T("Comma-separated tags to create, e.g. family, coworkers.")),
+		huh.NewConfirm().
+			Key("confirm").
+			Title(p.T("Save Changes?")).
+			Affirmative(p.T("Yep")).
+			Negative(p.T("Nope")),
 	)
-	return form
+	return huh.NewForm(huh.NewGroup(fields...)).WithShowErrors(false).WithTheme(styles.Form).WithKeyMap(formKeys(p))
 }
 
-func EmptyBirthdayForm(phoneNumber string, db *sql.DB) BfModel {
-	bf := BfModel{
-		state: bfState{
-			phoneNumber: phoneNumber,
-		},
-		db: db,
-		lg: lipgloss.DefaultRenderer(),
-		km: bfKeys,
+// formTags merges the selected existing tags with any newly entered ones.
+func formTags(form *huh.Form) []string {
+	tags, _ := form.Get("tags").([]string)
+	for _, tag := range events.ParseTags(form.GetString("newTags")) {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
 	}
-	bf.styles = NewStyles(bf.lg)
-	bf.form = PopulatedForm("", 1, "", "")
-	return bf
+	return tags
 }
 
-func EditBirthdayForm(phoneNumber string, editingId int, db *sql.DB) BfModel {
-	bf := BfModel{
-		state: bfState{
-			phoneNumber: phoneNumber,
-			editingId:   editingId,
-		},
-		db: db,
-		lg: lipgloss.DefaultRenderer(),
-		km: bfKeys,
+func EmptyBirthdayForm(s *session) BfModel {
+	return BfModel{
+		session: s,
+		form:    PopulatedForm(store.Event{}, nil, nil, s.clock.Now().Year(), s.styles, s.printer),
+		km:      localizeKeys(s.printer, bfKeys),
 	}
-	bf.styles = NewStyles(bf.lg)
-	bf.form = PopulatedForm("", 1, "", "")
+}
+
+func EditBirthdayForm(s *session, editingId int) BfModel {
+	bf := EmptyBirthdayForm(s)
+	bf.state.editingId = editingId
 	return bf
 }
 
 // BIRTHDAY FORM COMMANDS
 
 type birthdayRetrievalMsg struct {
-	name  string
-	month int
-	day   int
-	year  int
+	birthday store.Event
 }
 
-func getBirthday(db *sql.DB, birthdayId int) tea.Cmd {
+func getBirthday(st store.BirthdayStore, phoneNumber string, birthdayId int) tea.Cmd {
 	return func() tea.Msg {
-		var name string
-		var month, day, year int
-		row := db.QueryRow(`
-select name, month, day, year 
-from birthdays
-where id = ?;`, birthdayId)
-		err := row.Scan(&name, &month, &day, &year)
+		r, err := st.GetEvent(context.Background(), phoneNumber, birthdayId)
 		if err != nil {
 			return dbErrMsg{err}
 		}
-		return birthdayRetrievalMsg{name, month, day, year}
+		return birthdayRetrievalMsg{r}
 	}
 }
 
-func createBirthday(db *sql.DB, phoneNumber string, name string, month int, day int, year int) tea.Cmd {
+func createBirthday(ctx context.Context, st store.BirthdayStore, phoneNumber string, r store.Event) tea.Cmd {
 	return func() tea.Msg {
-		_, err := db.Exec(`
-insert into birthdays (phone_number_id, name, month, day, year)
-values (
-	(select id from phone_numbers where phone_number = ?),
-	?, ?, ?, ?
-);`, phoneNumber, name, month, day, year)
-		if err != nil {
+		if _, err := st.CreateEvent(ctx, phoneNumber, r); err != nil {
 			return dbErrMsg{err}
 		}
 		return dbSuccessMsg{}
 	}
 }
 
-func updateBirthday(db *sql.DB, birthdayId int, name string, month int, day int, year int) tea.Cmd {
+func updateBirthday(ctx context.Context, st store.BirthdayStore, phoneNumber string, r store.Event) tea.Cmd {
 	return func() tea.Msg {
-		_, err := db.Exec(`
-update birthdays
-set name = ?, month = ?, day = ?, year = ?
-where id = ?;`, name, month, day, year, birthdayId)
-		if err != nil {
+		if err := st.UpdateEvent(ctx, phoneNumber, r); err != nil {
 			return dbErrMsg{err}
 		}
 		return dbSuccessMsg{}
@@ -223,40 +265,38 @@ where id = ?;`, name, month, day, year, birthdayId)
 // BIRTHDAY FORM UPDATE-VIEW LOOP
 
 func (m *BfModel) Init() tea.Cmd {
-	if m.state.editingId == 0 {
-		return m.form.PrevField()
-	} else {
-		return getBirthday(m.db, m.state.editingId)
-	}
+	return getTags(m.session.store, m.session.phoneNumber)
 }
 
 func (m *BfModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.width = min(msg.Width, 80) - m.styles.Base.GetHorizontalFrameSize()
+		m.width = min(msg.Width, 80) - m.session.styles.Base.GetHorizontalFrameSize()
 	case tea.KeyMsg:
-		switch {
-		case key.Matches(msg, m.km.Quit):
-			return m, tea.Quit
-		case key.Matches(msg, m.km.Back):
-			bt := EmptyBirthdayTable(
-				m.state.phoneNumber,
-				m.db,
-				m.lg,
-				m.styles,
-			)
-			return EmptyRootModel(m).Navigate(&bt)
+		if key.Matches(msg, m.km.Back) {
+			return m, popScreen()
 		}
+	case tagsRetrievalMsg:
+		m.state.allTags = msg.tags
+		return m, getLists(m.session.store, m.session.phoneNumber)
+	case listsRetrievalMsg:
+		m.state.lists = editableLists(msg.lists)
+		if m.state.editingId != 0 {
+			return m, getBirthday(m.session.store, m.session.phoneNumber, m.state.editingId)
+		}
+		m.form = PopulatedForm(store.Event{}, m.state.allTags, m.state.lists, m.session.clock.Now().Year(), m.session.styles, m.session.printer)
+		return m, m.form.PrevField()
 	case birthdayRetrievalMsg:
-		m.form = PopulatedForm(msg.name, msg.month, strconv.Itoa(msg.day), strconv.Itoa(msg.year))
+		m.form = PopulatedForm(msg.birthday, m.state.allTags, m.state.lists, m.session.clock.Now().Year(), m.session.styles, m.session.printer)
 		return m, m.form.PrevField()
 	case dbErrMsg:
-		m.error = msg.err.Error()
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
 		return m, nil
 	case dbSuccessMsg:
-		bt := EmptyBirthdayTable(m.state.phoneNumber, m.db, m.lg, m.styles)
-		return EmptyRootModel(m).Navigate(&bt)
+		return m, popScreen()
 	}
 	f, cmd := m.form.Update(msg)
 	m.form = f.(*huh.Form)
@@ -266,43 +306,55 @@ func (m *BfModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			dayStr, yearStr := m.form.GetString("day"), m.form.GetString("year")
 			day, err1 := strconv.Atoi(dayStr)
 			year, err2 := strconv.Atoi(yearStr)
-			if err1 != nil {
-				panic(err1)
+			if err := errors.Join(err1, err2); err != nil {
+				// Leave the form open so the date can be corrected.
+				m.form.State = huh.StateNormal
+				return m, m.banner.Show(i18n.Errorf("invalid date: %v", err))
 			}
-			if err2 != nil {
-				panic(err2)
+			eventType, _ := m.form.Get("eventType").(events.Type)
+			system, _ := m.form.Get("calendar").(calendar.System)
+			r := store.Event{
+				ID:       m.state.editingId,
+				ListID:   m.form.GetInt("list"),
+				Name:     m.form.GetString("name"),
+				Type:     eventType,
+				Label:    strings.TrimSpace(m.form.GetString("label")),
+				Calendar: system,
+				Month:    m.form.GetInt("month"),
+				Day:      day,
+				Year:     year,
+				Notes:    m.form.GetString("notes"),
+				Tags:     formTags(m.form),
 			}
 			if m.state.editingId == 0 {
-				return m, createBirthday(m.db, m.state.phoneNumber, m.form.GetString("name"), m.form.GetInt("month"), day, year)
+				return m, createBirthday(m.session.ctx(), m.session.store, m.session.phoneNumber, r)
 			} else {
-				return m, updateBirthday(m.db, m.state.editingId, m.form.GetString("name"), m.form.GetInt("month"), day, year)
+				return m, updateBirthday(m.session.ctx(), m.session.store, m.session.phoneNumber, r)
 			}
 		} else {
-			bt := EmptyBirthdayTable(
-				m.state.phoneNumber,
-				m.db,
-				m.lg,
-				m.styles,
-			)
-			return EmptyRootModel(m).Navigate(&bt)
+			return m, popScreen()
 		}
 	}
 	return m, cmd
 }
 
 func (m *BfModel) View() string {
-	header := m.appBoundaryView("New Birthday Reminder")
-	body := m.styles.Base.Render(m.form.WithShowHelp(false).View())
+	title := m.session.T("New Reminder")
+	if m.state.editingId != 0 {
+		title = m.session.T("Edit Reminder")
+	}
+	header := m.banner.View(m.session, m.appBoundaryView(title))
+	body := m.session.styles.Base.Render(m.form.WithShowHelp(false).View() + m.session.styles.FormErrors(m.form, m.session.printer))
 	footer := m.appBoundaryView(m.form.Help().ShortHelpView(slices.Concat(m.km.ShortHelp(), m.form.KeyBinds())))
 	return header + "\n" + body + "\n" + footer
 }
 
 func (m *BfModel) appBoundaryView(text string) string {
-	return lipgloss.PlaceHorizontal(
+	return m.session.lg.PlaceHorizontal(
 		m.width,
 		lipgloss.Center,
-		m.styles.HeaderText.Render(text),
+		m.session.styles.HeaderText.Render(text),
 		lipgloss.WithWhitespaceChars("/"),
-		lipgloss.WithWhitespaceForeground(indigo),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
 	)
 }
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/phone"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"fmt"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+)
+
+// BIRTHDAY HISTORY KEYMAPS
+type bhKeyMap struct {
+	Up   key.Binding
+	Down key.Binding
+	Back key.Binding
+	Help key.Binding
+	Quit key.Binding
+}
+
+func (k bhKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Back, k.Help, k.Quit}
+}
+
+func (k bhKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down},   // first column
+		{k.Back, k.Quit}, // second column
+	}
+}
+
+var bhKeys = bhKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Help: rootKeys.Help,
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c", "q"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// BIRTHDAY HISTORY MODEL
+
+// BhModel lists who changed an event, when, from where, and what they
+// changed, newest first.
+type BhModel struct {
+	session *session
+	event   store.Event
+	history []store.AuditEvent
+	lists   []store.List
+	loaded  bool
+	table   table.Model
+	width   int
+	help    help.Model
+	km      bhKeyMap
+	banner  errorBanner
+}
+
+// BIRTHDAY HISTORY INITIALIZATION
+
+func bhColumns(p i18n.Printer) []table.Column {
+	return []table.Column{
+		{Title: p.T("When"), Width: 18},
+		{Title: p.T("Who"), Width: 16},
+		{Title: p.T("Via"), Width: 20},
+		{Title: p.T("Field"), Width: 10},
+		{Title: p.T("Change"), Width: 36},
+	}
+}
+
+func EmptyBirthdayHistory(s *session, e store.Event) BhModel {
+	t := table.New(
+		table.WithColumns(bhColumns(s.printer)),
+		table.WithFocused(true),
+		table.WithHeight(12),
+	)
+	t.SetStyles(s.styles.Table)
+
+	return BhModel{
+		session: s,
+		event:   e,
+		table:   t,
+		help:    s.styles.NewHelp(),
+		km:      localizeKeys(s.printer, bhKeys),
+	}
+}
+
+// BIRTHDAY HISTORY COMMANDS
+
+type historyRetrievalMsg struct {
+	history []store.AuditEvent
+}
+
+// getEventHistory loads an event's history with its times in the account's
+// timezone.
+func getEventHistory(st store.Store, phoneNumber string, eventID int) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		loc, err := userLocation(ctx, st, phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		history, err := st.EventHistory(ctx, phoneNumber, eventID)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		for i := range history {
+			history[i].At = history[i].At.In(loc)
+		}
+		return historyRetrievalMsg{history}
+	}
+}
+
+// auditFieldTitles are the form titles of the fields an event's history
+// records.
+var auditFieldTitles = map[string]string{
+	"list_id":  "List",
+	"name":     "Name",
+	"type":     "Occasion",
+	"label":    "Label",
+	"calendar": "Calendar",
+	"month":    "Month",
+	"day":      "Day",
+	"year":     "Year",
+	"notes":    "Notes",
+}
+
+// auditSourceTitles name the ways an account can connect.
+var auditSourceTitles = map[store.Source]string{
+	store.SourceSSH:   "SSH",
+	store.SourceLocal: "Local",
+	store.SourceAPI:   "API",
+}
+
+// auditValue formats a recorded value of field, given the record it's from
+// to name months in the right calendar.
+func auditValue(p i18n.Printer, lists []store.List, record map[string]any, field string) string {
+	v, ok := record[field]
+	if !ok || v == nil || v == "" {
+		return "—"
+	}
+	number, _ := v.(float64)
+	switch field {
+	case "type":
+		return p.T(events.Type(fmt.Sprint(v)).Title())
+	case "calendar":
+		return p.T(calendar.System(fmt.Sprint(v)).Title())
+	case "month":
+		system, _ := record["calendar"].(string)
+		return monthName(p, calendar.System(system), int(number))
+	case "year":
+		if number == 0 {
+			return "—"
+		}
+	case "list_id":
+		if l, ok := findList(lists, int(number)); ok {
+			return listTitle(p, l)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func (m *BhModel) setRows() {
+	p := m.session.printer
+	var rows []table.Row
+	for _, a := range m.history {
+		when := p.Date(a.At.Year(), a.At.Month(), a.At.Day()) + " " + p.Clock(a.At)
+		who := phone.FormatNational(a.PhoneNumber)
+		if a.PhoneNumber == m.session.phoneNumber {
+			who = p.T("You")
+		}
+		via := p.T("Unknown")
+		if title, ok := auditSourceTitles[a.Actor.Source]; ok {
+			via = p.T(title)
+		}
+		if a.Actor.Key != "" {
+			via += " · " + a.Actor.Key
+		}
+		switch a.Action {
+		case store.AuditCreate:
+			rows = append(rows, []string{when, who, via, "", p.T("Created")})
+		case store.AuditDelete:
+			rows = append(rows, []string{when, who, via, "", p.T("Deleted")})
+		default:
+			for _, c := range a.Changes() {
+				field := c.Field
+				if title, ok := auditFieldTitles[field]; ok {
+					field = p.T(title)
+				}
+				change := auditValue(p, m.lists, a.Before, c.Field) + " → " + auditValue(p, m.lists, a.After, c.Field)
+				rows = append(rows, []string{when, who, via, field, change})
+				// Later rows of the same change only name the field.
+				when, who, via = "", "", ""
+			}
+		}
+	}
+	m.table.SetRows(rows)
+}
+
+// BIRTHDAY HISTORY UPDATE-VIEW LOOP
+
+func (m *BhModel) Init() tea.Cmd {
+	m.table.SetStyles(m.session.styles.Table)
+	m.help.Styles = m.session.styles.KeyHelp
+	return tea.Batch(
+		getEventHistory(m.session.store, m.session.phoneNumber, m.event.ID),
+		getLists(m.session.store, m.session.phoneNumber),
+	)
+}
+
+func (m *BhModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 120) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.km.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.km.Back):
+			return m, popScreen()
+		}
+	case historyRetrievalMsg:
+		m.history = msg.history
+		m.loaded = true
+		m.setRows()
+		return m, nil
+	case listsRetrievalMsg:
+		m.lists = msg.lists
+		m.setRows()
+		return m, nil
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m *BhModel) KeyMap() help.KeyMap {
+	return m.km
+}
+
+func (m *BhModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("History · %s", m.event.Name)))
+	var b strings.Builder
+	if m.loaded && len(m.history) == 0 {
+		b.WriteString(m.session.styles.Help.Render(m.session.T("No changes have been recorded for this event yet.")) + "\n")
+	} else {
+		b.WriteString(m.table.View() + "\n")
+	}
+	body := m.session.styles.Base.Render(b.String())
+	footer := m.appBoundaryView(m.help.ShortHelpView(m.km.ShortHelp()))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *BhModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Left,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
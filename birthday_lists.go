@@ -0,0 +1,295 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/phone"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strconv"
+	"strings"
+)
+
+// BIRTHDAY LISTS KEYMAPS
+type lsKeyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	New     key.Binding
+	Invite  key.Binding
+	Collect key.Binding
+	Accept  key.Binding
+	Decline key.Binding
+	Back    key.Binding
+	Help    key.Binding
+	Quit    key.Binding
+}
+
+func (k lsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.New, k.Invite, k.Collect, k.Accept, k.Decline, k.Back, k.Help, k.Quit}
+}
+
+func (k lsKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.New, k.Invite, k.Collect}, // first column
+		{k.Accept, k.Decline, k.Back, k.Quit},      // second column
+	}
+}
+
+var lsKeys = lsKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	New: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new list"),
+	),
+	Invite: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "invite"),
+	),
+	Collect: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "collect birthdays"),
+	),
+	Accept: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "accept invitation"),
+	),
+	Decline: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "decline invitation"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Help: rootKeys.Help,
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c", "q"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// BIRTHDAY LISTS MODEL
+
+// LsModel shows the lists an account belongs to and who else is on them,
+// and answers the invitations waiting for it. Accept and decline act on the
+// oldest invitation.
+type LsModel struct {
+	session     *session
+	table       table.Model
+	lists       []store.List
+	members     map[int][]store.Member
+	invitations []store.Invitation
+	width       int
+	help        help.Model
+	km          lsKeyMap
+	banner      errorBanner
+}
+
+// BIRTHDAY LISTS INITIALIZATION
+
+func lsColumns(p i18n.Printer) []table.Column {
+	return []table.Column{
+		{Title: p.T("Name"), Width: 24},
+		{Title: p.T("Owner"), Width: 18},
+		{Title: p.T("Role"), Width: 10},
+		{Title: p.T("Members"), Width: 8},
+	}
+}
+
+func EmptyBirthdayLists(s *session) LsModel {
+	t := table.New(
+		table.WithColumns(lsColumns(s.printer)),
+		table.WithFocused(true),
+		table.WithHeight(8),
+	)
+	t.SetStyles(s.styles.Table)
+
+	return LsModel{
+		session: s,
+		table:   t,
+		help:    s.styles.NewHelp(),
+		km:      localizeKeys(s.printer, lsKeys),
+	}
+}
+
+// BIRTHDAY LISTS COMMANDS
+
+type birthdayListsMsg struct {
+	lists       []store.List
+	members     map[int][]store.Member
+	invitations []store.Invitation
+}
+
+type invitationAnsweredMsg struct{}
+
+func getBirthdayLists(st store.ListStore, phoneNumber string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		lists, err := st.ListLists(ctx, phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		members := make(map[int][]store.Member, len(lists))
+		for _, l := range lists {
+			if members[l.ID], err = st.ListMembers(ctx, phoneNumber, l.ID); err != nil {
+				return dbErrMsg{err}
+			}
+		}
+		invitations, err := st.ListInvitations(ctx, phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return birthdayListsMsg{lists, members, invitations}
+	}
+}
+
+func answerInvitation(st store.ListStore, phoneNumber string, id int, accept bool) tea.Cmd {
+	return func() tea.Msg {
+		if err := st.RespondToInvitation(context.Background(), phoneNumber, id, accept); err != nil {
+			return dbErrMsg{err}
+		}
+		return invitationAnsweredMsg{}
+	}
+}
+
+func (m *LsModel) setRows() {
+	p := m.session.printer
+	var rows []table.Row
+	for _, l := range m.lists {
+		rows = append(rows, []string{
+			listName(p, l.Name),
+			phone.FormatNational(l.Owner),
+			roleTitle(p, l.Role),
+			strconv.Itoa(len(m.members[l.ID])),
+		})
+	}
+	m.table.SetRows(rows)
+}
+
+// selected returns the highlighted list, if there is one.
+func (m *LsModel) selected() (store.List, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.lists) {
+		return store.List{}, false
+	}
+	return m.lists[i], true
+}
+
+// BIRTHDAY LISTS UPDATE-VIEW LOOP
+
+func (m *LsModel) Init() tea.Cmd {
+	// Init runs again when a form on top of this screen closes, so the new
+	// list or invitation shows.
+	m.table.SetStyles(m.session.styles.Table)
+	m.help.Styles = m.session.styles.KeyHelp
+	return getBirthdayLists(m.session.store, m.session.phoneNumber)
+}
+
+func (m *LsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 120) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.km.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.km.Back):
+			return m, popScreen()
+		case key.Matches(msg, m.km.New):
+			lf := EmptyListForm(m.session)
+			return m, pushScreen(&lf)
+		case key.Matches(msg, m.km.Invite):
+			l, ok := m.selected()
+			if !ok {
+				return m, nil
+			}
+			if l.Role != store.Owner {
+				return m, m.banner.Show(i18n.Errorf("only the owner of %s can invite people", listTitle(m.session.printer, l)))
+			}
+			inf := EmptyInviteForm(m.session, l)
+			return m, pushScreen(&inf)
+		case key.Matches(msg, m.km.Collect):
+			l, ok := m.selected()
+			if !ok {
+				return m, nil
+			}
+			if l.Role != store.Owner {
+				return m, m.banner.Show(i18n.Errorf("only the owner of %s can collect birthdays", listTitle(m.session.printer, l)))
+			}
+			sb := EmptySubmissions(m.session, l)
+			return m, pushScreen(&sb)
+		case key.Matches(msg, m.km.Accept, m.km.Decline):
+			if len(m.invitations) == 0 {
+				return m, nil
+			}
+			accept := key.Matches(msg, m.km.Accept)
+			return m, answerInvitation(m.session.store, m.session.phoneNumber, m.invitations[0].ID, accept)
+		}
+	case birthdayListsMsg:
+		m.lists = msg.lists
+		m.members = msg.members
+		m.invitations = msg.invitations
+		m.setRows()
+		return m, nil
+	case invitationAnsweredMsg:
+		return m, getBirthdayLists(m.session.store, m.session.phoneNumber)
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m *LsModel) KeyMap() help.KeyMap {
+	return m.km
+}
+
+func (m *LsModel) View() string {
+	p := m.session.printer
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("Shared Lists")))
+	var b strings.Builder
+	b.WriteString(m.table.View() + "\n")
+	if l, ok := m.selected(); ok {
+		members := m.session.styles.StatusHeader.Render(m.session.T("Members")) + "\n"
+		for _, member := range m.members[l.ID] {
+			members += phone.FormatNational(member.PhoneNumber) + " · " + roleTitle(p, member.Role) + "\n"
+		}
+		b.WriteString(m.session.styles.Status.Width(72).Render(strings.TrimSuffix(members, "\n")) + "\n")
+	}
+	if len(m.invitations) > 0 {
+		invitations := m.session.styles.StatusHeader.Render(m.session.T("Invitations")) + "\n"
+		for _, inv := range m.invitations {
+			invitations += m.session.T("%s invited you to %s as %s", phone.FormatNational(inv.From), listName(p, inv.ListName), roleTitle(p, inv.Role)) + "\n"
+		}
+		invitations += m.session.styles.Help.Render(m.session.T("Press a to accept or x to decline the first invitation."))
+		b.WriteString("\n" + m.session.styles.Status.Width(72).Render(invitations) + "\n")
+	}
+	body := m.session.styles.Base.Render(b.String())
+	footer := m.appBoundaryView(m.help.ShortHelpView(m.km.ShortHelp()))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *LsModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Left,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
@@ -1,15 +1,20 @@
 package main
 
 import (
-	"database/sql"
-	"fmt"
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/phone"
+	"ashwindharne/bdaybot/store"
+	"context"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	_ "modernc.org/sqlite"
+	"slices"
 	"strconv"
+	"time"
 )
 
 // BIRTHDAY TABLE KEYMAPS
@@ -18,18 +23,25 @@ type btKeyMap struct {
 	Down     key.Binding
 	Create   key.Binding
 	Edit     key.Binding
+	Details  key.Binding
+	Calendar key.Binding
+	Filter   key.Binding
 	Settings key.Binding
+	Upcoming key.Binding
+	Lists    key.Binding
+	Orgs     key.Binding
+	Help     key.Binding
 	Quit     key.Binding
 }
 
 func (k btKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Create, k.Edit, k.Settings, k.Quit}
+	return []key.Binding{k.Create, k.Edit, k.Details, k.Calendar, k.Settings, k.Help, k.Quit}
 }
 
 func (k btKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Create, k.Edit}, // first column
-		{k.Settings, k.Quit},             // second column
+		{k.Up, k.Down, k.Create, k.Edit, k.Details, k.Calendar},     // first column
+		{k.Filter, k.Settings, k.Upcoming, k.Lists, k.Orgs, k.Quit}, // second column
 	}
 }
 
@@ -44,16 +56,41 @@ var btKeys = btKeyMap{
 	),
 	Create: key.NewBinding(
 		key.WithKeys("c"),
-		key.WithHelp("c", "create birthday"),
+		key.WithHelp("c", "create event"),
 	),
 	Edit: key.NewBinding(
 		key.WithKeys("e", "enter"),
-		key.WithHelp("e", "edit birthday"),
+		key.WithHelp("e", "edit event"),
+	),
+	Details: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "notes & gifts"),
+	),
+	Calendar: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "month view"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "filter by tag"),
 	),
 	Settings: key.NewBinding(
 		key.WithKeys("s"),
 		key.WithHelp("s", "settings"),
 	),
+	Upcoming: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "upcoming reminders"),
+	),
+	Lists: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "shared lists"),
+	),
+	Orgs: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "organizations"),
+	),
+	Help: rootKeys.Help,
 	Quit: key.NewBinding(
 		key.WithKeys("ctrl+c", "q"),
 		key.WithHelp("q", "quit"),
@@ -63,57 +100,50 @@ var btKeys = btKeyMap{
 // BIRTHDAY TABLE MODEL
 
 type BtModel struct {
-	phoneNumber string
-	table       table.Model
+	session   *session
+	table     table.Model
+	reminders []store.Event
+	lists     []store.List
+	// invitations counts the invitations waiting for an answer.
+	invitations int
+	loc         *time.Location
+	tagFilter   string
 	width       int
-	styles      *Styles
-	lg          *lipgloss.Renderer
-	db          *sql.DB
 	help        help.Model
 	km          btKeyMap
+	banner      errorBanner
 }
 
 // BIRTHDAY TABLE INITIALIZATION
-func EmptyBirthdayTable(
-	phoneNumber string,
-	db *sql.DB,
-	lg *lipgloss.Renderer,
-	styles *Styles,
-) BtModel {
-	columns := []table.Column{
+
+func btColumns(p i18n.Printer) []table.Column {
+	return []table.Column{
 		{Title: "ID", Width: 0},
-		{Title: "Name", Width: 24},
-		{Title: "Birthday", Width: 12},
-		{Title: "How Soon?", Width: 16},
+		{Title: p.T("Name"), Width: 24},
+		{Title: p.T("Occasion"), Width: 24},
+		{Title: p.T("Date"), Width: 20},
+		{Title: p.T("How Soon?"), Width: 16},
+		{Title: p.T("Tags"), Width: 32},
 	}
+}
+
+func EmptyBirthdayTable(s *session) BtModel {
 	t := table.New(
-		table.WithColumns(columns),
+		table.WithColumns(btColumns(s.printer)),
 		table.WithFocused(true),
 		table.WithHeight(16),
 	)
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("57")).
-		Bold(false)
-	t.SetStyles(s)
+	t.SetStyles(s.styles.Table)
 
 	// HELP INITIALIZATION
-	h := help.New()
+	h := s.styles.NewHelp()
 
 	m := BtModel{
-		phoneNumber: phoneNumber,
-		table:       t,
-		db:          db,
-		help:        h,
-		km:          btKeys,
-		lg:          lg,
-		styles:      styles,
+		session: s,
+		table:   t,
+		loc:     time.Local,
+		help:    h,
+		km:      localizeKeys(s.printer, btKeys),
 	}
 	return m
 
@@ -121,61 +151,130 @@ func EmptyBirthdayTable(
 
 // BIRTHDAY TABLE COMMANDS
 
+// getBirthdaysSuccessMsg carries the account's timezone along with its
+// events, since how soon each one is depends on the user's today.
 type getBirthdaysSuccessMsg struct {
-	reminders []birthdayReminder
-}
-
-type birthdayReminder struct {
-	id    int
-	name  string
-	month int
-	day   int
-	year  int
+	reminders []store.Event
+	loc       *time.Location
 }
 
-func getBirthdays(db *sql.DB, phoneNumber string) tea.Cmd {
+func getBirthdays(st store.Store, phoneNumber string, c clock.Clock) tea.Cmd {
 	return func() tea.Msg {
-		results, err := db.Query(`
-	select birthdays.id, name, month, day, year
-	from birthdays
-	join phone_numbers on phone_numbers.id = birthdays.phone_number_id
-	where phone_numbers.phone_number = ?
-	ORDER BY
-		CASE
-			WHEN (month > strftime('%m', 'now') OR (month = strftime('%m', 'now') AND day >= strftime('%d', 'now')))
-			THEN date(strftime('%Y', 'now') || '-' || printf('%02d', month) || '-' || printf('%02d', day))
-			ELSE date((strftime('%Y', 'now')+1) || '-' || printf('%02d', month) || '-' || printf('%02d', day))
-		END; `, phoneNumber)
+		ctx := context.Background()
+		loc, err := userLocation(ctx, st, phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		reminders, err := st.ListEvents(ctx, phoneNumber)
 		if err != nil {
-			panic(err)
+			return dbErrMsg{err}
 		}
-		defer results.Close()
-		reminders := []birthdayReminder{}
-		for results.Next() {
-			var r = birthdayReminder{}
-			err := results.Scan(&r.id, &r.name, &r.month, &r.day, &r.year)
-			if err != nil {
-				panic(err)
+		// Dates in lunar and lunisolar calendars move around the Gregorian
+		// year, so the soonest-first ordering is computed here rather than
+		// in the store.
+		today := c.Now().In(loc)
+		slices.SortStableFunc(reminders, func(a, b store.Event) int {
+			return daysToNextBirthday(a.Calendar, a.Month, a.Day, today) - daysToNextBirthday(b.Calendar, b.Month, b.Day, today)
+		})
+		return getBirthdaysSuccessMsg{reminders, loc}
+	}
+}
+
+// allTags returns the sorted, de-duplicated tags used across the table.
+func (m *BtModel) allTags() []string {
+	var tags []string
+	for _, reminder := range m.reminders {
+		for _, tag := range reminder.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
 			}
-			reminders = append(reminders, r)
 		}
-		return getBirthdaysSuccessMsg{reminders}
 	}
+	slices.Sort(tags)
+	return tags
+}
+
+// nextTagFilter cycles from showing everyone through each tag in turn.
+func (m *BtModel) nextTagFilter() string {
+	tags := m.allTags()
+	i := slices.Index(tags, m.tagFilter) // -1 while showing everyone
+	if i+1 >= len(tags) {
+		return ""
+	}
+	return tags[i+1]
+}
+
+func (m *BtModel) setRows() {
+	now := m.session.clock.Now().In(m.loc)
+	p := m.session.printer
+	var rows []table.Row
+	for _, reminder := range m.reminders {
+		if m.tagFilter != "" && !slices.Contains(reminder.Tags, m.tagFilter) {
+			continue
+		}
+		rows = append(
+			rows,
+			[]string{
+				strconv.Itoa(reminder.ID),
+				reminder.Name,
+				events.Occasion(p, reminder.Type, reminder.Label, reminder.Year, nextOccurrence(reminder.Calendar, reminder.Month, reminder.Day, now).Year()),
+				formatEventDate(p, reminder.Calendar, reminder.Month, reminder.Day, reminder.Year),
+				daysTilString(p, reminder.Calendar, reminder.Month, reminder.Day, now),
+				tagBadges(reminder.Tags),
+			},
+		)
+	}
+	m.table.SetRows(rows)
+	m.table.SetCursor(0)
+}
+
+// event returns the loaded event with the given ID.
+func (m *BtModel) event(id int) store.Event {
+	i := slices.IndexFunc(m.reminders, func(e store.Event) bool { return e.ID == id })
+	if i < 0 {
+		return store.Event{ID: id}
+	}
+	return m.reminders[i]
+}
+
+// selectedId returns the ID of the highlighted event, or 0 when the table is
+// empty.
+func (m *BtModel) selectedId() int {
+	row := m.table.SelectedRow()
+	if len(row) == 0 {
+		return 0
+	}
+	id, _ := strconv.Atoi(row[0])
+	return id
 }
 
 // BIRTHDAY TABLE UPDATE-VIEW LOOP
 
 func (m *BtModel) Init() tea.Cmd {
-	return getBirthdays(m.db, m.phoneNumber)
+	// Init runs again when the table is uncovered, which is when a new
+	// theme or locale picked in settings first shows.
+	m.table.SetStyles(m.session.styles.Table)
+	m.table.SetColumns(btColumns(m.session.printer))
+	m.help.Styles = m.session.styles.KeyHelp
+	m.km = localizeKeys(m.session.printer, btKeys)
+	return tea.Batch(
+		getBirthdays(m.session.store, m.session.phoneNumber, m.session.clock),
+		getLists(m.session.store, m.session.phoneNumber),
+		getInvitations(m.session.store, m.session.phoneNumber),
+	)
+}
+
+func (m *BtModel) KeyMap() help.KeyMap {
+	return m.km
 }
 
 func (m *BtModel) appBoundaryView(text string) string {
-	return lipgloss.PlaceHorizontal(
+	return m.session.lg.PlaceHorizontal(
 		m.width,
 		lipgloss.Left,
-		m.styles.HeaderText.Render(text),
+		m.session.styles.HeaderText.Render(text),
 		lipgloss.WithWhitespaceChars("/"),
-		lipgloss.WithWhitespaceForeground(indigo),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
 	)
 }
 
@@ -183,36 +282,60 @@ func (m *BtModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.width = min(msg.Width, 120) - m.styles.Base.GetHorizontalFrameSize()
+		m.width = min(msg.Width, 120) - m.session.styles.Base.GetHorizontalFrameSize()
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.km.Quit):
 			return m, tea.Quit
 		case key.Matches(msg, m.km.Create):
-			newForm := EmptyBirthdayForm(m.phoneNumber, m.db)
-			return EmptyRootModel(m).Navigate(&newForm)
+			newForm := EmptyBirthdayForm(m.session)
+			return m, pushScreen(&newForm)
 		case key.Matches(msg, m.km.Edit):
-			editingId, err := strconv.Atoi(m.table.SelectedRow()[0])
-			if err != nil {
-				panic(err)
+			if id := m.selectedId(); id != 0 {
+				return m, editEvent(m.session, m.lists, m.event(id), &m.banner)
+			}
+			return m, nil
+		case key.Matches(msg, m.km.Details):
+			if id := m.selectedId(); id != 0 {
+				bd := EmptyBirthdayDetail(m.session, id)
+				return m, pushScreen(&bd)
 			}
-			editForm := EditBirthdayForm(m.phoneNumber, editingId, m.db)
-			return EmptyRootModel(m).Navigate(&editForm)
+			return m, nil
+		case key.Matches(msg, m.km.Calendar):
+			bc := EmptyBirthdayCalendar(m.session)
+			return m, pushScreen(&bc)
+		case key.Matches(msg, m.km.Settings):
+			sf := EmptySettingsForm(m.session)
+			return m, pushScreen(&sf)
+		case key.Matches(msg, m.km.Upcoming):
+			ur := EmptyUpcomingReminders(m.session)
+			return m, pushScreen(&ur)
+		case key.Matches(msg, m.km.Lists):
+			ls := EmptyBirthdayLists(m.session)
+			return m, pushScreen(&ls)
+		case key.Matches(msg, m.km.Orgs):
+			orgs := EmptyOrganizations(m.session)
+			return m, pushScreen(&orgs)
+		case key.Matches(msg, m.km.Filter):
+			m.tagFilter = m.nextTagFilter()
+			m.setRows()
+			return m, nil
 		}
 	case getBirthdaysSuccessMsg:
-		var rows []table.Row
-		for _, reminder := range msg.reminders {
-			rows = append(
-				rows,
-				[]string{
-					strconv.Itoa(reminder.id),
-					reminder.name,
-					fmt.Sprintf("%d/%d/%d", reminder.month, reminder.day, reminder.year),
-					daysTilString(reminder.month, reminder.day),
-				},
-			)
-		}
-		m.table.SetRows(rows)
+		m.reminders = msg.reminders
+		m.loc = msg.loc
+		m.setRows()
+		return m, nil
+	case listsRetrievalMsg:
+		m.lists = msg.lists
+		return m, nil
+	case invitationsRetrievalMsg:
+		m.invitations = len(msg.invitations)
+		return m, nil
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
 		return m, nil
 	}
 	m.table, cmd = m.table.Update(msg)
@@ -220,8 +343,15 @@ func (m *BtModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *BtModel) View() string {
-	header := m.appBoundaryView("Birthday Reminders")
-	body := m.styles.Base.Render(m.table.View())
+	title := m.session.T("Birthday Reminders · %s", phone.FormatNational(m.session.phoneNumber))
+	if m.tagFilter != "" {
+		title += " · #" + m.tagFilter
+	}
+	if m.invitations > 0 {
+		title += " · " + m.session.T("Invitations: %d", m.invitations)
+	}
+	header := m.banner.View(m.session, m.appBoundaryView(title))
+	body := m.session.styles.Base.Render(m.table.View())
 	footer := m.appBoundaryView(m.help.ShortHelpView(m.km.ShortHelp()))
 	return header + "\n" + body + "\n" + footer
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/store"
+	"context"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"testing"
+)
+
+func TestBirthdayTableLoadsFromStore(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	st.EnsureAccount(ctx, "+15555550100", "America/New_York")
+	st.CreateEvent(ctx, "+15555550100", store.Event{
+		Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 14, Year: 1990,
+		Tags: []string{"family"},
+	})
+
+	sess := newSession(st, lipgloss.DefaultRenderer())
+	sess.phoneNumber = "+15555550100"
+	m := EmptyBirthdayTable(sess)
+	settle(&m, m.Init())
+
+	rows := m.table.Rows()
+	if len(rows) != 1 || rows[0][1] != "Ann" || rows[0][5] != "#family" {
+		t.Fatalf("rows = %v", rows)
+	}
+	if m.loc.String() != "America/New_York" {
+		t.Errorf("loc = %s, want the account's timezone", m.loc)
+	}
+}
+
+func TestBirthdayTableKeysOnEmptyTable(t *testing.T) {
+	st := store.NewMemory()
+	st.EnsureAccount(context.Background(), "+15555550100", "UTC")
+	sess := newSession(st, lipgloss.DefaultRenderer())
+	sess.phoneNumber = "+15555550100"
+	m := EmptyBirthdayTable(sess)
+	settle(&m, m.Init())
+
+	root := EmptyRootModel(sess, &m)
+	for _, k := range []string{"e", "d"} {
+		next, _ := root.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		if next.(RootModel).crashed {
+			t.Errorf("pressing %q on an empty table panicked", k)
+		}
+	}
+}
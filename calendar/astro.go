@@ -0,0 +1,148 @@
+package calendar
+
+import (
+	"math"
+	"time"
+)
+
+// The Chinese calendar is defined by astronomical events: new moons start
+// months and the sun's longitude decides which month is leap. The routines
+// below follow Jean Meeus, "Astronomical Algorithms" (2nd ed.), using the
+// lower-precision series, which place new moons and solar terms within a few
+// minutes for dates between 1900 and 2100.
+
+const (
+	synodicMonth = 29.530588861
+	tropicalYear = 365.2422
+	// unixEpochJD is the Julian day of 1970-01-01T00:00:00Z.
+	unixEpochJD = 2440587.5
+)
+
+func sinDeg(d float64) float64 {
+	return math.Sin(d * math.Pi / 180)
+}
+
+// deltaT approximates TT - UT in seconds (Espenak & Meeus polynomials).
+func deltaT(year float64) float64 {
+	switch {
+	case year < 1920:
+		t := year - 1900
+		return -2.79 + 1.494119*t - 0.0598939*t*t + 0.0061966*t*t*t - 0.000197*t*t*t*t
+	case year < 1941:
+		t := year - 1920
+		return 21.20 + 0.84493*t - 0.076100*t*t + 0.0020936*t*t*t
+	case year < 1961:
+		t := year - 1950
+		return 29.07 + 0.407*t - t*t/233 + t*t*t/2547
+	case year < 1986:
+		t := year - 1975
+		return 45.45 + 1.067*t - t*t/260 - t*t*t/718
+	case year < 2005:
+		t := year - 2000
+		return 63.86 + 0.3345*t - 0.060374*t*t + 0.0017275*t*t*t + 0.000651814*t*t*t*t + 0.00002373599*t*t*t*t*t
+	case year < 2050:
+		t := year - 2000
+		return 62.92 + 0.32217*t + 0.005589*t*t
+	default:
+		u := (year - 1820) / 100
+		return -20 + 32*u*u - 0.5628*(2150-year)
+	}
+}
+
+// ttToUT converts a Julian ephemeris day to a Julian day in universal time.
+func ttToUT(jde float64) float64 {
+	year := 2000 + (jde-2451545)/365.25
+	return jde - deltaT(year)/86400
+}
+
+// newMoon returns the Julian ephemeris day of lunation k, where k = 0 is
+// the new moon of 2000-01-06 (Meeus chapter 49).
+func newMoon(k float64) float64 {
+	t := k / 1236.85
+	jde := 2451550.09766 + synodicMonth*k + 0.00015437*t*t - 0.000000150*t*t*t + 0.00000000073*t*t*t*t
+	e := 1 - 0.002516*t - 0.0000074*t*t
+	m := 2.5534 + 29.10535670*k - 0.0000014*t*t - 0.00000011*t*t*t
+	mp := 201.5643 + 385.81693528*k + 0.0107582*t*t + 0.00001238*t*t*t - 0.000000058*t*t*t*t
+	f := 160.7108 + 390.67050284*k - 0.0016118*t*t - 0.00000227*t*t*t + 0.000000011*t*t*t*t
+	om := 124.7746 - 1.56375588*k + 0.0020672*t*t + 0.00000215*t*t*t
+	jde += -0.40720*sinDeg(mp) +
+		0.17241*e*sinDeg(m) +
+		0.01608*sinDeg(2*mp) +
+		0.01039*sinDeg(2*f) +
+		0.00739*e*sinDeg(mp-m) -
+		0.00514*e*sinDeg(mp+m) +
+		0.00208*e*e*sinDeg(2*m) -
+		0.00111*sinDeg(mp-2*f) -
+		0.00057*sinDeg(mp+2*f) +
+		0.00056*e*sinDeg(2*mp+m) -
+		0.00042*sinDeg(3*mp) +
+		0.00042*e*sinDeg(m+2*f) +
+		0.00038*e*sinDeg(m-2*f) -
+		0.00024*e*sinDeg(2*mp-m) -
+		0.00017*sinDeg(om) -
+		0.00007*sinDeg(mp+2*m) +
+		0.00004*sinDeg(2*mp-2*f) +
+		0.00004*sinDeg(3*m) +
+		0.00003*sinDeg(mp+m-2*f) +
+		0.00003*sinDeg(2*mp+2*f) -
+		0.00003*sinDeg(mp+m+2*f) +
+		0.00003*sinDeg(mp-m+2*f) -
+		0.00002*sinDeg(mp-m-2*f) -
+		0.00002*sinDeg(3*mp+m) +
+		0.00002*sinDeg(4*mp)
+	return jde
+}
+
+// solarLongitude returns the sun's apparent longitude in degrees [0, 360)
+// at the given Julian ephemeris day (Meeus chapter 25).
+func solarLongitude(jde float64) float64 {
+	t := (jde - 2451545) / 36525
+	l0 := 280.46646 + 36000.76983*t + 0.0003032*t*t
+	m := 357.52911 + 35999.05029*t - 0.0001537*t*t
+	c := (1.914602-0.004817*t-0.000014*t*t)*sinDeg(m) +
+		(0.019993-0.000101*t)*sinDeg(2*m) +
+		0.000289*sinDeg(3*m)
+	om := 125.04 - 1934.136*t
+	lambda := l0 + c - 0.00569 - 0.00478*sinDeg(om)
+	return math.Mod(math.Mod(lambda, 360)+360, 360)
+}
+
+// solarTermAfter returns the first Julian ephemeris day at or after jde when
+// the sun's apparent longitude reaches the given number of degrees.
+func solarTermAfter(longitude float64, jde float64) float64 {
+	delta := math.Mod(longitude-solarLongitude(jde)+360, 360)
+	estimate := jde + delta*tropicalYear/360
+	for i := 0; i < 10; i++ {
+		diff := math.Mod(longitude-solarLongitude(estimate)+540, 360) - 180
+		estimate += diff * tropicalYear / 360
+		if math.Abs(diff) < 1e-7 {
+			break
+		}
+	}
+	return estimate
+}
+
+// julianDay converts a time to a Julian day (UT).
+func julianDay(t time.Time) float64 {
+	return unixEpochJD + float64(t.Unix())/86400
+}
+
+// civilDay returns the Julian day number of the calendar date on which the
+// universal-time instant jd falls, for a zone offsetHours east of UTC.
+func civilDay(jd float64, offsetHours float64) int {
+	return int(math.Floor(jd + 0.5 + offsetHours/24))
+}
+
+// dateOfDay converts a Julian day number back to a calendar date.
+func dateOfDay(jdn int, loc *time.Location) time.Time {
+	unixDays := jdn - 2440588
+	year, month, day := time.Unix(int64(unixDays)*86400, 0).UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// dayOfDate returns the Julian day number of t's calendar date.
+func dayOfDate(t time.Time) int {
+	year, month, day := t.Date()
+	utc := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return int(utc.Unix()/86400) + 2440588
+}
@@ -0,0 +1,119 @@
+// Package calendar finds the next Gregorian occurrence of dates kept in the
+// Gregorian, Chinese lunar or Hebrew calendars. Everything is computed
+// locally; the Chinese calendar is derived from astronomical positions, so
+// results are reliable for roughly 1900-2100.
+package calendar
+
+import (
+	"fmt"
+	"time"
+)
+
+type System string
+
+const (
+	Gregorian System = "gregorian"
+	Chinese   System = "chinese"
+	Hebrew    System = "hebrew"
+)
+
+// Systems lists every supported calendar in the order they're offered in
+// forms.
+var Systems = []System{Gregorian, Chinese, Hebrew}
+
+func (s System) Title() string {
+	switch s {
+	case Chinese:
+		return "Chinese lunar"
+	case Hebrew:
+		return "Hebrew"
+	default:
+		return "Gregorian"
+	}
+}
+
+// Month is a selectable month of a calendar system.
+type Month struct {
+	Number int
+	Name   string
+}
+
+var gregorianMonths = []Month{
+	{1, "January"}, {2, "February"}, {3, "March"}, {4, "April"},
+	{5, "May"}, {6, "June"}, {7, "July"}, {8, "August"},
+	{9, "September"}, {10, "October"}, {11, "November"}, {12, "December"},
+}
+
+var chineseMonths = []Month{
+	{1, "1st month (Zhēngyuè)"}, {2, "2nd month"}, {3, "3rd month"},
+	{4, "4th month"}, {5, "5th month"}, {6, "6th month"},
+	{7, "7th month"}, {8, "8th month"}, {9, "9th month"},
+	{10, "10th month"}, {11, "11th month (Dōngyuè)"}, {12, "12th month (Làyuè)"},
+}
+
+// hebrewMonths are listed in the civil order of the year, from Tishri.
+var hebrewMonths = []Month{
+	{Tishri, "Tishri"}, {Heshvan, "Heshvan"}, {Kislev, "Kislev"},
+	{Tevet, "Tevet"}, {Shevat, "Shevat"}, {Adar, "Adar"},
+	{AdarI, "Adar I"}, {AdarII, "Adar II"}, {Nisan, "Nisan"},
+	{Iyyar, "Iyyar"}, {Sivan, "Sivan"}, {Tammuz, "Tammuz"},
+	{Av, "Av"}, {Elul, "Elul"},
+}
+
+// Months returns the months that can be picked for a date in the system.
+func (s System) Months() []Month {
+	switch s {
+	case Chinese:
+		return chineseMonths
+	case Hebrew:
+		return hebrewMonths
+	default:
+		return gregorianMonths
+	}
+}
+
+// MonthName returns the display name of a month in the system.
+func (s System) MonthName(month int) string {
+	for _, m := range s.Months() {
+		if m.Number == month {
+			return m.Name
+		}
+	}
+	return fmt.Sprintf("month %d", month)
+}
+
+// NextOccurrence returns the first date on or after from's calendar date
+// on which the given month and day of the system fall, at midnight in from's
+// location. Gregorian February 29th falls on the 28th in common years.
+func NextOccurrence(system System, month int, day int, from time.Time) time.Time {
+	switch system {
+	case Chinese:
+		return nextChinese(month, day, from)
+	case Hebrew:
+		return nextHebrew(month, day, from)
+	default:
+		return nextGregorian(month, day, from)
+	}
+}
+
+// DaysUntil returns the number of calendar days from from's date until the
+// next occurrence, so 0 means today.
+func DaysUntil(system System, month int, day int, from time.Time) int {
+	return dayOfDate(NextOccurrence(system, month, day, from)) - dayOfDate(from)
+}
+
+func gregorianDate(year int, month int, day int, loc *time.Location) time.Time {
+	if month == 2 && day == 29 && !(year%4 == 0 && (year%100 != 0 || year%400 == 0)) {
+		day = 28
+	}
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+}
+
+func nextGregorian(month int, day int, from time.Time) time.Time {
+	year, fMonth, fDay := from.Date()
+	today := time.Date(year, fMonth, fDay, 0, 0, 0, 0, from.Location())
+	if d := gregorianDate(year, month, day, from.Location()); !d.Before(today) {
+		return d
+	}
+	return gregorianDate(year+1, month, day, from.Location())
+}
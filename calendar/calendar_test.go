@@ -0,0 +1,41 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	from := time.Date(2024, time.October, 3, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		system     System
+		month, day int
+		want       string
+	}{
+		{"gregorian later this year", Gregorian, 12, 25, "2024-12-25"},
+		{"gregorian today", Gregorian, 10, 3, "2024-10-03"},
+		{"gregorian next year", Gregorian, 1, 1, "2025-01-01"},
+		{"gregorian leap day in common year", Gregorian, 2, 29, "2025-02-28"},
+		{"chinese new year", Chinese, 1, 1, "2025-01-29"},
+		{"chinese late month", Chinese, 12, 8, "2025-01-07"},
+		{"hebrew today", Hebrew, Tishri, 1, "2024-10-03"},
+		{"hebrew passover", Hebrew, Nisan, 15, "2025-04-13"},
+	}
+	for _, tt := range tests {
+		got := NextOccurrence(tt.system, tt.month, tt.day, from).Format(time.DateOnly)
+		if got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDaysUntil(t *testing.T) {
+	from := time.Date(2024, time.December, 31, 23, 0, 0, 0, time.UTC)
+	if got := DaysUntil(Gregorian, 12, 31, from); got != 0 {
+		t.Errorf("DaysUntil today = %d, want 0", got)
+	}
+	if got := DaysUntil(Gregorian, 1, 1, from); got != 1 {
+		t.Errorf("DaysUntil tomorrow = %d, want 1", got)
+	}
+}
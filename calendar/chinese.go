@@ -0,0 +1,142 @@
+package calendar
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Chinese calendar days are counted in China Standard Time.
+const beijingOffset = 8.0
+
+type lunarMonth struct {
+	number int
+	leap   bool
+	start  int // Julian day number of the first day
+	length int
+}
+
+var (
+	suiMu    sync.Mutex
+	suiCache = map[int][]lunarMonth{}
+)
+
+func newMoonDay(k float64) int {
+	return civilDay(ttToUT(newMoon(k)), beijingOffset)
+}
+
+// lunationOnOrBefore returns the lunation number of the new moon that starts
+// the month containing the given day.
+func lunationOnOrBefore(day int) float64 {
+	jd := float64(day) - 0.5
+	k := math.Floor((jd-2451550.09766)/synodicMonth) + 1
+	for newMoonDay(k) > day {
+		k--
+	}
+	for newMoonDay(k+1) <= day {
+		k++
+	}
+	return k
+}
+
+// winterSolsticeDay returns the day of the December solstice in the given
+// Gregorian year.
+func winterSolsticeDay(year int) (int, float64) {
+	start := julianDay(time.Date(year, time.December, 1, 0, 0, 0, 0, time.UTC))
+	jde := solarTermAfter(270, start)
+	return civilDay(ttToUT(jde), beijingOffset), jde
+}
+
+// chineseSui returns the months of the sui ending in the given Gregorian
+// year: from the 11th month, which contains the previous December solstice,
+// up to but excluding the 11th month containing this year's. Months 1-10 of
+// the lunar year that begins in this Gregorian year fall within it.
+func chineseSui(year int) []lunarMonth {
+	suiMu.Lock()
+	defer suiMu.Unlock()
+	if months, ok := suiCache[year]; ok {
+		return months
+	}
+
+	solstice, solsticeJDE := winterSolsticeDay(year - 1)
+	nextSolstice, _ := winterSolsticeDay(year)
+	first := lunationOnOrBefore(solstice)
+	last := lunationOnOrBefore(nextSolstice)
+	count := int(last - first)
+	starts := make([]int, count+1)
+	for i := range starts {
+		starts[i] = newMoonDay(first + float64(i))
+	}
+
+	// A sui with 13 months has a leap month: the first one after the 11th
+	// that contains no principal solar term (a multiple of 30°).
+	leapIndex := -1
+	if count == 13 {
+		terms := []int{solstice}
+		jde := solsticeJDE
+		for i := 1; i < 14; i++ {
+			jde = solarTermAfter(math.Mod(270+30*float64(i), 360), jde+1)
+			terms = append(terms, civilDay(ttToUT(jde), beijingOffset))
+		}
+		for i := 1; i < count && leapIndex == -1; i++ {
+			hasTerm := false
+			for _, term := range terms {
+				if term >= starts[i] && term < starts[i+1] {
+					hasTerm = true
+					break
+				}
+			}
+			if !hasTerm {
+				leapIndex = i
+			}
+		}
+	}
+
+	var months []lunarMonth
+	number := 10
+	for i := 0; i < count; i++ {
+		leap := i == leapIndex
+		if !leap {
+			number = number%12 + 1
+		}
+		months = append(months, lunarMonth{
+			number: number,
+			leap:   leap,
+			start:  starts[i],
+			length: starts[i+1] - starts[i],
+		})
+	}
+	suiCache[year] = months
+	return months
+}
+
+// chineseToDay returns the Julian day number of the given day of a
+// (non-leap) month in the lunar year beginning in the given Gregorian year.
+// Days past the end of a short month fall on its last day. Months and days
+// out of range, which only bad data has, are clamped to the nearest ones
+// rather than failing.
+func chineseToDay(year int, month int, day int) int {
+	month = min(max(month, 1), 12)
+	sui := chineseSui(year)
+	if month > 10 {
+		sui = chineseSui(year + 1)
+	}
+	// A sui has every month once besides its leap month, so this always
+	// finds it.
+	var m lunarMonth
+	for _, m = range sui {
+		if m.number == month && !m.leap {
+			break
+		}
+	}
+	return m.start + min(max(day, 1), m.length) - 1
+}
+
+func nextChinese(month int, day int, from time.Time) time.Time {
+	today := dayOfDate(from)
+	for year := from.Year() - 1; ; year++ {
+		if d := chineseToDay(year, month, day); d >= today {
+			return dateOfDay(d, from.Location())
+		}
+	}
+}
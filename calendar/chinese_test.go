@@ -0,0 +1,77 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChineseNewYear(t *testing.T) {
+	tests := []struct {
+		year int
+		want string
+	}{
+		{1990, "1990-01-27"},
+		{2000, "2000-02-05"},
+		{2020, "2020-01-25"},
+		{2021, "2021-02-12"},
+		{2022, "2022-02-01"},
+		{2023, "2023-01-22"},
+		{2024, "2024-02-10"},
+		{2025, "2025-01-29"},
+		{2026, "2026-02-17"},
+		{2027, "2027-02-06"},
+		{2030, "2030-02-03"},
+	}
+	for _, tt := range tests {
+		got := dateOfDay(chineseToDay(tt.year, 1, 1), time.UTC).Format(time.DateOnly)
+		if got != tt.want {
+			t.Errorf("new year %d = %s, want %s", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestChineseLeapYears(t *testing.T) {
+	// Mid-Autumn Festival (8/15) in years with a leap month before it.
+	tests := []struct {
+		year int
+		want string
+	}{
+		{2020, "2020-10-01"}, // leap 4th month
+		{2023, "2023-09-29"}, // leap 2nd month
+		{2025, "2025-10-06"}, // leap 6th month
+		{2024, "2024-09-17"},
+	}
+	for _, tt := range tests {
+		got := dateOfDay(chineseToDay(tt.year, 8, 15), time.UTC).Format(time.DateOnly)
+		if got != tt.want {
+			t.Errorf("mid-autumn %d = %s, want %s", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestChineseLateMonths(t *testing.T) {
+	// Laba Festival (12/8) of the lunar year beginning in 2024 falls in 2025.
+	got := dateOfDay(chineseToDay(2024, 12, 8), time.UTC).Format(time.DateOnly)
+	if want := "2025-01-07"; got != want {
+		t.Errorf("laba 2024 = %s, want %s", got, want)
+	}
+}
+
+func TestChineseOutOfRange(t *testing.T) {
+	// Bad data is clamped rather than panicking.
+	tests := []struct {
+		month, day int
+		want       string
+	}{
+		{0, 1, "2024-02-10"},
+		{13, 1, "2024-12-31"},
+		{1, 0, "2024-02-10"},
+		{1, 40, "2024-03-09"},
+	}
+	for _, tt := range tests {
+		got := dateOfDay(chineseToDay(2024, tt.month, tt.day), time.UTC).Format(time.DateOnly)
+		if got != tt.want {
+			t.Errorf("%d/%d 2024 = %s, want %s", tt.month, tt.day, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package calendar
+
+import "time"
+
+// Hebrew months are numbered from Nisan as in Reingold & Dershowitz,
+// "Calendrical Calculations", with Adar II as month 13 in leap years. AdarI
+// is not a month number there: it records a date in Adar I of a leap year,
+// which is kept in Adar I in leap years, while a plain Adar date moves to
+// Adar II.
+const (
+	Nisan   = 1
+	Iyyar   = 2
+	Sivan   = 3
+	Tammuz  = 4
+	Av      = 5
+	Elul    = 6
+	Tishri  = 7
+	Heshvan = 8
+	Kislev  = 9
+	Tevet   = 10
+	Shevat  = 11
+	Adar    = 12
+	AdarII  = 13
+	AdarI   = 14
+)
+
+// hebrewEpoch is the fixed (R.D.) date of 1 Tishri, year 1.
+const hebrewEpoch = -1373427
+
+// fixedToJDN converts an R.D. fixed date to a Julian day number.
+const fixedToJDN = 1721425
+
+func hebrewLeapYear(year int) bool {
+	return (7*year+1)%19 < 7
+}
+
+func hebrewCalendarElapsedDays(year int) int {
+	monthsElapsed := (235*year - 234) / 19
+	partsElapsed := 12084 + 13753*monthsElapsed
+	days := 29*monthsElapsed + partsElapsed/25920
+	if (3*(days+1))%7 < 3 {
+		return days + 1
+	}
+	return days
+}
+
+func hebrewYearLengthCorrection(year int) int {
+	ny0 := hebrewCalendarElapsedDays(year - 1)
+	ny1 := hebrewCalendarElapsedDays(year)
+	ny2 := hebrewCalendarElapsedDays(year + 1)
+	if ny2-ny1 == 356 {
+		return 2
+	} else if ny1-ny0 == 382 {
+		return 1
+	}
+	return 0
+}
+
+func hebrewNewYear(year int) int {
+	return hebrewEpoch + hebrewCalendarElapsedDays(year) + hebrewYearLengthCorrection(year)
+}
+
+func lastMonthOfHebrewYear(year int) int {
+	if hebrewLeapYear(year) {
+		return AdarII
+	}
+	return Adar
+}
+
+func lastDayOfHebrewMonth(month int, year int) int {
+	daysInYear := hebrewNewYear(year+1) - hebrewNewYear(year)
+	switch {
+	case month == Iyyar, month == Tammuz, month == Elul, month == Tevet, month == AdarII:
+		return 29
+	case month == Adar && !hebrewLeapYear(year):
+		return 29
+	case month == Heshvan && daysInYear%10 != 5:
+		return 29
+	case month == Kislev && daysInYear%10 == 3:
+		return 29
+	}
+	return 30
+}
+
+// hebrewToFixed returns the R.D. fixed date of a Hebrew date.
+func hebrewToFixed(year int, month int, day int) int {
+	fixed := hebrewNewYear(year) + day - 1
+	if month < Tishri {
+		for m := Tishri; m <= lastMonthOfHebrewYear(year); m++ {
+			fixed += lastDayOfHebrewMonth(m, year)
+		}
+		for m := Nisan; m < month; m++ {
+			fixed += lastDayOfHebrewMonth(m, year)
+		}
+	} else {
+		for m := Tishri; m < month; m++ {
+			fixed += lastDayOfHebrewMonth(m, year)
+		}
+	}
+	return fixed
+}
+
+// observedHebrewMonth maps a stored month to the month it's observed in
+// during the given year, resolving Adar in leap and common years.
+func observedHebrewMonth(month int, year int) int {
+	leap := hebrewLeapYear(year)
+	switch month {
+	case Adar, AdarII:
+		if leap {
+			return AdarII
+		}
+		return Adar
+	case AdarI:
+		return Adar
+	}
+	return month
+}
+
+// hebrewToDay returns the Julian day number on which a Hebrew month and day
+// fall in the given Hebrew year. Days past the end of a short month, such as
+// 30 Heshvan, fall on its last day.
+func hebrewToDay(year int, month int, day int) int {
+	month = observedHebrewMonth(month, year)
+	day = min(day, lastDayOfHebrewMonth(month, year))
+	return hebrewToFixed(year, month, day) + fixedToJDN
+}
+
+func nextHebrew(month int, day int, from time.Time) time.Time {
+	today := dayOfDate(from)
+	for year := from.Year() + 3759; ; year++ {
+		if d := hebrewToDay(year, month, day); d >= today {
+			return dateOfDay(d, from.Location())
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHebrewToDay(t *testing.T) {
+	tests := []struct {
+		name             string
+		year, month, day int
+		want             string
+	}{
+		{"Rosh Hashanah 5785", 5785, Tishri, 1, "2024-10-03"},
+		{"Rosh Hashanah 5786", 5786, Tishri, 1, "2025-09-23"},
+		{"Passover 5784", 5784, Nisan, 15, "2024-04-23"},
+		{"Hanukkah 5785", 5785, Kislev, 25, "2024-12-26"},
+		{"Purim 5784 (leap)", 5784, Adar, 14, "2024-03-24"},
+		{"Purim Katan 5784", 5784, AdarI, 14, "2024-02-23"},
+		{"Purim 5785", 5785, Adar, 14, "2025-03-14"},
+		{"Purim 5785 from Adar II", 5785, AdarII, 14, "2025-03-14"},
+	}
+	for _, tt := range tests {
+		got := dateOfDay(hebrewToDay(tt.year, tt.month, tt.day), time.UTC).Format(time.DateOnly)
+		if got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHebrewShortMonths(t *testing.T) {
+	// 5784 has 383 days, so Heshvan has 29 days and 30 Heshvan falls on its
+	// last day.
+	got := dateOfDay(hebrewToDay(5784, Heshvan, 30), time.UTC).Format(time.DateOnly)
+	if want := "2023-11-13"; got != want {
+		t.Errorf("30 Heshvan 5784 = %s, want %s", got, want)
+	}
+}
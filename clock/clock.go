@@ -0,0 +1,76 @@
+// Package clock lets the app, the store and the notifier agree on what time
+// it is. Everything that depends on the date takes a Clock rather than
+// calling time.Now, so tests can fix the time and the -now flag can replay
+// any day.
+package clock
+
+import (
+	"fmt"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the real time.
+var System Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Fixed is a clock stopped at t.
+func Fixed(t time.Time) Clock {
+	return fixedClock{t}
+}
+
+type fixedClock struct {
+	t time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.t
+}
+
+// StartingAt is a clock that reads start when it's created and then keeps
+// time like the real one, so a session started "on Dec 28" still ticks.
+func StartingAt(start time.Time) Clock {
+	return offsetClock{start.Sub(time.Now())}
+}
+
+type offsetClock struct {
+	offset time.Duration
+}
+
+func (c offsetClock) Now() time.Time {
+	return time.Now().Add(c.offset)
+}
+
+// Parse reads a -now flag value: an RFC 3339 time such as
+// 2024-12-28T09:00:00-05:00, or a date such as 2024-12-28, which means
+// midnight in loc.
+func Parse(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, loc); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a date like 2024-12-28 nor a time like 2024-12-28T09:00:00-05:00", s)
+}
+
+// FromFlag returns the clock for a -now flag value: the system clock when
+// it's empty, and otherwise a clock starting at the time it names.
+func FromFlag(value string) (Clock, error) {
+	if value == "" {
+		return System, nil
+	}
+	start, err := Parse(value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return StartingAt(start), nil
+}
@@ -0,0 +1,42 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-12-28", time.Date(2024, time.December, 28, 0, 0, 0, 0, ny)},
+		{"2024-12-28T09:00:00-05:00", time.Date(2024, time.December, 28, 9, 0, 0, 0, ny)},
+		{"2024-12-28T14:00:00Z", time.Date(2024, time.December, 28, 9, 0, 0, 0, ny)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, ny)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+		} else if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"", "12/28/2024", "tomorrow"} {
+		if _, err := Parse(in, ny); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestStartingAt(t *testing.T) {
+	start := time.Date(2024, time.December, 28, 9, 0, 0, 0, time.UTC)
+	c := StartingAt(start)
+	if got := c.Now(); got.Before(start) || got.Sub(start) > time.Minute {
+		t.Errorf("Now() = %v right after starting at %v", got, start)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/health"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/charmbracelet/log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// backupPattern matches the files backups are written to. Their names sort
+// in the order they were taken.
+const backupPattern = "bdaybot-*.sqlite"
+
+// backupName names a backup taken at t, with an optional note of why.
+func backupName(t time.Time, note string) string {
+	name := "bdaybot-" + t.UTC().Format("20060102T150405Z")
+	if note != "" {
+		name += "-" + note
+	}
+	return name + ".sqlite"
+}
+
+// backup writes a backup of st into dir and then deletes all but the
+// newest keep backups there, returning the new backup's path.
+func backup(ctx context.Context, st *store.SQLite, dir string, note string, keep int) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, backupName(time.Now(), note))
+	if err := st.Backup(ctx, path); err != nil {
+		return "", fmt.Errorf("backing up to %s: %w", path, err)
+	}
+	backups, err := filepath.Glob(filepath.Join(dir, backupPattern))
+	if err != nil {
+		return "", err
+	}
+	slices.Sort(backups)
+	for len(backups) > keep {
+		if err := os.Remove(backups[0]); err != nil {
+			return "", err
+		}
+		log.Info("Deleted old backup", "path", backups[0])
+		backups = backups[1:]
+	}
+	return path, nil
+}
+
+// runBackup implements admin backup, which backs up the database while it's
+// in use, once or every -every until it's stopped, keeping the newest -keep
+// backups.
+func runBackup(args []string) {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	dirPtr := flags.String("dir", "backups", "directory to write backups to")
+	keepPtr := flags.Int("keep", 7, "number of backups to keep in -dir, deleting older ones")
+	everyPtr := flags.Duration("every", 0, "instead of backing up once, back up at this interval, e.g. 24h, until stopped")
+	flags.Parse(args)
+	if *keepPtr < 1 {
+		fmt.Println("-keep must be at least 1")
+		os.Exit(2)
+	}
+	if *everyPtr < 0 {
+		fmt.Println("-every can't be negative")
+		os.Exit(2)
+	}
+	st := openStore()
+	defer st.Close()
+
+	repeat(*everyPtr, func(ctx context.Context) error {
+		path, err := backup(ctx, st, *dirPtr, "", *keepPtr)
+		if err != nil {
+			return err
+		}
+		// Scripts taking a single backup read its path from stdout.
+		if *everyPtr == 0 {
+			fmt.Println(path)
+		} else {
+			log.Info("Backed up", "path", path)
+		}
+		return nil
+	})
+}
+
+// runRestore implements admin restore, which replaces the database's
+// contents with a backup's. The backup has to be intact and at the schema
+// version this build expects. The current contents are backed up into -dir
+// first, so a restore can be undone.
+func runRestore(args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	dirPtr := flags.String("dir", "backups", "directory to back the current database up to before restoring")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Println("usage: admin restore [-dir backups] backup.sqlite")
+		os.Exit(2)
+	}
+	path := flags.Arg(0)
+	ctx := context.Background()
+
+	b := openExisting(path)
+	err := b.IntegrityCheck(ctx)
+	if err == nil {
+		if err = health.Migrated(b).Run(ctx); err != nil {
+			err = fmt.Errorf("%w; migrate it with golang-migrate before restoring", err)
+		}
+	}
+	b.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		os.Exit(1)
+	}
+
+	st := openStore()
+	defer st.Close()
+	// Keep every backup there is; the one being restored may be the oldest.
+	previous, err := backup(ctx, st, *dirPtr, "before-restore", math.MaxInt)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := st.Restore(ctx, path); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Restored %s. The database as it was is in %s.\n", path, previous)
+}
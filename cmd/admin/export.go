@@ -0,0 +1,48 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/export"
+	"ashwindharne/bdaybot/phone"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// runExport implements admin export, which writes everything stored about
+// an account as JSON, for the account's owner to take elsewhere.
+func runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	phonePtr := flags.String("phone", "", "phone number of the account to export")
+	outPtr := flags.String("o", "", "file to write the archive to instead of standard output")
+	flags.Parse(args)
+	number, err := phone.Parse(*phonePtr, "US")
+	if err != nil {
+		fmt.Println("-phone:", err)
+		os.Exit(2)
+	}
+	st := openStore()
+	defer st.Close()
+
+	archive, err := export.Build(context.Background(), st, number.E164(), time.Now().UTC())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "exporting %s: %v\n", number.E164(), err)
+		os.Exit(1)
+	}
+	out := os.Stdout
+	if *outPtr != "" {
+		if out, err = os.OpenFile(*outPtr, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(archive); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
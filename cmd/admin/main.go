@@ -0,0 +1,101 @@
+// Command admin looks after bdaybot's database:
+//
+//	admin backup -dir backups [-keep 7] [-every 24h]
+//	admin restore [-dir backups] backups/bdaybot-20261019T090000Z.sqlite
+//	admin export -phone +15555550100 [-o archive.json]
+//	admin deletions
+//	admin purge [-delivery-days 90] [-every 1h]
+//
+// It uses the database at $DB_PATH, or db.sqlite by default, like the other
+// commands.
+package main
+
+import (
+	"ashwindharne/bdaybot/store"
+	"context"
+	"fmt"
+	"github.com/charmbracelet/log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// dbPath is the database the commands share.
+func dbPath() string {
+	if path := os.Getenv("DB_PATH"); path != "" {
+		return path
+	}
+	return "db.sqlite"
+}
+
+// openStore opens the database at dbPath, which must already exist. Opening
+// SQLite creates the file it's given, which would hide a wrong path.
+func openStore() *store.SQLite {
+	return openExisting(dbPath())
+}
+
+// openExisting opens the SQLite database at path, exiting if there's no
+// file there.
+func openExisting(path string) *store.SQLite {
+	if _, err := os.Stat(path); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	st, err := store.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	return st
+}
+
+// repeat calls run once, exiting if it fails. When every isn't zero it
+// instead calls run at that interval until the process is stopped, logging
+// failures to be retried the next time.
+func repeat(every time.Duration, run func(ctx context.Context) error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	for {
+		err := run(ctx)
+		if every == 0 {
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err != nil {
+			log.Error("Failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(every):
+		}
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: admin backup|restore|export|deletions|purge [flags]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "backup":
+		runBackup(os.Args[2:])
+	case "restore":
+		runRestore(os.Args[2:])
+	case "export":
+		runExport(os.Args[2:])
+	case "deletions":
+		runDeletions(os.Args[2:])
+	case "purge":
+		runPurge(os.Args[2:])
+	default:
+		usage()
+	}
+}
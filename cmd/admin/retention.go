@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/charmbracelet/log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// runDeletions implements admin deletions, which reports the accounts
+// scheduled to be deleted and how much goes with each.
+func runDeletions(args []string) {
+	flags := flag.NewFlagSet("deletions", flag.ExitOnError)
+	flags.Parse(args)
+	st := openStore()
+	defer st.Close()
+
+	deletions, err := st.ListScheduledDeletions(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if len(deletions) == 0 {
+		fmt.Println("No accounts are scheduled to be deleted.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACCOUNT\tDUE\tLISTS\tEVENTS")
+	for _, d := range deletions {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", d.PhoneNumber, d.DueAt.Format("2006-01-02 15:04 MST"), d.Lists, d.Events)
+	}
+	w.Flush()
+}
+
+// runPurge implements admin purge, which deletes the accounts whose grace
+// period is over and the delivery log older than -delivery-days, once or
+// every -every until it's stopped.
+func runPurge(args []string) {
+	flags := flag.NewFlagSet("purge", flag.ExitOnError)
+	deliveryDaysPtr := flags.Int("delivery-days", 90, "days to keep the log of reminders and announcements sent, or 0 to keep all of it")
+	everyPtr := flags.Duration("every", 0, "instead of purging once, purge at this interval, e.g. 1h, until stopped")
+	flags.Parse(args)
+	if *deliveryDaysPtr < 0 {
+		fmt.Println("-delivery-days can't be negative")
+		os.Exit(2)
+	}
+	if *everyPtr < 0 {
+		fmt.Println("-every can't be negative")
+		os.Exit(2)
+	}
+	st := openStore()
+	defer st.Close()
+
+	repeat(*everyPtr, func(ctx context.Context) error {
+		now := time.Now()
+		deleted, err := st.DeleteDueAccounts(ctx, now)
+		if err != nil {
+			return fmt.Errorf("deleting accounts: %w", err)
+		}
+		for _, phoneNumber := range deleted {
+			log.Info("Deleted account", "phone", phoneNumber)
+		}
+		if *deliveryDaysPtr == 0 {
+			return nil
+		}
+		before := now.AddDate(0, 0, -*deliveryDaysPtr)
+		n, err := st.PurgeDeliveries(ctx, before)
+		if err != nil {
+			return fmt.Errorf("purging the delivery log: %w", err)
+		}
+		log.Info("Purged delivery log", "deliveries", n, "before", before.Format(time.DateOnly))
+		return nil
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/health"
+	"ashwindharne/bdaybot/metrics"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"errors"
+	"github.com/charmbracelet/log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// runDaemon runs at the top of every hour until it's stopped, serving
+// metrics and health checks on addr in the meantime. The first run waits
+// for the next hour, as the hour it starts in may already have been run by
+// the process it replaced.
+func runDaemon(addr string, st *store.SQLite, reg *metrics.Registry, m *runMetrics) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", reg)
+	health.Routes(mux, st)
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	log.Info("Starting notifier", "addr", addr)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Could not start server", "error", err)
+			stop()
+		}
+	}()
+
+	for {
+		next := time.Now().Truncate(time.Hour).Add(time.Hour)
+		select {
+		case <-ctx.Done():
+			log.Info("Stopping notifier")
+			shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := srv.Shutdown(shutdown); err != nil {
+				log.Error("Could not stop server", "error", err)
+			}
+			return
+		case <-time.After(time.Until(next)):
+		}
+		// The run is for the hour it was due, however late the timer fired.
+		if err := run(ctx, st, next, m); err != nil {
+			log.Error("Run failed", "hour", next, "error", err)
+		}
+	}
+}
@@ -1,65 +1,146 @@
 package main
 
 import (
-	"database/sql"
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/metrics"
+	"ashwindharne/bdaybot/notifier"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"time"
 )
 
-type reminder struct {
-	phoneNumber string
-	month       int
-	day         int
-	year        int
-	name        string
-}
-
-func main() {
+// openStore opens the database at $DB_PATH, or db.sqlite by default,
+// timing its statements in reg.
+func openStore(reg *metrics.Registry) *store.SQLite {
 	dbPath := os.Getenv("DB_PATH")
 	if dbPath == "" {
 		dbPath = "db.sqlite"
 	}
-	//twilioAccountSid := os.Getenv("TWILIO_ACCOUNT_SID")
-	//twilioAuthToken := os.Getenv("TWILIO_AUTH_TOKEN")
-	//twilioPhoneNumber := os.Getenv("TWILIO_PHONE_NUMBER")
-	db, err := sql.Open("sqlite", dbPath)
+	queries := reg.Histogram("bdaybot_db_query_duration_seconds", "Time taken by database statements.", metrics.DefaultBuckets, "op")
+	st, err := store.OpenObserved(dbPath, func(op string, took time.Duration) {
+		queries.Observe(took.Seconds(), op)
+	})
 	if err != nil {
 		panic(err)
 	}
-	reminderQuery := `
-SELECT phone_numbers.phone_number, birthdays.name, birthdays.month, birthdays.day, birthdays.year
-FROM birthdays
-JOIN phone_numbers ON phone_numbers.id = birthdays.phone_number_id
-WHERE 
-    phone_numbers.enabled = TRUE
-    AND (
-        (strftime('%m', 'now') = printf('%02d', birthdays.month) AND 
-         cast(strftime('%d', 'now') as integer) <= birthdays.day AND 
-         birthdays.day - cast(strftime('%d', 'now') as integer) < phone_numbers.notification_days)
-        OR 
-        (strftime('%m', 'now') != printf('%02d', birthdays.month) AND 
-         (julianday(printf('%04d-%02d-%02d', strftime('%Y', 'now'), birthdays.month, birthdays.day)) - julianday('now')) < phone_numbers.notification_days
-        )
-    )
-    AND cast(strftime('%H', 'now', 'utc') as integer) = phone_numbers.notification_hour_utc;
-`
-	reminderResults, err := db.Query(reminderQuery)
+	return st
+}
+
+// webhookChannel labels the announcements posted to team channels, next to
+// notifier.Channel for reminders.
+const webhookChannel = "webhook"
+
+// runMetrics are what runs report about themselves.
+type runMetrics struct {
+	sent        *metrics.Counter
+	failed      *metrics.Counter
+	duration    *metrics.Histogram
+	lastSuccess *metrics.Gauge
+}
+
+func newRunMetrics(reg *metrics.Registry) *runMetrics {
+	return &runMetrics{
+		sent:        reg.Counter("bdaybot_reminders_sent_total", "Reminders and announcements sent.", "channel"),
+		failed:      reg.Counter("bdaybot_reminders_failed_total", "Reminders and announcements that couldn't be sent.", "channel"),
+		duration:    reg.Histogram("bdaybot_notifier_run_duration_seconds", "Time taken by a run of the notifier.", metrics.DefaultBuckets),
+		lastSuccess: reg.Gauge("bdaybot_notifier_last_success_timestamp_seconds", "When the last run that sent everything finished."),
+	}
+}
+
+// logDelivery adds a message to the delivery log. A message that went out
+// but couldn't be logged still went out, so the run carries on.
+func logDelivery(ctx context.Context, st store.DeliveryStore, d store.Delivery) {
+	if err := st.RecordDelivery(ctx, d); err != nil {
+		fmt.Fprintf(os.Stderr, "logging delivery: %v\n", err)
+	}
+}
+
+// run sends the reminders and posts the announcements due at now. One
+// message that can't be sent shouldn't keep the others from going out, so
+// failures are reported once everything has been tried.
+func run(ctx context.Context, st store.Store, now time.Time, m *runMetrics) error {
+	defer m.duration.ObserveSince(time.Now())
+	reminders, err := notifier.Due(ctx, st, now)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	failures := 0
+	sender := notifier.WriterSender{W: os.Stdout}
+	for _, reminder := range reminders {
+		d := store.Delivery{Channel: notifier.Channel, PhoneNumber: reminder.PhoneNumber, Text: reminder.Message()}
+		if err := sender.Send(ctx, d.PhoneNumber, d.Text); err != nil {
+			fmt.Fprintf(os.Stderr, "sending to %s: %v\n", d.PhoneNumber, err)
+			m.failed.Inc(notifier.Channel)
+			failures++
+			d.Error = err.Error()
+		} else {
+			m.sent.Inc(notifier.Channel)
+		}
+		logDelivery(ctx, st, d)
 	}
-	defer reminderResults.Close()
 
-	var reminders []reminder
-	for reminderResults.Next() {
-		reminderResult := reminder{}
-		err := reminderResults.Scan(&reminderResult.phoneNumber, &reminderResult.name, &reminderResult.month, &reminderResult.day, &reminderResult.year)
-		if err != nil {
-			panic(err)
+	// Organizations' birthdays go to their team channel instead.
+	announcements, err := notifier.Announcements(ctx, st, now)
+	if err != nil {
+		return err
+	}
+	poster := notifier.WebhookPoster{Client: &http.Client{Timeout: 10 * time.Second}}
+	for _, a := range announcements {
+		d := store.Delivery{Channel: webhookChannel, OrganizationID: a.Organization.ID, Text: a.Text()}
+		fmt.Printf("Posting announcement for %s: %s\n", a.Organization.Name, d.Text)
+		if err := poster.Post(ctx, a.Organization.WebhookURL, d.Text); err != nil {
+			fmt.Fprintf(os.Stderr, "posting for %s: %v\n", a.Organization.Name, err)
+			m.failed.Inc(webhookChannel)
+			failures++
+			d.Error = err.Error()
+		} else {
+			m.sent.Inc(webhookChannel)
 		}
-		reminders = append(reminders, reminderResult)
+		logDelivery(ctx, st, d)
 	}
+	if failures > 0 {
+		return fmt.Errorf("%d messages couldn't be sent", failures)
+	}
+	m.lastSuccess.SetToTime(time.Now())
+	return nil
+}
 
-	for _, reminder := range reminders {
-		fmt.Printf("Sending reminder to %s for %s's birthday on %d/%d/%d\n", reminder.phoneNumber, reminder.name, reminder.month, reminder.day, reminder.year)
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "plan" {
+		runPlan(os.Args[2:])
+		return
+	}
+	nowPtr := flag.String("now", "", "send the reminders due at this date (2024-12-28) or time (2024-12-28T09:00:00-05:00) instead of now")
+	addrPtr := flag.String("addr", "", "instead of sending once, run every hour and serve metrics and health checks on this address, e.g. :9091")
+	flag.Parse()
+	c, err := clock.FromFlag(*nowPtr)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	if *addrPtr != "" && *nowPtr != "" {
+		fmt.Println("-now can't be used with -addr")
+		os.Exit(2)
+	}
+	//twilioAccountSid := os.Getenv("TWILIO_ACCOUNT_SID")
+	//twilioAuthToken := os.Getenv("TWILIO_AUTH_TOKEN")
+	//twilioPhoneNumber := os.Getenv("TWILIO_PHONE_NUMBER")
+	reg := metrics.NewRegistry()
+	st := openStore(reg)
+	defer st.Close()
+	st.SetClock(c)
+	m := newRunMetrics(reg)
+
+	if *addrPtr != "" {
+		runDaemon(*addrPtr, st, reg, m)
+		return
+	}
+	if err := run(context.Background(), st, c.Now(), m); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/metrics"
+	"ashwindharne/bdaybot/notifier"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// plannedMessage is a message that a run of the notifier would send.
+type plannedMessage struct {
+	// At is the time of the run, in the recipient's timezone.
+	At time.Time `json:"at"`
+	// Recipient is a phone number, or the webhook URL an announcement is
+	// posted to.
+	Recipient  string `json:"recipient"`
+	Channel    string `json:"channel"`
+	Occasion   string `json:"occasion"`
+	DaysBefore int    `json:"days_before"`
+	Text       string `json:"text"`
+}
+
+// runPlan implements notify plan, which previews the reminders and
+// announcements the notifier would send over a range of dates:
+//
+//	notify plan --from 2026-12-20 --days 30 [--json]
+func runPlan(args []string) {
+	flags := flag.NewFlagSet("plan", flag.ExitOnError)
+	fromPtr := flags.String("from", time.Now().Format(time.DateOnly), "first date (2024-12-28) or time (2024-12-28T09:00:00-05:00) to simulate")
+	daysPtr := flags.Int("days", 30, "number of days to simulate")
+	jsonPtr := flags.Bool("json", false, "print the plan as JSON")
+	flags.Parse(args)
+	from, err := clock.Parse(*fromPtr, time.Local)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	if *daysPtr < 1 {
+		fmt.Println("-days must be at least 1")
+		os.Exit(2)
+	}
+	// Nothing scrapes a plan, so its metrics are dropped.
+	st := openStore(metrics.NewRegistry())
+	defer st.Close()
+
+	reminders, err := notifier.Plan(context.Background(), st, from, *daysPtr)
+	if err != nil {
+		panic(err)
+	}
+	announcements, err := notifier.PlanAnnouncements(context.Background(), st, from, *daysPtr)
+	if err != nil {
+		panic(err)
+	}
+	plan := []plannedMessage{}
+	for _, r := range reminders {
+		plan = append(plan, plannedMessage{
+			At:         r.At,
+			Recipient:  r.PhoneNumber,
+			Channel:    notifier.Channel,
+			Occasion:   r.Occurrence.Format(time.DateOnly),
+			DaysBefore: r.DaysBefore,
+			Text:       r.Message(),
+		})
+	}
+	// Announcements go to the organization's webhook on the day.
+	for _, a := range announcements {
+		plan = append(plan, plannedMessage{
+			At:        a.At,
+			Recipient: a.Organization.WebhookURL,
+			Channel:   webhookChannel,
+			Occasion:  a.At.Format(time.DateOnly),
+			Text:      a.Text(),
+		})
+	}
+	slices.SortStableFunc(plan, func(a, b plannedMessage) int { return a.At.Compare(b.At) })
+	if *jsonPtr {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(plan); err != nil {
+			panic(err)
+		}
+		return
+	}
+	for _, m := range plan {
+		fmt.Printf("%s  %s via %s, %d days before %s\n", m.At.Format("2006-01-02 15:04 MST"), m.Recipient, m.Channel, m.DaysBefore, m.Occasion)
+		fmt.Printf("  %s\n\n", indent(m.Text))
+	}
+	fmt.Printf("%d messages from %s over %d days\n", len(plan), from.Format(time.DateOnly), *daysPtr)
+}
+
+// indent indents the lines of a multi-line message after the first.
+func indent(text string) string {
+	return strings.ReplaceAll(text, "\n", "\n  ")
+}
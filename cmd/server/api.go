@@ -0,0 +1,421 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/notifier"
+	"ashwindharne/bdaybot/store"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The API lets other tools manage an account's birthdays and settings. Each
+// request is made as the account whose token it carries, with the same
+// rules the app follows.
+
+//go:embed openapi.json
+var openAPI []byte
+
+// maxBodySize is far more than any event or settings body needs.
+const maxBodySize = 64 << 10
+
+func (s *server) apiRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPI)
+	})
+	mux.HandleFunc("GET /api/v1/birthdays", s.authenticated(s.listEvents))
+	mux.HandleFunc("POST /api/v1/birthdays", s.authenticated(s.createEvent))
+	mux.HandleFunc("GET /api/v1/birthdays/{id}", s.authenticated(s.getEvent))
+	mux.HandleFunc("PUT /api/v1/birthdays/{id}", s.authenticated(s.updateEvent))
+	mux.HandleFunc("DELETE /api/v1/birthdays/{id}", s.authenticated(s.deleteEvent))
+	mux.HandleFunc("GET /api/v1/settings", s.authenticated(s.getSettings))
+	mux.HandleFunc("PATCH /api/v1/settings", s.authenticated(s.updateSettings))
+	mux.HandleFunc("GET /api/v1/reminders", s.authenticated(s.listReminders))
+}
+
+// apiHandler handles a request made as the account with phoneNumber.
+type apiHandler func(w http.ResponseWriter, r *http.Request, phoneNumber string)
+
+// authenticated checks a request's bearer token before handing it to h.
+func (s *server) authenticated(h apiHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || secret == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("missing bearer token"))
+			return
+		}
+		phoneNumber, token, err := s.store.AuthenticateAPIToken(r.Context(), secret)
+		if errors.Is(err, store.ErrNotFound) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("invalid or revoked token"))
+			return
+		}
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		actor := store.Actor{Source: store.SourceAPI, Key: fmt.Sprintf("%s #%d", token.Name, token.ID)}
+		h(w, r.WithContext(store.WithActor(r.Context(), actor)), phoneNumber)
+	}
+}
+
+// API ERRORS AND ENCODING
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error("Could not write response", "error", err)
+	}
+}
+
+// writeError responds with {"error": ...}. Messages are in English, since
+// they're for whoever writes the integration.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeStoreError maps the store's errors to statuses, logging the ones
+// that aren't the client's fault.
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	case errors.Is(err, store.ErrReadOnly):
+		writeError(w, http.StatusForbidden, errors.New("you can only view this list"))
+	default:
+		log.Error("API request failed", "error", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+	}
+}
+
+// decode reads a JSON body into v, refusing fields the API doesn't have so
+// typos don't go unnoticed.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON: %v", err))
+		return false
+	}
+	return true
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return 0, false
+	}
+	return id, true
+}
+
+// BIRTHDAYS
+
+// apiEvent is an event as the API reads and writes it.
+type apiEvent struct {
+	ID int `json:"id"`
+	// ListID is the list the event is on. New events without one go on
+	// the account's personal list; updates can't move events.
+	ListID   int             `json:"list_id"`
+	Name     string          `json:"name"`
+	Type     events.Type     `json:"type"`
+	Label    string          `json:"label"`
+	Calendar calendar.System `json:"calendar"`
+	Month    int             `json:"month"`
+	Day      int             `json:"day"`
+	Year     int             `json:"year"`
+	Notes    string          `json:"notes"`
+	Tags     []string        `json:"tags"`
+}
+
+func toAPIEvent(e store.Event) apiEvent {
+	tags := e.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return apiEvent{e.ID, e.ListID, e.Name, e.Type, e.Label, e.Calendar, e.Month, e.Day, e.Year, e.Notes, tags}
+}
+
+// event validates a with the checks the app's event form makes, filling in
+// the defaults the form starts with.
+func (a apiEvent) event(thisYear int) (store.Event, error) {
+	e := store.Event{
+		ID: a.ID, ListID: a.ListID, Name: strings.TrimSpace(a.Name), Type: a.Type, Label: strings.TrimSpace(a.Label),
+		Calendar: a.Calendar, Month: a.Month, Day: a.Day, Year: a.Year, Notes: a.Notes,
+	}
+	if e.Type == "" {
+		e.Type = events.Birthday
+	}
+	if e.Calendar == "" {
+		e.Calendar = calendar.Gregorian
+	}
+	if e.Name == "" {
+		return store.Event{}, i18n.Errorf("name can't be empty")
+	}
+	if !slices.Contains(events.Types, e.Type) {
+		return store.Event{}, fmt.Errorf("type must be one of %v", events.Types)
+	}
+	if !slices.Contains(calendar.Systems, e.Calendar) {
+		return store.Event{}, fmt.Errorf("calendar must be one of %v", calendar.Systems)
+	}
+	if !slices.ContainsFunc(e.Calendar.Months(), func(m calendar.Month) bool { return m.Number == e.Month }) {
+		return store.Event{}, fmt.Errorf("month %d isn't a month of the %s calendar", e.Month, e.Calendar)
+	}
+	if err := events.ValidateDay(strconv.Itoa(e.Day)); err != nil {
+		return store.Event{}, err
+	}
+	if err := events.ValidateYear(thisYear)(strconv.Itoa(e.Year)); err != nil {
+		return store.Event{}, err
+	}
+	if err := events.ValidateLabel(e.Type, e.Label); err != nil {
+		return store.Event{}, err
+	}
+	tags, err := events.ValidateTags(a.Tags)
+	if err != nil {
+		return store.Event{}, err
+	}
+	e.Tags = tags
+	return e, nil
+}
+
+func (s *server) listEvents(w http.ResponseWriter, r *http.Request, phoneNumber string) {
+	list, err := s.store.ListEvents(r.Context(), phoneNumber)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	body := []apiEvent{}
+	for _, e := range list {
+		body = append(body, toAPIEvent(e))
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *server) getEvent(w http.ResponseWriter, r *http.Request, phoneNumber string) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	e, err := s.store.GetEvent(r.Context(), phoneNumber, id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAPIEvent(e))
+}
+
+func (s *server) createEvent(w http.ResponseWriter, r *http.Request, phoneNumber string) {
+	var body apiEvent
+	if !decode(w, r, &body) {
+		return
+	}
+	e, err := body.event(s.clock.Now().Year())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	id, err := s.store.CreateEvent(r.Context(), phoneNumber, e)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if e, err = s.store.GetEvent(r.Context(), phoneNumber, id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/birthdays/%d", id))
+	writeJSON(w, http.StatusCreated, toAPIEvent(e))
+}
+
+func (s *server) updateEvent(w http.ResponseWriter, r *http.Request, phoneNumber string) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.store.GetEvent(r.Context(), phoneNumber, id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var body apiEvent
+	if !decode(w, r, &body) {
+		return
+	}
+	body.ID = id
+	e, err := body.event(s.clock.Now().Year())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.store.UpdateEvent(r.Context(), phoneNumber, e); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if e, err = s.store.GetEvent(r.Context(), phoneNumber, id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAPIEvent(e))
+}
+
+func (s *server) deleteEvent(w http.ResponseWriter, r *http.Request, phoneNumber string) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.store.GetEvent(r.Context(), phoneNumber, id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if err := s.store.DeleteEvent(r.Context(), phoneNumber, id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SETTINGS
+
+// apiSettings are the account settings the API exposes. The theme only
+// matters to the app, so it's left out.
+type apiSettings struct {
+	NotificationDays int    `json:"notification_days"`
+	Timezone         string `json:"timezone"`
+	NotificationHour int    `json:"notification_hour"`
+	Enabled          bool   `json:"enabled"`
+	IncludeGiftIdeas bool   `json:"include_gift_ideas"`
+	Locale           string `json:"locale"`
+}
+
+// settingsPatch changes only the settings it has.
+type settingsPatch struct {
+	NotificationDays *int    `json:"notification_days"`
+	Timezone         *string `json:"timezone"`
+	NotificationHour *int    `json:"notification_hour"`
+	Enabled          *bool   `json:"enabled"`
+	IncludeGiftIdeas *bool   `json:"include_gift_ideas"`
+	Locale           *string `json:"locale"`
+}
+
+func toAPISettings(st store.Settings) apiSettings {
+	return apiSettings{st.NotificationDays, st.Timezone, st.NotificationHour, st.Enabled, st.IncludeGiftIdeas, st.Locale}
+}
+
+// apply validates p the way the app's settings form does and applies it to
+// st.
+func (p settingsPatch) apply(st *store.Settings) error {
+	if p.NotificationDays != nil {
+		if *p.NotificationDays < 1 || *p.NotificationDays > 365 {
+			return errors.New("notification_days must be between 1 and 365")
+		}
+		st.NotificationDays = *p.NotificationDays
+	}
+	if p.Timezone != nil {
+		if _, err := time.LoadLocation(*p.Timezone); err != nil || *p.Timezone == "" {
+			return errors.New("timezone must be a timezone like America/New_York")
+		}
+		st.Timezone = *p.Timezone
+	}
+	if p.NotificationHour != nil {
+		if *p.NotificationHour < 0 || *p.NotificationHour > 23 {
+			return errors.New("notification_hour must be between 0 and 23")
+		}
+		st.NotificationHour = *p.NotificationHour
+	}
+	if p.Enabled != nil {
+		st.Enabled = *p.Enabled
+	}
+	if p.IncludeGiftIdeas != nil {
+		st.IncludeGiftIdeas = *p.IncludeGiftIdeas
+	}
+	if p.Locale != nil {
+		if _, err := i18n.Parse(*p.Locale); err != nil {
+			return fmt.Errorf("locale must be one of %v", i18n.Locales)
+		}
+		st.Locale = *p.Locale
+	}
+	return nil
+}
+
+func (s *server) getSettings(w http.ResponseWriter, r *http.Request, phoneNumber string) {
+	st, err := s.store.GetSettings(r.Context(), phoneNumber)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAPISettings(st))
+}
+
+func (s *server) updateSettings(w http.ResponseWriter, r *http.Request, phoneNumber string) {
+	var patch settingsPatch
+	if !decode(w, r, &patch) {
+		return
+	}
+	st, err := s.store.GetSettings(r.Context(), phoneNumber)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if err := patch.apply(&st); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.store.UpdateSettings(r.Context(), phoneNumber, st); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAPISettings(st))
+}
+
+// REMINDERS
+
+// apiReminder is a reminder the notifier will send.
+type apiReminder struct {
+	EventID int    `json:"event_id"`
+	Name    string `json:"name"`
+	// SendAt is when the reminder goes out, in the account's timezone.
+	SendAt time.Time `json:"send_at"`
+	// Date is the day of the occasion, as YYYY-MM-DD.
+	Date       string `json:"date"`
+	DaysBefore int    `json:"days_before"`
+	Message    string `json:"message"`
+}
+
+func (s *server) listReminders(w http.ResponseWriter, r *http.Request, phoneNumber string) {
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		var err error
+		if days, err = strconv.Atoi(v); err != nil || days < 1 || days > 365 {
+			writeError(w, http.StatusBadRequest, errors.New("days must be between 1 and 365"))
+			return
+		}
+	}
+	reminders, err := notifier.PlanAccount(r.Context(), s.store, phoneNumber, s.clock.Now(), days)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	body := []apiReminder{}
+	for _, rem := range reminders {
+		body = append(body, apiReminder{
+			EventID:    rem.ID,
+			Name:       rem.Name,
+			SendAt:     rem.At,
+			Date:       rem.Occurrence.Format(time.DateOnly),
+			DaysBefore: rem.DaysBefore,
+			Message:    rem.Message(),
+		})
+	}
+	writeJSON(w, http.StatusOK, body)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI(t *testing.T) {
+	ctx := context.Background()
+	const me, stranger = "+15555550100", "+15555550199"
+	st := store.NewMemory()
+	st.EnsureAccount(ctx, me, "UTC")
+	st.EnsureAccount(ctx, stranger, "UTC")
+	_, secret, err := st.CreateAPIToken(ctx, me, "script")
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs, _ := st.CreateEvent(ctx, stranger, store.Event{Name: "Zed", Type: "birthday", Calendar: "gregorian", Month: 1, Day: 2, Year: 1970})
+	srv := httptest.NewServer((&server{
+		store: st,
+		clock: clock.Fixed(time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC)),
+	}).routes())
+	defer srv.Close()
+
+	do := func(method, path, token, body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+
+	if code, _ := do("GET", "/api/v1/birthdays", "", ""); code != http.StatusUnauthorized {
+		t.Errorf("without a token: %d, want 401", code)
+	}
+	if code, _ := do("GET", "/api/v1/birthdays", "bdb_nope", ""); code != http.StatusUnauthorized {
+		t.Errorf("with an unknown token: %d, want 401", code)
+	}
+
+	code, body := do("POST", "/api/v1/birthdays", secret, `{"name":"Bob","month":3,"day":10,"year":1985,"tags":["work"]}`)
+	if code != http.StatusCreated {
+		t.Fatalf("create: %d %s", code, body)
+	}
+	var created apiEvent
+	json.Unmarshal([]byte(body), &created)
+	if created.ID == 0 || created.Type != "birthday" || created.Calendar != "gregorian" || created.ListID == 0 {
+		t.Errorf("created = %+v, want a Gregorian birthday on a list", created)
+	}
+	path := "/api/v1/birthdays/" + strconv.Itoa(created.ID)
+
+	if code, body := do("PUT", path, secret, `{"name":"Bobby","month":3,"day":11,"year":1985}`); code != http.StatusOK || !strings.Contains(body, `"name":"Bobby"`) {
+		t.Errorf("update: %d %s", code, body)
+	}
+	if code, body := do("PUT", path, secret, `{"name":"Bobby","month":3,"day":11,"year":1985,"tags":["#Work","work"," x "]}`); code != http.StatusOK || !strings.Contains(body, `"tags":["work","x"]`) {
+		t.Errorf("update with repeated tags: %d %s", code, body)
+	}
+	if code, body := do("PUT", path, secret, `{"name":"Bobby","month":3,"day":11,"year":1985,"tags":["a,b"]}`); code != http.StatusBadRequest || !strings.Contains(body, "commas") {
+		t.Errorf("tag with a comma: %d %s", code, body)
+	}
+	if code, body := do("POST", "/api/v1/birthdays", secret, `{"name":"Bob","month":3,"day":40,"year":1985}`); code != http.StatusBadRequest || !strings.Contains(body, "day") {
+		t.Errorf("bad day: %d %s", code, body)
+	}
+	if code, _ := do("POST", "/api/v1/birthdays", secret, `{"name":"Bob","mnth":3}`); code != http.StatusBadRequest {
+		t.Errorf("unknown field: %d, want 400", code)
+	}
+	if code, _ := do("GET", "/api/v1/birthdays/"+strconv.Itoa(theirs), secret, ""); code != http.StatusNotFound {
+		t.Errorf("someone else's event: %d, want 404", code)
+	}
+	if code, _ := do("DELETE", "/api/v1/birthdays/"+strconv.Itoa(theirs), secret, ""); code != http.StatusNotFound {
+		t.Errorf("deleting someone else's event: %d, want 404", code)
+	}
+
+	code, body = do("GET", "/api/v1/reminders?days=30", secret, "")
+	var reminders []apiReminder
+	json.Unmarshal([]byte(body), &reminders)
+	if code != http.StatusOK || len(reminders) == 0 || reminders[0].Name != "Bobby" || reminders[0].Date != "2024-03-11" {
+		t.Errorf("reminders: %d %s", code, body)
+	}
+	if code, _ := do("GET", "/api/v1/reminders?days=0", secret, ""); code != http.StatusBadRequest {
+		t.Errorf("reminders for 0 days: %d, want 400", code)
+	}
+
+	if code, _ := do("DELETE", path, secret, ""); code != http.StatusNoContent {
+		t.Errorf("delete: %d, want 204", code)
+	}
+	if code, _ := do("GET", path, secret, ""); code != http.StatusNotFound {
+		t.Errorf("deleted event: %d, want 404", code)
+	}
+
+	code, body = do("PATCH", "/api/v1/settings", secret, `{"notification_hour":8,"locale":"es"}`)
+	var settings apiSettings
+	json.Unmarshal([]byte(body), &settings)
+	if code != http.StatusOK || settings.NotificationHour != 8 || settings.Locale != "es" || settings.Timezone != "UTC" {
+		t.Errorf("settings: %d %s", code, body)
+	}
+	if code, _ := do("PATCH", "/api/v1/settings", secret, `{"timezone":"Mars/Olympus"}`); code != http.StatusBadRequest {
+		t.Errorf("bad timezone: %d, want 400", code)
+	}
+
+	code, body = do("GET", "/api/v1/openapi.json", "", "")
+	var doc struct {
+		Paths map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(body), &doc); code != http.StatusOK || err != nil {
+		t.Fatalf("openapi.json: %d %v", code, err)
+	}
+	for _, p := range []string{"/birthdays", "/birthdays/{id}", "/settings", "/reminders"} {
+		if _, ok := doc.Paths[p]; !ok {
+			t.Errorf("openapi.json doesn't describe %s", p)
+		}
+	}
+}
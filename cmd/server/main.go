@@ -1 +1,303 @@
 package main
+
+import (
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/collect"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/health"
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/metrics"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"errors"
+	"flag"
+	"github.com/charmbracelet/log"
+	"html/template"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+// maxNameLength keeps submitted names to what fits in the app's tables.
+const maxNameLength = 100
+
+// server serves the JSON API, and the forms people open from a list's
+// collection link to submit their own birthday. Submissions wait for the
+// list's owner to review them in the app.
+type server struct {
+	store store.Store
+	// links checks collection links. Without it, there are no forms.
+	links *collect.Links
+	clock clock.Clock
+	// metrics and db, when set, are served at /metrics and checked by
+	// /healthz and /readyz.
+	metrics *metrics.Registry
+	db      health.DB
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	if s.links != nil {
+		mux.HandleFunc("GET /collect/{token}", s.showForm)
+		mux.HandleFunc("POST /collect/{token}", s.submit)
+	}
+	s.apiRoutes(mux)
+	if s.metrics != nil {
+		mux.Handle("GET /metrics", s.metrics)
+	}
+	if s.db != nil {
+		health.Routes(mux, s.db)
+	}
+	return mux
+}
+
+// page is what the template needs to render the form or its outcome.
+type page struct {
+	p        i18n.Printer
+	ListName string
+	Months   []string
+	Form     submissionForm
+	Error    string
+	Done     bool
+}
+
+func (pg page) T(message string, args ...any) string {
+	return pg.p.T(message, args...)
+}
+
+func (pg page) Lang() string {
+	lang, _, _ := strings.Cut(string(pg.p.Locale()), "-")
+	return lang
+}
+
+// submissionForm holds the fields as typed, so they can be shown again with
+// an error.
+type submissionForm struct {
+	Name  string
+	Month int
+	Day   string
+	Year  string
+}
+
+// printer picks the visitor's language from their first Accept-Language
+// choice.
+func printer(r *http.Request) i18n.Printer {
+	first, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	first, _, _ = strings.Cut(first, ";")
+	locale, _ := i18n.Match(strings.TrimSpace(first))
+	return i18n.For(locale)
+}
+
+// list looks up the list a request's link is for, writing the error page
+// when there's none or the link has been replaced by a new one.
+func (s *server) list(w http.ResponseWriter, r *http.Request, p i18n.Printer) (store.List, bool) {
+	if id, version, ok := s.links.Verify(r.PathValue("token")); ok {
+		l, err := s.store.GetList(r.Context(), id)
+		if err == nil && l.LinkVersion == version {
+			return l, true
+		}
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			log.Error("Could not look up list", "list", id, "error", err)
+			http.Error(w, p.T("Sorry, something went wrong and it's been logged."), http.StatusInternalServerError)
+			return store.List{}, false
+		}
+	}
+	s.render(w, http.StatusNotFound, page{p: p, Error: p.T("This link isn't valid anymore. Ask whoever sent it for a new one.")})
+	return store.List{}, false
+}
+
+func (s *server) newPage(p i18n.Printer, l store.List) page {
+	name := l.Name
+	if name == store.PersonalList {
+		name = p.T(store.PersonalList)
+	}
+	var months []string
+	for m := time.January; m <= time.December; m++ {
+		months = append(months, p.Month(m))
+	}
+	return page{p: p, ListName: name, Months: months}
+}
+
+func (s *server) showForm(w http.ResponseWriter, r *http.Request) {
+	p := printer(r)
+	l, ok := s.list(w, r, p)
+	if !ok {
+		return
+	}
+	s.render(w, http.StatusOK, s.newPage(p, l))
+}
+
+func (s *server) submit(w http.ResponseWriter, r *http.Request) {
+	p := printer(r)
+	l, ok := s.list(w, r, p)
+	if !ok {
+		return
+	}
+	pg := s.newPage(p, l)
+	r.Body = http.MaxBytesReader(w, r.Body, 4096)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	pg.Form = submissionForm{
+		Name: strings.TrimSpace(r.PostFormValue("name")),
+		Day:  strings.TrimSpace(r.PostFormValue("day")),
+		Year: strings.TrimSpace(r.PostFormValue("year")),
+	}
+	pg.Form.Month, _ = strconv.Atoi(r.PostFormValue("month"))
+	sub, err := s.validate(pg.Form)
+	if err != nil {
+		pg.Error = p.Error(err)
+		s.render(w, http.StatusBadRequest, pg)
+		return
+	}
+	sub.ListID = l.ID
+	if _, err := s.store.SubmitBirthday(r.Context(), sub); err != nil {
+		log.Error("Could not save submission", "list", l.ID, "error", err)
+		http.Error(w, p.T("Sorry, something went wrong and it's been logged."), http.StatusInternalServerError)
+		return
+	}
+	pg.Done = true
+	s.render(w, http.StatusOK, pg)
+}
+
+// validate checks a submission the way the app's birthday form would.
+func (s *server) validate(f submissionForm) (store.Submission, error) {
+	thisYear := s.clock.Now().Year()
+	if f.Name == "" {
+		return store.Submission{}, i18n.Errorf("name can't be empty")
+	}
+	if utf8.RuneCountInString(f.Name) > maxNameLength {
+		return store.Submission{}, i18n.Errorf("name must be at most %d characters", maxNameLength)
+	}
+	if f.Month < 1 || f.Month > 12 {
+		return store.Submission{}, i18n.Errorf("pick a month")
+	}
+	if err := events.ValidateDay(f.Day); err != nil {
+		return store.Submission{}, err
+	}
+	if err := events.ValidateYear(thisYear)(f.Year); err != nil {
+		return store.Submission{}, err
+	}
+	// Both are known to be numbers now.
+	day, _ := strconv.Atoi(f.Day)
+	year, _ := strconv.Atoi(f.Year)
+	if time.Date(year, time.Month(f.Month), day, 0, 0, 0, 0, time.UTC).Day() != day {
+		return store.Submission{}, i18n.Errorf("that date doesn't exist")
+	}
+	return store.Submission{Name: f.Name, Month: f.Month, Day: day, Year: year}, nil
+}
+
+func (s *server) render(w http.ResponseWriter, status int, pg page) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := pageTemplate.Execute(w, pg); err != nil {
+		log.Error("Could not render page", "error", err)
+	}
+}
+
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!doctype html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.T "Add Your Birthday"}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 28rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; }
+label { display: block; margin-top: 1rem; font-weight: 600; }
+input, select, button { font: inherit; padding: .4rem; width: 100%; box-sizing: border-box; }
+button { margin-top: 1.5rem; cursor: pointer; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>🎂 {{.T "Add Your Birthday"}}</h1>
+{{if .Done -}}
+<p>{{.T "Thanks! Your birthday will be added to %s once the list's owner approves it." .ListName}}</p>
+{{- else if not .ListName -}}
+<p class="error">{{.Error}}</p>
+{{- else -}}
+<p>{{.T "You've been asked for your birthday for the list %s." .ListName}}</p>
+{{with .Error}}<p class="error" role="alert">{{.}}</p>{{end}}
+<form method="post">
+<label for="name">{{.T "Name"}}</label>
+<input id="name" name="name" value="{{.Form.Name}}" maxlength="100" required autocomplete="name">
+<label for="month">{{.T "Month"}}</label>
+<select id="month" name="month" required>
+<option value=""></option>
+{{- $month := .Form.Month}}
+{{- range $i, $name := .Months}}
+<option value="{{inc $i}}"{{if eq (inc $i) $month}} selected{{end}}>{{$name}}</option>
+{{- end}}
+</select>
+<label for="day">{{.T "Day"}}</label>
+<input id="day" name="day" value="{{.Form.Day}}" inputmode="numeric" maxlength="2" required>
+<label for="year">{{.T "Year"}}</label>
+<input id="year" name="year" value="{{.Form.Year}}" inputmode="numeric" maxlength="4" required>
+<button type="submit">{{.T "Send"}}</button>
+</form>
+{{- end}}
+</body>
+</html>
+`))
+
+// openStore opens the database at $DB_PATH, or db.sqlite by default,
+// timing its statements in reg.
+func openStore(reg *metrics.Registry) *store.SQLite {
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = "db.sqlite"
+	}
+	queries := reg.Histogram("bdaybot_db_query_duration_seconds", "Time taken by database statements.", metrics.DefaultBuckets, "op")
+	st, err := store.OpenObserved(dbPath, func(op string, took time.Duration) {
+		queries.Observe(took.Seconds(), op)
+	})
+	if err != nil {
+		log.Fatal("Could not open database", "error", err)
+	}
+	return st
+}
+
+func main() {
+	addrPtr := flag.String("addr", ":8080", "address to serve the API, collection links, metrics and health checks on")
+	flag.Parse()
+	var links *collect.Links
+	if l, ok := collect.FromEnv(); ok {
+		links = &l
+	} else {
+		log.Warn("Collection links are off; set COLLECT_URL and COLLECT_SECRET to serve them")
+	}
+	reg := metrics.NewRegistry()
+	st := openStore(reg)
+	defer st.Close()
+
+	srv := &http.Server{
+		Addr:              *addrPtr,
+		Handler:           (&server{store: st, links: links, clock: clock.System, metrics: reg, db: st}).routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	log.Info("Starting HTTP server", "addr", *addrPtr)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Could not start server", "error", err)
+			done <- nil
+		}
+	}()
+
+	<-done
+	log.Info("Stopping HTTP server")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Error("Could not stop server", "error", err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/collect"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectionLink(t *testing.T) {
+	ctx := context.Background()
+	const owner = "+15555550100"
+	st := store.NewMemory()
+	st.EnsureAccount(ctx, owner, "UTC")
+	family, _ := st.CreateList(ctx, owner, "Family")
+	links := collect.Links{BaseURL: "https://bdaybot.example.com", Secret: []byte("s3cret")}
+	srv := httptest.NewServer((&server{
+		store: st,
+		links: &links,
+		clock: clock.Fixed(time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC)),
+	}).routes())
+	defer srv.Close()
+	link := srv.URL + "/collect/" + links.Token(family, 0)
+
+	get := func(url string, lang string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Accept-Language", lang)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	post := func(form url.Values) (int, string) {
+		t.Helper()
+		resp, err := http.PostForm(link, form)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if code, body := get(link, "en-US"); code != http.StatusOK || !strings.Contains(body, "for the list Family") {
+		t.Errorf("form: %d\n%s", code, body)
+	}
+	if code, body := get(link, "es-ES,es;q=0.9"); code != http.StatusOK || !strings.Contains(body, "Añade tu cumpleaños") {
+		t.Errorf("Spanish form: %d\n%s", code, body)
+	}
+	other := collect.Links{Secret: []byte("other")}
+	if code, _ := get(srv.URL+"/collect/"+other.Token(family, 0), ""); code != http.StatusNotFound {
+		t.Errorf("link signed with another secret: %d, want 404", code)
+	}
+
+	code, body := post(url.Values{"name": {"Bob"}, "month": {"2"}, "day": {"30"}, "year": {"1985"}})
+	if code != http.StatusBadRequest || !strings.Contains(body, "that date doesn&#39;t exist") || !strings.Contains(body, `value="Bob"`) {
+		t.Errorf("invalid date: %d\n%s", code, body)
+	}
+	if code, body := post(url.Values{"name": {"Bob"}, "month": {"12"}, "day": {"1"}, "year": {"1985"}}); code != http.StatusOK || !strings.Contains(body, "Thanks!") {
+		t.Errorf("submission: %d\n%s", code, body)
+	}
+	submissions, err := st.ListSubmissions(ctx, owner, family)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submissions) != 1 || submissions[0].Name != "Bob" || submissions[0].Month != 12 || submissions[0].Day != 1 || submissions[0].Year != 1985 {
+		t.Errorf("submissions = %+v, want Bob's", submissions)
+	}
+
+	// A new link replaces the old one.
+	version, _ := st.RegenerateLink(ctx, owner, family)
+	if code, _ := get(link, ""); code != http.StatusNotFound {
+		t.Errorf("replaced link: %d, want 404", code)
+	}
+	if code, _ := get(srv.URL+"/collect/"+links.Token(family, version), ""); code != http.StatusOK {
+		t.Errorf("new link: %d, want 200", code)
+	}
+}
@@ -0,0 +1,90 @@
+// Package collect signs the links that let people add their own birthday to
+// a list without signing in. A link carries its list's ID and link version
+// and a signature of them, so cmd/server can trust links while only keeping
+// track of each list's current version.
+package collect
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Links makes and checks collection links. The app and cmd/server must be
+// given the same secret.
+type Links struct {
+	// BaseURL is where cmd/server can be reached, e.g.
+	// "https://bdaybot.example.com".
+	BaseURL string
+	Secret  []byte
+}
+
+// FromEnv reads the links' settings from $COLLECT_URL and $COLLECT_SECRET.
+// It returns false when either is missing, in which case there are no links
+// to hand out.
+func FromEnv() (Links, bool) {
+	baseURL, secret := os.Getenv("COLLECT_URL"), os.Getenv("COLLECT_SECRET")
+	if baseURL == "" || secret == "" {
+		return Links{}, false
+	}
+	return Links{BaseURL: strings.TrimSuffix(baseURL, "/"), Secret: []byte(secret)}, true
+}
+
+func (l Links) sign(payload string) string {
+	mac := hmac.New(sha256.New, l.Secret)
+	mac.Write([]byte("collect:" + payload))
+	// Half the digest keeps links short and is still far beyond guessing.
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Token is the signed part of a list's link, e.g. "12.3q2-7wAAAA…". The
+// version is left out while it's 0, so links handed out before a list's
+// first new link keep working.
+func (l Links) Token(listID int, version int) string {
+	payload := strconv.Itoa(listID)
+	if version > 0 {
+		payload += "." + strconv.Itoa(version)
+	}
+	return payload + "." + l.sign(payload)
+}
+
+// URL is the link to share for a version of a list's link.
+func (l Links) URL(listID int, version int) string {
+	return l.BaseURL + "/collect/" + l.Token(listID, version)
+}
+
+// Verify returns the list and link version a token was signed for, or false
+// when it wasn't signed with this secret. Whether the version is still the
+// list's current one is up to the caller.
+func (l Links) Verify(token string) (int, int, bool) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 || len(l.Secret) == 0 {
+		return 0, 0, false
+	}
+	payload, sig := token[:i], token[i+1:]
+	id, v, hasVersion := strings.Cut(payload, ".")
+	listID, ok := canonicalInt(id)
+	if !ok || listID <= 0 {
+		return 0, 0, false
+	}
+	version := 0
+	if hasVersion {
+		if version, ok = canonicalInt(v); !ok || version <= 0 {
+			return 0, 0, false
+		}
+	}
+	if !hmac.Equal([]byte(sig), []byte(l.sign(payload))) {
+		return 0, 0, false
+	}
+	return listID, version, true
+}
+
+// canonicalInt parses s as a number written the way strconv.Itoa writes
+// it, so each token has one spelling.
+func canonicalInt(s string) (int, bool) {
+	n, err := strconv.Atoi(s)
+	return n, err == nil && strconv.Itoa(n) == s
+}
@@ -0,0 +1,36 @@
+package collect
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	links := Links{BaseURL: "https://bdaybot.example.com", Secret: []byte("s3cret")}
+	url := links.URL(12, 0)
+	token, ok := strings.CutPrefix(url, "https://bdaybot.example.com/collect/")
+	if !ok {
+		t.Fatalf("URL(12, 0) = %q", url)
+	}
+	if id, version, ok := links.Verify(token); !ok || id != 12 || version != 0 {
+		t.Errorf("Verify(%q) = %d, %d, %v, want 12, 0", token, id, version, ok)
+	}
+	regenerated := links.Token(12, 3)
+	if id, version, ok := links.Verify(regenerated); !ok || id != 12 || version != 3 {
+		t.Errorf("Verify(%q) = %d, %d, %v, want 12, 3", regenerated, id, version, ok)
+	}
+
+	_, sig, _ := strings.Cut(token, ".")
+	other := Links{Secret: []byte("other")}
+	for _, bad := range []string{
+		"", "12", "13." + sig, "012." + sig, "12." + sig[1:], other.Token(12, 0),
+		"12.0." + sig, "12.3." + sig, "12.03" + regenerated[4:], "12.4" + regenerated[4:],
+	} {
+		if id, _, ok := links.Verify(bad); ok {
+			t.Errorf("Verify(%q) = %d, want it refused", bad, id)
+		}
+	}
+	if _, _, ok := (Links{}).Verify(Links{}.Token(12, 0)); ok {
+		t.Error("tokens verify without a secret")
+	}
+}
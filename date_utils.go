@@ -1,29 +1,39 @@
 package main
 
 import (
-	"fmt"
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/i18n"
 	"time"
 )
 
-func daysToNextBirthday(bMonth int, bDay int) int {
-	now := time.Now()
-	nYear, _, _ := now.Date()
-	birthdayThisYear := time.Date(nYear, time.Month(bMonth), bDay, 0, 0, 0, 0, time.UTC)
-	birthdayNextYear := time.Date(nYear+1, time.Month(bMonth), bDay, 0, 0, 0, 0, time.UTC)
-	if birthdayThisYear.After(now) {
-		return int(birthdayThisYear.Sub(now).Hours() / 24)
-	} else {
-		return int(birthdayNextYear.Sub(now).Hours() / 24)
-	}
+// nextOccurrence returns the date of the next occurrence of the given month
+// and day in the event's calendar, counting today. now should be in the
+// user's timezone so "today" is their today.
+func nextOccurrence(system calendar.System, bMonth int, bDay int, now time.Time) time.Time {
+	return calendar.NextOccurrence(system, bMonth, bDay, now)
+}
+
+func daysToNextBirthday(system calendar.System, bMonth int, bDay int, now time.Time) int {
+	return calendar.DaysUntil(system, bMonth, bDay, now)
 }
 
-func daysTilString(bMonth int, bDay int) string {
-	days := daysToNextBirthday(bMonth, bDay)
+func daysTilString(p i18n.Printer, system calendar.System, bMonth int, bDay int, now time.Time) string {
+	days := daysToNextBirthday(system, bMonth, bDay, now)
 	if days == 0 {
-		return "It's today!"
+		return p.T("It's today!")
 	} else if days == 1 {
-		return "It's tomorrow!"
+		return p.T("It's tomorrow!")
 	} else {
-		return fmt.Sprintf("%d days", days)
+		return p.T("%d days", days)
+	}
+}
+
+// formatEventDate renders a stored date: numerically in the locale's order
+// for Gregorian dates, or the day and month name followed by the Gregorian
+// year for other calendars.
+func formatEventDate(p i18n.Printer, system calendar.System, month int, day int, year int) string {
+	if system == calendar.Gregorian || system == "" {
+		return p.Date(year, time.Month(month), day)
 	}
+	return p.T("%[1]d %[2]s (%[3]d)", day, monthName(p, system, month), year)
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/store"
+	"errors"
+	tea "github.com/charmbracelet/bubbletea"
+	"time"
+)
+
+// ERROR BANNER
+
+// errorBannerTimeout is how long an error stays in place of a screen's
+// header before it's dismissed.
+const errorBannerTimeout = 6 * time.Second
+
+// errorBanner shows the latest error in place of a screen's header, like a
+// toast. Screens embed one, call Show when a command fails and pass
+// errorBannerExpiredMsg to Expire.
+type errorBanner struct {
+	err error
+	seq int
+}
+
+type errorBannerExpiredMsg struct {
+	seq int
+}
+
+// Show displays err until it times out or another error replaces it.
+func (b *errorBanner) Show(err error) tea.Cmd {
+	b.seq++
+	b.err = err
+	seq := b.seq
+	return tea.Tick(errorBannerTimeout, func(time.Time) tea.Msg {
+		return errorBannerExpiredMsg{seq}
+	})
+}
+
+// Expire clears the banner unless a newer error has replaced it.
+func (b *errorBanner) Expire(msg errorBannerExpiredMsg) {
+	if msg.seq == b.seq {
+		b.err = nil
+	}
+}
+
+// View returns the error, translated where it can be, if there is one, or
+// the screen's own header.
+func (b errorBanner) View(s *session, header string) string {
+	if b.err == nil {
+		return header
+	}
+	text := s.printer.Error(b.err)
+	if errors.Is(b.err, store.ErrReadOnly) {
+		// Screens check roles before changing a list, so this is only seen
+		// when a role changed while the screen was open.
+		text = s.T("You can only view this list.")
+	}
+	return s.styles.ErrorHeaderText.Render("⚠ " + text)
+}
@@ -0,0 +1,81 @@
+// Package events describes the recurring occasions bdaybot reminds people
+// about and how each kind is worded, in any locale, in the UI and in reminder
+// messages.
+package events
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"strings"
+)
+
+type Type string
+
+const (
+	Birthday    Type = "birthday"
+	Anniversary Type = "anniversary"
+	Memorial    Type = "memorial"
+	Custom      Type = "custom"
+)
+
+// Types lists every event type in the order they're offered in forms.
+var Types = []Type{Birthday, Anniversary, Memorial, Custom}
+
+// Title is the capitalized name of the type, e.g. "Anniversary".
+func (t Type) Title() string {
+	s := string(t)
+	if s == "" {
+		return ""
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// Occasion describes an event as it occurs in the given year, e.g.
+// "36th birthday" or "5th wedding anniversary". since is the year the event
+// started; when it isn't before year, the count is left out.
+func Occasion(p i18n.Printer, t Type, label string, since int, year int) string {
+	years := year - since
+	label = strings.TrimSpace(label)
+	switch t {
+	case Anniversary:
+		name := p.T("anniversary")
+		if label != "" {
+			name = p.T("%s anniversary", strings.ToLower(label))
+		}
+		if years > 0 {
+			return p.Ordinal(years) + " " + name
+		}
+		return name
+	case Memorial:
+		if years == 1 {
+			return p.T("1 year in memory")
+		} else if years > 1 {
+			return p.T("%d years in memory", years)
+		}
+		return p.T("memorial")
+	case Custom:
+		if label == "" {
+			label = p.T("occasion")
+		}
+		if years > 0 {
+			return p.T("%s (%d years)", label, years)
+		}
+		return label
+	default:
+		if years > 0 {
+			return p.T("%s birthday", p.Ordinal(years))
+		}
+		return p.T("birthday")
+	}
+}
+
+// Headline names the person and the occasion together, e.g.
+// "Ann's 36th birthday" or "Remembering Ann (5 years)".
+func Headline(p i18n.Printer, t Type, label string, name string, since int, year int) string {
+	if t == Memorial {
+		if years := year - since; years > 0 {
+			return p.T("Remembering %s (%d years)", name, years)
+		}
+		return p.T("Remembering %s", name)
+	}
+	return p.T("%s's %s", name, Occasion(p, t, label, since, year))
+}
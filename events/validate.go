@@ -0,0 +1,79 @@
+package events
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValidateDay accepts a day of the month as typed, for the app's forms and
+// the API alike. Whether the month has that many days is left to the
+// calendar the date is kept in.
+func ValidateDay(day string) error {
+	dayInt, err := strconv.Atoi(day)
+	if err != nil || dayInt < 1 || dayInt > 31 {
+		return i18n.Errorf("day must be number between 1 and 31")
+	}
+	return nil
+}
+
+// ValidateYear accepts years up to thisYear, which should come from the
+// caller's clock.
+func ValidateYear(thisYear int) func(string) error {
+	return func(year string) error {
+		yearInt, err := strconv.Atoi(year)
+		if err != nil || yearInt < 1 || yearInt > thisYear {
+			return i18n.Errorf("year must be number between 1 and %d", thisYear)
+		}
+		return nil
+	}
+}
+
+// ValidateLabel requires a label for custom occasions, since the label is
+// all there is to describe them.
+func ValidateLabel(t Type, label string) error {
+	if t == Custom && strings.TrimSpace(label) == "" {
+		return i18n.Errorf("custom occasions need a label")
+	}
+	return nil
+}
+
+// NormalizeTag lowercases a tag name and strips surrounding whitespace and a
+// leading "#", so "#Family " and "family" refer to the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// ParseTags splits a comma-separated list of tag names, as typed into the
+// app's forms, dropping blanks and duplicates.
+func ParseTags(tags string) []string {
+	var parsed []string
+	for _, tag := range strings.Split(tags, ",") {
+		tag = NormalizeTag(tag)
+		if tag != "" && !slices.Contains(parsed, tag) {
+			parsed = append(parsed, tag)
+		}
+	}
+	return parsed
+}
+
+// ValidateTags normalizes tag names given one by one, as the API takes them,
+// dropping duplicates. Blank names and names with commas are rejected, since
+// neither could be typed into the app's forms.
+func ValidateTags(tags []string) ([]string, error) {
+	var valid []string
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" {
+			return nil, i18n.Errorf("tags can't be empty")
+		}
+		if strings.Contains(tag, ",") {
+			return nil, i18n.Errorf("tags can't contain commas")
+		}
+		if !slices.Contains(valid, tag) {
+			valid = append(valid, tag)
+		}
+	}
+	return valid, nil
+}
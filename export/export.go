@@ -0,0 +1,247 @@
+// Package export gathers everything bdaybot keeps about an account into an
+// archive that can be saved as JSON and taken elsewhere.
+package export
+
+import (
+	"ashwindharne/bdaybot/store"
+	"context"
+	"time"
+)
+
+// Version is the version of the archive's format, bumped whenever a field
+// changes meaning or goes away.
+const Version = 1
+
+// Archive is an account's data as of ExportedAt.
+type Archive struct {
+	Version       int            `json:"version"`
+	ExportedAt    time.Time      `json:"exported_at"`
+	Account       Account        `json:"account"`
+	Lists         []List         `json:"lists"`
+	Organizations []Organization `json:"organizations"`
+	APITokens     []APIToken     `json:"api_tokens"`
+}
+
+// Account is the account itself and its settings.
+type Account struct {
+	PhoneNumber string   `json:"phone_number"`
+	Settings    Settings `json:"settings"`
+}
+
+type Settings struct {
+	NotificationDays int    `json:"notification_days"`
+	Timezone         string `json:"timezone"`
+	NotificationHour int    `json:"notification_hour"`
+	Enabled          bool   `json:"enabled"`
+	IncludeGiftIdeas bool   `json:"include_gift_ideas"`
+	Theme            string `json:"theme"`
+	Locale           string `json:"locale"`
+	// TagNotificationDays are the tags' overrides of NotificationDays.
+	TagNotificationDays map[string]int64 `json:"tag_notification_days,omitempty"`
+}
+
+// List is a list the account is a member of, with its events.
+type List struct {
+	Name    string   `json:"name"`
+	Owner   string   `json:"owner"`
+	Role    string   `json:"role"`
+	Members []Member `json:"members"`
+	Events  []Event  `json:"events"`
+}
+
+type Member struct {
+	PhoneNumber string `json:"phone_number"`
+	Role        string `json:"role"`
+}
+
+// Event is an event with the account's tags on it and its gifts.
+type Event struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Label    string `json:"label,omitempty"`
+	Calendar string `json:"calendar"`
+	Month    int    `json:"month"`
+	Day      int    `json:"day"`
+	// Year is left out when it isn't known.
+	Year  int      `json:"year,omitempty"`
+	Notes string   `json:"notes,omitempty"`
+	Tags  []string `json:"tags"`
+	Gifts []Gift   `json:"gifts"`
+}
+
+type Gift struct {
+	Idea       string `json:"idea"`
+	Status     string `json:"status"`
+	Year       *int64 `json:"year,omitempty"`
+	PriceCents *int64 `json:"price_cents,omitempty"`
+	Link       string `json:"link,omitempty"`
+}
+
+// Organization is an organization the account is on the roster of, with
+// the account's own entry. The rest of the roster belongs to its members.
+type Organization struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Timezone string `json:"timezone"`
+	// RosterName and the date are the account's entry on the roster. The
+	// date is left out until it's been entered.
+	RosterName string `json:"roster_name"`
+	Month      int    `json:"month,omitempty"`
+	Day        int    `json:"day,omitempty"`
+	Year       int    `json:"year,omitempty"`
+	ShareYear  bool   `json:"share_year"`
+}
+
+// APIToken is a token of the account's. Secrets aren't stored, so there
+// are none to export.
+type APIToken struct {
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// Build gathers the archive of the account with phoneNumber, stamped as
+// exported at now. An account that doesn't exist gets store.ErrNotFound.
+func Build(ctx context.Context, st store.Store, phoneNumber string, now time.Time) (Archive, error) {
+	settings, err := st.GetSettings(ctx, phoneNumber)
+	if err != nil {
+		return Archive{}, err
+	}
+	a := Archive{
+		Version:    Version,
+		ExportedAt: now,
+		Account: Account{
+			PhoneNumber: phoneNumber,
+			Settings: Settings{
+				NotificationDays: settings.NotificationDays,
+				Timezone:         settings.Timezone,
+				NotificationHour: settings.NotificationHour,
+				Enabled:          settings.Enabled,
+				IncludeGiftIdeas: settings.IncludeGiftIdeas,
+				Theme:            settings.Theme,
+				Locale:           settings.Locale,
+			},
+		},
+		Lists:         []List{},
+		Organizations: []Organization{},
+		APITokens:     []APIToken{},
+	}
+	for _, t := range settings.Tags {
+		if t.NotificationDays.Valid {
+			if a.Account.Settings.TagNotificationDays == nil {
+				a.Account.Settings.TagNotificationDays = map[string]int64{}
+			}
+			a.Account.Settings.TagNotificationDays[t.Name] = t.NotificationDays.Int64
+		}
+	}
+
+	if a.Lists, err = lists(ctx, st, phoneNumber); err != nil {
+		return Archive{}, err
+	}
+	if a.Organizations, err = organizations(ctx, st, phoneNumber); err != nil {
+		return Archive{}, err
+	}
+	tokens, err := st.ListAPITokens(ctx, phoneNumber)
+	if err != nil {
+		return Archive{}, err
+	}
+	for _, t := range tokens {
+		token := APIToken{Name: t.Name, CreatedAt: t.CreatedAt}
+		if !t.LastUsedAt.IsZero() {
+			token.LastUsedAt = &t.LastUsedAt
+		}
+		a.APITokens = append(a.APITokens, token)
+	}
+	return a, nil
+}
+
+// lists returns the account's lists with their members and events.
+func lists(ctx context.Context, st store.Store, phoneNumber string) ([]List, error) {
+	ls, err := st.ListLists(ctx, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	evs, err := st.ListEvents(ctx, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	lists := []List{}
+	for _, l := range ls {
+		list := List{Name: l.Name, Owner: l.Owner, Role: string(l.Role), Members: []Member{}, Events: []Event{}}
+		members, err := st.ListMembers(ctx, phoneNumber, l.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			list.Members = append(list.Members, Member{m.PhoneNumber, string(m.Role)})
+		}
+		for _, e := range evs {
+			if e.ListID != l.ID {
+				continue
+			}
+			event, err := exportEvent(ctx, st, phoneNumber, e)
+			if err != nil {
+				return nil, err
+			}
+			list.Events = append(list.Events, event)
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+func exportEvent(ctx context.Context, st store.Store, phoneNumber string, e store.Event) (Event, error) {
+	event := Event{
+		Name:     e.Name,
+		Type:     string(e.Type),
+		Label:    e.Label,
+		Calendar: string(e.Calendar),
+		Month:    e.Month,
+		Day:      e.Day,
+		Year:     e.Year,
+		Notes:    e.Notes,
+		Tags:     append([]string{}, e.Tags...),
+		Gifts:    []Gift{},
+	}
+	gifts, err := st.ListGifts(ctx, phoneNumber, e.ID)
+	if err != nil {
+		return Event{}, err
+	}
+	for _, g := range gifts {
+		gift := Gift{Idea: g.Idea, Status: g.Status, Link: g.Link}
+		if g.Year.Valid {
+			gift.Year = &g.Year.Int64
+		}
+		if g.PriceCents.Valid {
+			gift.PriceCents = &g.PriceCents.Int64
+		}
+		event.Gifts = append(event.Gifts, gift)
+	}
+	return event, nil
+}
+
+// organizations returns the organizations the account is on, with its own
+// entry on each roster.
+func organizations(ctx context.Context, st store.Store, phoneNumber string) ([]Organization, error) {
+	orgs, err := st.ListOrganizations(ctx, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	organizations := []Organization{}
+	for _, o := range orgs {
+		roster, err := st.ListRoster(ctx, phoneNumber, o.ID)
+		if err != nil {
+			return nil, err
+		}
+		org := Organization{Name: o.Name, Role: string(o.Role), Timezone: o.Timezone}
+		for _, e := range roster {
+			if e.PhoneNumber == phoneNumber {
+				org.RosterName = e.Name
+				org.Month, org.Day, org.Year = e.Month, e.Day, e.Year
+				org.ShareYear = e.ShareYear
+			}
+		}
+		organizations = append(organizations, org)
+	}
+	return organizations, nil
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	ctx := context.Background()
+	const phoneNumber, friend = "+15555550100", "+15555550111"
+	st := store.NewMemory()
+	st.EnsureAccount(ctx, phoneNumber, "America/New_York")
+	st.EnsureAccount(ctx, friend, "UTC")
+	id, _ := st.CreateEvent(ctx, phoneNumber, store.Event{
+		Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 14, Year: 1990,
+		Notes: "Likes tea", Tags: []string{"family"},
+	})
+	st.CreateGift(ctx, phoneNumber, store.Gift{EventID: id, Idea: "Teapot", Status: "idea", PriceCents: sql.NullInt64{Int64: 2500, Valid: true}})
+	st.CreateAPIToken(ctx, phoneNumber, "Zapier")
+	org := store.Organization{Name: "Acme", Timezone: "UTC", AnnouncementHour: 9}
+	org.ID, _ = st.CreateOrganization(ctx, friend, org)
+	st.AddToRoster(ctx, friend, store.RosterEntry{OrganizationID: org.ID, PhoneNumber: phoneNumber, Name: "Pat", Role: store.OrgMember, Month: 7, Day: 4})
+	st.AddToRoster(ctx, friend, store.RosterEntry{OrganizationID: org.ID, PhoneNumber: "+15555550122", Name: "Sam", Role: store.OrgMember, Month: 1, Day: 1})
+
+	now := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+	a, err := Build(ctx, st, phoneNumber, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b)
+	for _, want := range []string{
+		`"exported_at":"2026-10-19T09:00:00Z"`,
+		`"timezone":"America/New_York"`,
+		`"name":"Ann","type":"birthday","calendar":"gregorian","month":3,"day":14,"year":1990,"notes":"Likes tea","tags":["family"]`,
+		`"gifts":[{"idea":"Teapot","status":"idea","price_cents":2500}]`,
+		`"api_tokens":[{"name":"Zapier"`,
+		`"name":"Acme","role":"member","timezone":"UTC","roster_name":"Pat","month":7,"day":4`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("archive is missing %s:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Sam") {
+		t.Errorf("archive has someone else's roster entry:\n%s", got)
+	}
+
+	if _, err := Build(ctx, st, "+15555550199", now); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Build of a missing account = %v, want ErrNotFound", err)
+	}
+}
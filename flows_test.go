@@ -0,0 +1,258 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/store"
+	"bytes"
+	"context"
+	"database/sql"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/golden"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/muesli/termenv"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// flowNow is the fixed clock the flow tests run at, so how soon each event
+// is doesn't change from day to day.
+var flowNow = time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC)
+
+// flowPhoneNumber is what the flow tests type into the phone number form,
+// and flowAccount the account it signs in to.
+const (
+	flowPhoneNumber = "(202) 555-0123"
+	flowAccount     = "+12025550123"
+)
+
+// migratedStore returns a store backed by a temporary SQLite database with
+// every up migration applied.
+func migratedStore(t *testing.T) *store.SQLite {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob("migrations/*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	for _, f := range files {
+		migration, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(migration)); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+	}
+	st := store.NewSQLite(db)
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+// startApp runs the app against st from the phone number form, drawing
+// without colors at a fixed time. The terminal is tall enough for the whole
+// event form, since text scrolled off the top can't be waited for.
+func startApp(t *testing.T, st store.Store) *teatest.TestModel {
+	t.Helper()
+	sess := newSession(st, testRenderer(termenv.Ascii))
+	sess.clock = clock.Fixed(flowNow)
+	pnf := EmptyPhoneNumberForm(sess)
+	return teatest.NewTestModel(t, EmptyRootModel(sess, &pnf), teatest.WithInitialTermSize(120, 80))
+}
+
+// waitFor waits until the app has drawn every one of texts since the last
+// wait. Screens load asynchronously, so tests wait for text that only shows
+// once loading is done before typing into a screen.
+func waitFor(t *testing.T, tm *teatest.TestModel, texts ...string) {
+	t.Helper()
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		for _, text := range texts {
+			if !bytes.Contains(out, []byte(text)) {
+				return false
+			}
+		}
+		return true
+	}, teatest.WithDuration(5*time.Second), teatest.WithCheckInterval(10*time.Millisecond))
+}
+
+// keyDelay is how long press gives each key to take effect. huh moves
+// between fields with commands, so text typed straight after an enter
+// could otherwise reach the field being left.
+const keyDelay = 50 * time.Millisecond
+
+func press(tm *teatest.TestModel, keys ...tea.KeyType) {
+	for _, k := range keys {
+		tm.Send(tea.KeyMsg{Type: k})
+		time.Sleep(keyDelay)
+	}
+}
+
+// signIn submits the phone number form and waits for the birthday table to
+// show rows.
+func signIn(t *testing.T, tm *teatest.TestModel, rows ...string) {
+	t.Helper()
+	waitFor(t, tm, "Enter your phone number.")
+	press(tm, tea.KeyEnter) // keep the United States
+	tm.Type(flowPhoneNumber)
+	press(tm, tea.KeyEnter)
+	waitFor(t, tm, append([]string{"Birthday Reminders"}, rows...)...)
+}
+
+// seedAccount signs flowAccount up with one event, Bob's birthday, tagged
+// #family.
+func seedAccount(t *testing.T, st store.Store) {
+	t.Helper()
+	ctx := context.Background()
+	if err := st.EnsureAccount(ctx, flowAccount, "America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := st.CreateEvent(ctx, flowAccount, store.Event{
+		Name: "Bob", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 6, Day: 1, Year: 1985,
+		Tags: []string{"family"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tagsLoaded is shown by the event form once it has the account's tags.
+const tagsLoaded = "Select the groups this person belongs to."
+
+// finalView quits the app and returns what it last drew, for comparing with
+// testdata/<test name>.golden. After an intended change to a screen, rewrite
+// the golden files with go test -update and review their diff.
+func finalView(t *testing.T, tm *teatest.TestModel) []byte {
+	t.Helper()
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	return []byte(tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).View())
+}
+
+func TestSignInFlow(t *testing.T) {
+	st := migratedStore(t)
+	tm := startApp(t, st)
+	signIn(t, tm)
+	golden.RequireEqual(t, finalView(t, tm))
+
+	settings, err := st.GetSettings(context.Background(), flowAccount)
+	if err != nil {
+		t.Fatalf("account wasn't created: %v", err)
+	}
+	if settings.Timezone != "America/New_York" {
+		t.Errorf("timezone of a new US account = %q, want America/New_York", settings.Timezone)
+	}
+}
+
+func TestNewReminderForm(t *testing.T) {
+	st := migratedStore(t)
+	seedAccount(t, st)
+	tm := startApp(t, st)
+	signIn(t, tm, "Bob")
+	tm.Type("c")
+	waitFor(t, tm, "New Reminder", tagsLoaded)
+	golden.RequireEqual(t, finalView(t, tm))
+}
+
+func TestCreateReminderFlow(t *testing.T) {
+	st := migratedStore(t)
+	seedAccount(t, st)
+	tm := startApp(t, st)
+	signIn(t, tm, "Bob")
+	tm.Type("c")
+	waitFor(t, tm, "New Reminder", tagsLoaded)
+
+	press(tm, tea.KeyEnter) // birthday
+	tm.Type("Ann")
+	press(tm, tea.KeyEnter)
+	press(tm, tea.KeyEnter) // no label
+	press(tm, tea.KeyEnter) // Gregorian
+	press(tm, tea.KeyDown, tea.KeyDown, tea.KeyEnter)
+	tm.Type("14")
+	press(tm, tea.KeyEnter)
+	tm.Type("1990")
+	press(tm, tea.KeyEnter)
+	tm.Type("Likes tea")
+	press(tm, tea.KeyEnter)
+	press(tm, tea.KeyEnter) // no existing tags
+	tm.Type("friends")
+	press(tm, tea.KeyEnter)
+	tm.Type("y")
+	waitFor(t, tm, "10 days")
+	golden.RequireEqual(t, finalView(t, tm))
+
+	got, err := st.ListEvents(context.Background(), flowAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d events after create, want 2", len(got))
+	}
+	want := store.Event{
+		ID: got[1].ID, Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian,
+		Month: 3, Day: 14, Year: 1990, Notes: "Likes tea", Tags: []string{"friends"},
+	}
+	if !eventsEqual(got[1], want) {
+		t.Errorf("created event = %+v, want %+v", got[1], want)
+	}
+}
+
+func TestEditReminderFlow(t *testing.T) {
+	ctx := context.Background()
+	st := migratedStore(t)
+	st.EnsureAccount(ctx, flowAccount, "America/New_York")
+	id, err := st.CreateEvent(ctx, flowAccount, store.Event{
+		Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 14, Year: 1990,
+		Notes: "Likes tea",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := startApp(t, st)
+	signIn(t, tm, "Ann")
+	tm.Type("e")
+	waitFor(t, tm, "Edit Reminder", "Likes tea")
+
+	press(tm, tea.KeyEnter) // birthday
+	press(tm, tea.KeyCtrlU) // clear the name
+	tm.Type("Annie")
+	press(tm, tea.KeyEnter)
+	press(tm, tea.KeyEnter) // no label
+	press(tm, tea.KeyEnter) // Gregorian
+	press(tm, tea.KeyEnter) // March
+	press(tm, tea.KeyCtrlU)
+	tm.Type("5")
+	press(tm, tea.KeyEnter)
+	press(tm, tea.KeyEnter) // 1990
+	press(tm, tea.KeyEnter) // same notes
+	press(tm, tea.KeyEnter) // no new tags
+	tm.Type("y")
+	waitFor(t, tm, "3/5/1990")
+	golden.RequireEqual(t, finalView(t, tm))
+
+	got, err := st.GetEvent(ctx, flowAccount, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := store.Event{
+		ID: id, Name: "Annie", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 5, Year: 1990,
+		Notes: "Likes tea",
+	}
+	if !eventsEqual(got, want) {
+		t.Errorf("event after edit = %+v, want %+v", got, want)
+	}
+}
+
+func eventsEqual(a, b store.Event) bool {
+	return a.ID == b.ID && a.Name == b.Name && a.Type == b.Type && a.Label == b.Label &&
+		a.Calendar == b.Calendar && a.Month == b.Month && a.Day == b.Day && a.Year == b.Year &&
+		a.Notes == b.Notes && slices.Equal(a.Tags, b.Tags)
+}
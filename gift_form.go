@@ -0,0 +1,288 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GIFT FORM KEYMAPS
+type gfKeyMap struct {
+	Back key.Binding
+	Quit key.Binding
+}
+
+func (k gfKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Back, k.Quit}
+}
+
+func (k gfKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{
+		k.Back,
+	}}
+}
+
+var gfKeys = gfKeyMap{
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// GIFT FORM MODEL
+
+type gfState struct {
+	birthdayId int
+	editingId  int
+}
+
+type GfModel struct {
+	session *session
+	state   gfState
+	form    *huh.Form
+	width   int
+	km      gfKeyMap
+	banner  errorBanner
+}
+
+// GIFT FORM INITIALIZATION AND VALIDATION
+
+func validateIdea(idea string) error {
+	if strings.TrimSpace(idea) == "" {
+		return i18n.Errorf("idea can't be empty")
+	}
+	return nil
+}
+
+func validateGiftYear(year string) error {
+	if year == "" {
+		return nil
+	}
+	if yearInt, err := strconv.Atoi(year); err != nil || yearInt < 1 {
+		return i18n.Errorf("year must be a number")
+	}
+	return nil
+}
+
+// parsePrice converts a dollar amount such as "25" or "$19.99" into cents.
+func parsePrice(price string) (sql.NullInt64, error) {
+	price = strings.TrimPrefix(strings.TrimSpace(price), "$")
+	if price == "" {
+		return sql.NullInt64{}, nil
+	}
+	dollars, err := strconv.ParseFloat(price, 64)
+	if err != nil || dollars < 0 {
+		return sql.NullInt64{}, i18n.Errorf("price must be a dollar amount")
+	}
+	return sql.NullInt64{Int64: int64(dollars*100 + 0.5), Valid: true}, nil
+}
+
+func validatePrice(price string) error {
+	_, err := parsePrice(price)
+	return err
+}
+
+func formatPrice(cents sql.NullInt64) string {
+	if !cents.Valid {
+		return ""
+	}
+	return fmt.Sprintf("$%d.%02d", cents.Int64/100, cents.Int64%100)
+}
+
+func formatGiftYear(year sql.NullInt64) string {
+	if !year.Valid {
+		return ""
+	}
+	return strconv.FormatInt(year.Int64, 10)
+}
+
+func PopulatedGiftForm(g store.Gift, styles *Styles, p i18n.Printer) *huh.Form {
+	status := g.Status
+	if status == "" {
+		status = store.GiftStatuses[0]
+	}
+	year := formatGiftYear(g.Year)
+	price := strings.TrimPrefix(formatPrice(g.PriceCents), "$")
+	var statusOptions []huh.Option[string]
+	for _, s := range store.GiftStatuses {
+		statusOptions = append(statusOptions, huh.NewOption(p.T(s), s))
+	}
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Key("idea").
+				Title(p.T("Gift")).
+				Description(p.T("What's the gift, or what did they mention wanting?")).
+				Value(&g.Idea).
+				Validate(validateIdea),
+			huh.NewSelect[string]().
+				Key("status").
+				Title(p.T("Status")).
+				Options(statusOptions...).
+				Value(&status),
+			huh.NewInput().
+				Key("year").
+				Title(p.T("Year")).
+				Description(p.T("Which birthday is this for? Leave blank if undecided.")).
+				Value(&year).
+				CharLimit(4).
+				Validate(validateGiftYear),
+			huh.NewInput().
+				Key("price").
+				Title(p.T("Price")).
+				Description(p.T("Optional, in dollars.")).
+				Value(&price).
+				Validate(validatePrice),
+			huh.NewInput().
+				Key("link").
+				Title(p.T("Link")).
+				Description(p.T("Optional link to where it can be bought.")).
+				Value(&g.Link),
+			huh.NewConfirm().
+				Key("confirm").
+				Title(p.T("Save Changes?")).
+				Affirmative(p.T("Yep")).
+				Negative(p.T("Nope")),
+		),
+	).WithShowHelp(false).WithShowErrors(false).WithTheme(styles.Form).WithKeyMap(formKeys(p))
+}
+
+func EmptyGiftForm(s *session, birthdayId int) GfModel {
+	return GfModel{
+		session: s,
+		state: gfState{
+			birthdayId: birthdayId,
+		},
+		form: PopulatedGiftForm(store.Gift{}, s.styles, s.printer),
+		km:   localizeKeys(s.printer, gfKeys),
+	}
+}
+
+func EditGiftForm(s *session, birthdayId int, editingId int) GfModel {
+	gf := EmptyGiftForm(s, birthdayId)
+	gf.state.editingId = editingId
+	return gf
+}
+
+// GIFT FORM COMMANDS
+
+type giftRetrievalMsg struct {
+	gift store.Gift
+}
+
+func getGift(st store.BirthdayStore, phoneNumber string, giftId int) tea.Cmd {
+	return func() tea.Msg {
+		g, err := st.GetGift(context.Background(), phoneNumber, giftId)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return giftRetrievalMsg{g}
+	}
+}
+
+func createGift(st store.BirthdayStore, phoneNumber string, g store.Gift) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := st.CreateGift(context.Background(), phoneNumber, g); err != nil {
+			return dbErrMsg{err}
+		}
+		return dbSuccessMsg{}
+	}
+}
+
+func updateGift(st store.BirthdayStore, phoneNumber string, g store.Gift) tea.Cmd {
+	return func() tea.Msg {
+		if err := st.UpdateGift(context.Background(), phoneNumber, g); err != nil {
+			return dbErrMsg{err}
+		}
+		return dbSuccessMsg{}
+	}
+}
+
+// formGift reads the submitted gift back out of the form. The inputs have
+// already been validated, so conversion errors can't occur.
+func (m *GfModel) formGift() store.Gift {
+	g := store.Gift{
+		ID:      m.state.editingId,
+		EventID: m.state.birthdayId,
+		Idea:    strings.TrimSpace(m.form.GetString("idea")),
+		Status:  m.form.GetString("status"),
+		Link:    strings.TrimSpace(m.form.GetString("link")),
+	}
+	if year, err := strconv.Atoi(m.form.GetString("year")); err == nil {
+		g.Year = sql.NullInt64{Int64: int64(year), Valid: true}
+	}
+	g.PriceCents, _ = parsePrice(m.form.GetString("price"))
+	return g
+}
+
+// GIFT FORM UPDATE-VIEW LOOP
+
+func (m *GfModel) Init() tea.Cmd {
+	if m.state.editingId == 0 {
+		return m.form.PrevField()
+	}
+	return getGift(m.session.store, m.session.phoneNumber, m.state.editingId)
+}
+
+func (m *GfModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 80) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		if key.Matches(msg, m.km.Back) {
+			return m, popScreen()
+		}
+	case giftRetrievalMsg:
+		m.form = PopulatedGiftForm(msg.gift, m.session.styles, m.session.printer)
+		return m, m.form.PrevField()
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	case dbSuccessMsg:
+		return m, popScreen()
+	}
+	f, cmd := m.form.Update(msg)
+	m.form = f.(*huh.Form)
+	if m.form.State == huh.StateCompleted {
+		if !m.form.GetBool("confirm") {
+			return m, popScreen()
+		}
+		if m.state.editingId == 0 {
+			return m, createGift(m.session.store, m.session.phoneNumber, m.formGift())
+		}
+		return m, updateGift(m.session.store, m.session.phoneNumber, m.formGift())
+	}
+	return m, cmd
+}
+
+func (m *GfModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("Gift Idea")))
+	body := m.session.styles.Base.Render(m.form.View() + m.session.styles.FormErrors(m.form, m.session.printer))
+	footer := m.appBoundaryView(m.form.Help().ShortHelpView(slices.Concat(m.km.ShortHelp(), m.form.KeyBinds())))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *GfModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Center,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
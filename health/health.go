@@ -0,0 +1,76 @@
+// Package health answers the liveness and readiness probes of the processes
+// that use the database.
+package health
+
+import (
+	"ashwindharne/bdaybot/migrations"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DB is what the checks need from the store.
+type DB interface {
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (version int, dirty bool, err error)
+}
+
+// Check returns an error when something a process needs isn't working.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Reachable checks that the database answers.
+func Reachable(db DB) Check {
+	return Check{"database", db.Ping}
+}
+
+// Migrated checks that every migration has been applied to the database,
+// and none failed partway.
+func Migrated(db DB) Check {
+	return Check{"migrations", func(ctx context.Context) error {
+		version, dirty, err := db.MigrationVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d failed partway and needs fixing by hand", version)
+		}
+		if want := migrations.Latest(); version != want {
+			return fmt.Errorf("database is at version %d, want %d", version, want)
+		}
+		return nil
+	}}
+}
+
+// Handler runs checks on every request, answering 200 when they all pass
+// and 503 otherwise, with a line for each check.
+func Handler(checks ...Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+		status := http.StatusOK
+		var b strings.Builder
+		for _, c := range checks {
+			if err := c.Run(ctx); err != nil {
+				status = http.StatusServiceUnavailable
+				fmt.Fprintf(&b, "%s: %v\n", c.Name, err)
+			} else {
+				fmt.Fprintf(&b, "%s: ok\n", c.Name)
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write([]byte(b.String()))
+	})
+}
+
+// Routes serves /healthz, which fails when the database can't be reached,
+// and /readyz, which also fails until it's fully migrated.
+func Routes(mux *http.ServeMux, db DB) {
+	mux.Handle("GET /healthz", Handler(Reachable(db)))
+	mux.Handle("GET /readyz", Handler(Reachable(db), Migrated(db)))
+}
@@ -0,0 +1,78 @@
+package health
+
+import (
+	"ashwindharne/bdaybot/migrations"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"database/sql"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRoutes(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.sqlite")
+	var queries atomic.Int64
+	st, err := store.OpenObserved(path, func(op string, took time.Duration) {
+		queries.Add(1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	mux := http.NewServeMux()
+	Routes(mux, st)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if code, body := get("/healthz"); code != http.StatusOK {
+		t.Errorf("healthz: %d\n%s", code, body)
+	}
+	if code, body := get("/readyz"); code != http.StatusServiceUnavailable || !strings.Contains(body, "schema_migrations") {
+		t.Errorf("readyz before migrating: %d\n%s", code, body)
+	}
+
+	// Migrate the way golang-migrate does, stopping one short.
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	files, _ := fs.Glob(migrations.FS, "*.up.sql")
+	latest := migrations.Latest()
+	for _, f := range files[:len(files)-1] {
+		migration, _ := fs.ReadFile(migrations.FS, f)
+		if _, err := db.ExecContext(ctx, string(migration)); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+	}
+	db.ExecContext(ctx, `create table schema_migrations (version bigint not null primary key, dirty boolean not null);`)
+	db.ExecContext(ctx, `insert into schema_migrations values (?, false);`, latest-1)
+	if code, body := get("/readyz"); code != http.StatusServiceUnavailable || !strings.Contains(body, "want") {
+		t.Errorf("readyz a migration behind: %d\n%s", code, body)
+	}
+	db.ExecContext(ctx, `update schema_migrations set version = ?;`, latest)
+	if code, body := get("/readyz"); code != http.StatusOK || body != "database: ok\nmigrations: ok\n" {
+		t.Errorf("readyz when migrated: %d\n%s", code, body)
+	}
+	if queries.Load() == 0 {
+		t.Error("queries weren't observed")
+	}
+}
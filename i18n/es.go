@@ -0,0 +1,321 @@
+package i18n
+
+// spanish translates the UI and reminder messages into Spanish. Keys are the
+// English messages passed to Printer.T and Errorf.
+var spanish = map[string]string{
+	// Screens
+	"Birthday Reminders · %s": "Recordatorios de cumpleaños · %s",
+	"Birthday Calendar":       "Calendario de cumpleaños",
+	"New Reminder":            "Nuevo recordatorio",
+	"Edit Reminder":           "Editar recordatorio",
+	"Gift Idea":               "Idea de regalo",
+	"Settings · %s":           "Ajustes · %s",
+	"Nothing on this day":     "Nada este día",
+	"It's today!":             "¡Es hoy!",
+	"It's tomorrow!":          "¡Es mañana!",
+	"%d days":                 "%d días",
+	"%[1]d %[2]s (%[3]d)":     "%[1]d de %[2]s (%[3]d)",
+	"Sorry, something went wrong and it's been logged.": "Lo sentimos, algo salió mal y ha quedado registrado.",
+	"Press esc to go back or q to quit.":                "Pulsa esc para volver o q para salir.",
+	"Upcoming Reminders":                                "Próximos recordatorios",
+	"Reminders are turned off in settings.":             "Los recordatorios están desactivados en los ajustes.",
+	"No reminders in the next %d days.":                 "No hay recordatorios en los próximos %d días.",
+	"Test message sent to %s.":                          "Mensaje de prueba enviado al %s.",
+	"Shared Lists":                                      "Listas compartidas",
+	"New List":                                          "Nueva lista",
+	"Invite to %s":                                      "Invitar a %s",
+	"Invitations: %d":                                   "Invitaciones: %d",
+	"Members":                                           "Miembros",
+	"Invitations":                                       "Invitaciones",
+	"%s invited you to %s as %s":                        "%s te invitó a %s como %s",
+	"Press a to accept or x to decline the first invitation.": "Pulsa a para aceptar o x para rechazar la primera invitación.",
+	"You can only view this list.":                            "Solo puedes ver esta lista.",
+	"Birthday Submissions · %s":                               "Cumpleaños enviados · %s",
+	"Collection Link":                                         "Enlace para recoger cumpleaños",
+	"Anyone with the link can submit their birthday. Nothing is added until you approve it. A new link stops the old one from working.": "Cualquiera con el enlace puede enviar su cumpleaños. No se añade nada hasta que lo apruebes. Un enlace nuevo deja sin validez el anterior.",
+	"Collection links aren't set up on this server.":                               "Los enlaces para recoger cumpleaños no están configurados en este servidor.",
+	"No birthdays are waiting for review.":                                         "No hay cumpleaños pendientes de revisar.",
+	"Add Your Birthday":                                                            "Añade tu cumpleaños",
+	"You've been asked for your birthday for the list %s.":                         "Te han pedido tu cumpleaños para la lista %s.",
+	"Thanks! Your birthday will be added to %s once the list's owner approves it.": "¡Gracias! Tu cumpleaños se añadirá a %s cuando quien tiene la lista lo apruebe.",
+	"This link isn't valid anymore. Ask whoever sent it for a new one.":            "Este enlace ya no es válido. Pide uno nuevo a quien te lo envió.",
+	"API Tokens":     "Tokens de API",
+	"New API Token":  "Nuevo token de API",
+	"Your New Token": "Tu nuevo token",
+	"Tokens let other tools manage your birthdays and settings through the API. Revoke any you no longer use.": "Los tokens permiten que otras herramientas gestionen tus cumpleaños y ajustes mediante la API. Revoca los que ya no uses.",
+	"You don't have any API tokens yet.": "Todavía no tienes tokens de API.",
+	"Copy it now; it won't be shown again. Send it as a bearer token in the Authorization header.": "Cópialo ahora; no se volverá a mostrar. Envíalo como token bearer en la cabecera Authorization.",
+	"Organizations":                   "Organizaciones",
+	"New Organization":                "Nueva organización",
+	"Add to %s":                       "Añadir a %s",
+	"Your Birthday · %s":              "Tu cumpleaños · %s",
+	"Announcements":                   "Anuncios",
+	"Off, no webhook":                 "Desactivados, sin webhook",
+	"Daily at %s (%s)":                "Cada día a las %s (%s)",
+	"Press m to enter your birthday.": "Pulsa m para indicar tu cumpleaños.",
+	"You're not on any organization's roster. Press n to start one for your team.": "No estás en la plantilla de ninguna organización. Pulsa n para crear una para tu equipo.",
+	"History · %s": "Historial · %s",
+	"No changes have been recorded for this event yet.": "Todavía no se ha registrado ningún cambio de este evento.",
+
+	// Table and form fields
+	"Name":                                   "Nombre",
+	"Occasion":                               "Ocasión",
+	"Date":                                   "Fecha",
+	"How Soon?":                              "¿Cuándo?",
+	"Tags":                                   "Etiquetas",
+	"Gift":                                   "Regalo",
+	"Status":                                 "Estado",
+	"Year":                                   "Año",
+	"Price":                                  "Precio",
+	"Link":                                   "Enlace",
+	"Label":                                  "Etiqueta",
+	"Calendar":                               "Calendario",
+	"Month":                                  "Mes",
+	"Day":                                    "Día",
+	"Notes":                                  "Notas",
+	"When":                                   "Cuándo",
+	"Channel":                                "Canal",
+	"Days Before":                            "Días antes",
+	"Message":                                "Mensaje",
+	"Text message":                           "SMS",
+	"New Tags":                               "Nuevas etiquetas",
+	"Country":                                "País",
+	"List":                                   "Lista",
+	"Role":                                   "Rol",
+	"Phone Number":                           "Número de teléfono",
+	"Send Invitation?":                       "¿Enviar invitación?",
+	"Everyone on the list will be reminded.": "Se avisará a todos los miembros de la lista.",
+	"What's the list for, e.g. \"Family\"?":  "¿Para qué es la lista? P. ej. \"Familia\".",
+	"Who to share the list with. They'll see the invitation when they sign in.": "Con quién compartir la lista. Verá la invitación al iniciar sesión.",
+	"Editors can change the list; viewers only get its reminders.":              "Los editores pueden cambiar la lista; los lectores solo reciben sus recordatorios.",
+	"Webhook":           "Webhook",
+	"Announcement Time": "Hora del anuncio",
+	"Submitted":         "Enviado",
+	"Send":              "Enviar",
+	"Not entered":       "Sin indicar",
+	"Created":           "Creado",
+	"Last Used":         "Último uso",
+	"Never":             "Nunca",
+	"What will use the token, e.g. \"Home Assistant\"?": "¿Qué usará el token? P. ej. \"Home Assistant\".",
+	"Create Token?": "¿Crear token?",
+	"Your team or company, as announcements should name it.":                                                "Tu equipo o empresa, tal como deben nombrarlo los anuncios.",
+	"Incoming webhook of the team channel to announce birthdays in. Leave blank to turn announcements off.": "Webhook de entrada del canal del equipo donde anunciar los cumpleaños. Déjalo en blanco para desactivar los anuncios.",
+	"Where the team's day starts. Type / to search.":                                                        "Dónde empieza el día del equipo. Escribe / para buscar.",
+	"When to post on the day of a birthday.":                                                                "Cuándo publicar el día de un cumpleaños.",
+	"They can enter their own birthday by signing in with this number.":                                     "Podrá indicar su cumpleaños iniciando sesión con este número.",
+	"As the team knows them.":                                                                               "Como lo conoce el equipo.",
+	"Admins manage the roster and announcements.":                                                           "Los administradores gestionan la plantilla y los anuncios.",
+	"Optional. Announcements give ages only when the year is shared.":                                       "Opcional. Los anuncios solo dicen la edad si el año se comparte.",
+	"Share Your Birth Year?":                                                                                "¿Compartir tu año de nacimiento?",
+	"Turn off to keep your age out of the roster and announcements.":                                        "Desactívalo para que tu edad no aparezca en la plantilla ni en los anuncios.",
+	"Save Changes?":              "¿Guardar cambios?",
+	"Yep":                        "Sí",
+	"Nope":                       "No",
+	"What kind of date is this?": "¿Qué tipo de fecha es?",
+	"Who is this for? For anniversaries, e.g. \"Ann & Bob\".":                            "¿Para quién es? Para aniversarios, p. ej. \"Ana y Bob\".",
+	"Optional for anniversaries (\"wedding\", \"work\"), required for custom occasions.": "Opcional para aniversarios (\"boda\", \"trabajo\"), obligatoria para ocasiones personalizadas.",
+	"Which calendar is the date kept in?":                                                "¿En qué calendario se guarda la fecha?",
+	"Enter the month of the occasion.":                                                   "Indica el mes de la ocasión.",
+	"Enter the day of the occasion.":                                                     "Indica el día de la ocasión.",
+	"Enter the year they were born, married, started, etc.":                              "Indica el año en que nació, se casó, empezó, etc.",
+	"Anything worth remembering, like what they mentioned wanting.":                      "Cualquier cosa que valga la pena recordar, como lo que dijo que quería.",
+	"Select the groups this person belongs to.":                                          "Elige los grupos a los que pertenece esta persona.",
+	"Comma-separated tags to create, e.g. family, coworkers.":                            "Etiquetas nuevas separadas por comas, p. ej. familia, compañeros.",
+	"What's the gift, or what did they mention wanting?":                                 "¿Cuál es el regalo, o qué dijo que quería?",
+	"Which birthday is this for? Leave blank if undecided.":                              "¿Para qué cumpleaños es? Déjalo en blanco si no lo sabes.",
+	"Optional, in dollars.":                                                              "Opcional, en dólares.",
+	"Optional link to where it can be bought.":                                           "Enlace opcional a dónde comprarlo.",
+	"Enter your phone number.":                                                           "Introduce tu número de teléfono.",
+	"Please enter the phone number that you would like alerts to be sent to. Numbers from other countries can be entered with + and the country code.": "Introduce el número de teléfono al que quieres que enviemos los avisos. Los números de otros países se pueden introducir con + y el prefijo del país.",
+	"Who":     "Quién",
+	"Via":     "Desde",
+	"Field":   "Campo",
+	"Change":  "Cambio",
+	"You":     "Tú",
+	"Unknown": "Desconocido",
+	"Local":   "Local",
+	"Deleted": "Borrado",
+
+	// Settings
+	"Days of Notice": "Días de antelación",
+	"How many days ahead of a birthday to start sending reminders.": "Con cuántos días de antelación empezar a enviar recordatorios.",
+	"Timezone": "Zona horaria",
+	"Defaults to your phone number's country. Type / to search.": "Por defecto, la del país de tu número. Escribe / para buscar.",
+	"Reminder Time": "Hora del recordatorio",
+	"When to send reminders, in your timezone.": "Cuándo enviar los recordatorios, en tu zona horaria.",
+	"Send Reminders?":                  "¿Enviar recordatorios?",
+	"Include Gift Ideas in Reminders?": "¿Incluir ideas de regalo en los recordatorios?",
+	"Theme":                            "Tema",
+	"Colors for this app. High contrast and colorblind safe themes are included.": "Colores de la aplicación. Incluye temas de alto contraste y aptos para daltónicos.",
+	"Language": "Idioma",
+	"Language and date format for this app and your reminders.":      "Idioma y formato de fecha de la aplicación y de tus recordatorios.",
+	"Days of Notice for #%s":                                         "Días de antelación para #%s",
+	"Leave blank to use the account setting. Use 1 for day-of only.": "Déjalo en blanco para usar el ajuste de la cuenta. Usa 1 para avisar solo el mismo día.",
+	"Default":              "Predeterminado",
+	"High contrast":        "Alto contraste",
+	"Colorblind safe":      "Apto para daltónicos",
+	"Delete Account":       "Borrar cuenta",
+	"Delete Your Account?": "¿Borrar tu cuenta?",
+	"Your birthdays, gifts, lists and settings will be deleted in %d days. You won't get reminders in the meantime, and you can change your mind here until then.": "Tus cumpleaños, regalos, listas y ajustes se borrarán dentro de %d días. Mientras tanto no recibirás recordatorios, y hasta entonces puedes cambiar de opinión aquí.",
+	"Delete":                              "Borrar",
+	"Keep":                                "Conservar",
+	"Keep Your Account?":                  "¿Conservar tu cuenta?",
+	"Your account will be deleted on %s.": "Tu cuenta se borrará el %s.",
+	"Your account will be deleted on %s. Press ctrl+x to keep it.": "Tu cuenta se borrará el %s. Pulsa ctrl+x para conservarla.",
+
+	// Validation
+	"day must be number between 1 and 31":        "el día debe ser un número entre 1 y 31",
+	"year must be number between 1 and %d":       "el año debe ser un número entre 1 y %d",
+	"custom occasions need a label":              "las ocasiones personalizadas necesitan una etiqueta",
+	"tags can't be empty":                        "las etiquetas no pueden estar vacías",
+	"tags can't contain commas":                  "las etiquetas no pueden contener comas",
+	"invalid date: %v":                           "fecha no válida: %v",
+	"idea can't be empty":                        "la idea no puede estar vacía",
+	"year must be a number":                      "el año debe ser un número",
+	"price must be a dollar amount":              "el precio debe ser una cantidad en dólares",
+	"must be a number between 1 and 365":         "debe ser un número entre 1 y 365",
+	"must be a timezone like America/New_York":   "debe ser una zona horaria como Europe/Madrid",
+	"enter a phone number":                       "introduce un número de teléfono",
+	"start with + and the country code":          "empieza con + y el prefijo del país",
+	"not a valid international number":           "no es un número internacional válido",
+	"%s numbers have %d digits":                  "los números de %s tienen %d dígitos",
+	"%s numbers have %d to %d digits":            "los números de %s tienen de %d a %d dígitos",
+	"not a valid %s number":                      "no es un número válido de %s",
+	"numbers only":                               "solo números",
+	"name can't be empty":                        "el nombre no puede estar vacío",
+	"that's your own number":                     "ese es tu propio número",
+	"you can only view %s":                       "solo puedes ver %s",
+	"only the owner of %s can invite people":     "solo quien es dueño de %s puede invitar",
+	"only admins of %s can do that":              "solo los administradores de %s pueden hacer eso",
+	"you can't remove yourself":                  "no puedes quitarte a ti mismo",
+	"already on the roster":                      "ya está en la plantilla",
+	"must be a link starting with https://":      "debe ser un enlace que empiece por https://",
+	"only the owner of %s can collect birthdays": "solo quien es dueño de %s puede recoger cumpleaños",
+	"name must be at most %d characters":         "el nombre debe tener como máximo %d caracteres",
+	"pick a month":                               "elige un mes",
+	"that date doesn't exist":                    "esa fecha no existe",
+
+	// Occasions, calendars and gifts
+	"Birthday":                  "Cumpleaños",
+	"Anniversary":               "Aniversario",
+	"Memorial":                  "Conmemoración",
+	"Custom":                    "Personalizada",
+	"birthday":                  "cumpleaños",
+	"%s birthday":               "%s cumpleaños",
+	"anniversary":               "aniversario",
+	"%s anniversary":            "aniversario de %s",
+	"memorial":                  "en memoria",
+	"1 year in memory":          "1 año en su memoria",
+	"%d years in memory":        "%d años en su memoria",
+	"occasion":                  "ocasión",
+	"%s (%d years)":             "%s (%d años)",
+	"%s's %s":                   "%[2]s de %[1]s",
+	"Remembering %s":            "En memoria de %s",
+	"Remembering %s (%d years)": "En memoria de %s (%d años)",
+	"Gregorian":                 "Gregoriano",
+	"Chinese lunar":             "Lunar chino",
+	"Hebrew":                    "Hebreo",
+	"1st month (Zhēngyuè)":      "1.er mes (Zhēngyuè)",
+	"2nd month":                 "2.º mes",
+	"3rd month":                 "3.er mes",
+	"4th month":                 "4.º mes",
+	"5th month":                 "5.º mes",
+	"6th month":                 "6.º mes",
+	"7th month":                 "7.º mes",
+	"8th month":                 "8.º mes",
+	"9th month":                 "9.º mes",
+	"10th month":                "10.º mes",
+	"11th month (Dōngyuè)":      "11.º mes (Dōngyuè)",
+	"12th month (Làyuè)":        "12.º mes (Làyuè)",
+	"idea":                      "idea",
+	"bought":                    "comprado",
+	"given":                     "regalado",
+	"Personal":                  "Personal",
+	"Owner":                     "Propietario",
+	"Editor":                    "Editor",
+	"Viewer":                    "Lector",
+	"Admin":                     "Administrador",
+	"Member":                    "Miembro",
+
+	// Reminder messages
+	"Reminder: %s on %s.":    "Recordatorio: %s el %s.",
+	"Gift ideas: %s":         "Ideas de regalo: %s",
+	"Last year you gave: %s": "El año pasado regalaste: %s",
+	"%s and %s":              "%s y %s",
+	"🎂 Happy birthday to %s from everyone at %s!":                                      "🎂 ¡Feliz cumpleaños a %s de parte de todo el equipo de %s!",
+	"This is a test message from bdaybot. Your reminders will be sent to this number.": "Este es un mensaje de prueba de bdaybot. Tus recordatorios se enviarán a este número.",
+
+	// Key help, including huh's
+	"move up":            "subir",
+	"move down":          "bajar",
+	"create event":       "crear evento",
+	"edit event":         "editar evento",
+	"notes & gifts":      "notas y regalos",
+	"month view":         "vista mensual",
+	"filter by tag":      "filtrar por etiqueta",
+	"settings":           "ajustes",
+	"upcoming reminders": "próximos recordatorios",
+	"send test message":  "enviar mensaje de prueba",
+	"shared lists":       "listas compartidas",
+	"new list":           "nueva lista",
+	"invite":             "invitar",
+	"accept invitation":  "aceptar invitación",
+	"decline invitation": "rechazar invitación",
+	"organizations":      "organizaciones",
+	"new organization":   "nueva organización",
+	"roster":             "plantilla",
+	"my birthday":        "mi cumpleaños",
+	"add member":         "añadir miembro",
+	"edit member":        "editar miembro",
+	"remove member":      "quitar miembro",
+	"announcements":      "anuncios",
+	"collect birthdays":  "recoger cumpleaños",
+	"approve":            "aprobar",
+	"API tokens":         "tokens de API",
+	"new token":          "nuevo token",
+	"revoke":             "revocar",
+	"history":            "historial",
+	"delete account":     "borrar cuenta",
+	"reject":             "rechazar",
+	"new link":           "nuevo enlace",
+	"quit":               "salir",
+	"more":               "más",
+	"back":               "volver",
+	"prev day":           "día anterior",
+	"next day":           "día siguiente",
+	"prev week":          "semana anterior",
+	"next week":          "semana siguiente",
+	"prev month":         "mes anterior",
+	"next month":         "mes siguiente",
+	"next person":        "siguiente persona",
+	"add gift":           "añadir regalo",
+	"edit gift":          "editar regalo",
+	"delete gift":        "borrar regalo",
+	"next":               "siguiente",
+	"submit":             "enviar",
+	"select":             "elegir",
+	"filter":             "filtrar",
+	"set filter":         "aplicar filtro",
+	"clear filter":       "quitar filtro",
+	"toggle":             "marcar",
+	"select all":         "elegir todo",
+	"select none":        "no elegir nada",
+	"confirm":            "confirmar",
+	"up":                 "arriba",
+	"down":               "abajo",
+	"left":               "izquierda",
+	"right":              "derecha",
+	"first":              "primero",
+	"last":               "último",
+	"go to start":        "ir al inicio",
+	"go to end":          "ir al final",
+	"page up":            "página arriba",
+	"page down":          "página abajo",
+	"½ page up":          "½ página arriba",
+	"½ page down":        "½ página abajo",
+	"complete":           "completar",
+	"new line":           "nueva línea",
+	"open":               "abrir",
+	"close":              "cerrar",
+	"open editor":        "abrir editor",
+}
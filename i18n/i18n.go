@@ -0,0 +1,255 @@
+// Package i18n translates the strings of the UI and of reminder messages,
+// and formats dates the way each supported locale writes them.
+//
+// Messages are looked up by their English text, so code reads naturally and
+// English needs no catalog: p.T("%d days", n). Anything missing from a
+// catalog falls back to English.
+package i18n
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Locale string
+
+const (
+	EnglishUS Locale = "en-US"
+	EnglishGB Locale = "en-GB"
+	Spanish   Locale = "es"
+)
+
+// Locales lists the supported locales in the order they're offered in
+// settings. The first is the default.
+var Locales = []Locale{EnglishUS, EnglishGB, Spanish}
+
+// dateOrder is how a locale orders the parts of a numeric date.
+type dateOrder int
+
+const (
+	monthFirst dateOrder = iota // 3/14/2024
+	dayFirst                    // 14/3/2024
+)
+
+type localeInfo struct {
+	title    string
+	catalog  map[string]string
+	order    dateOrder
+	months   [12]string
+	weekdays [7]string
+	// firstWeekday is the day calendars start their weeks on.
+	firstWeekday time.Weekday
+	// clock is the time.Format layout for a time of day.
+	clock string
+	// longDate formats a weekday, day and month, e.g. "Monday, January 2".
+	longDate func(weekday, month string, day int) string
+	// monthYear formats a month and year, e.g. "January 2024".
+	monthYear func(month string, year int) string
+	ordinal   func(n int) string
+}
+
+var englishMonths = [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+
+var englishWeekdays = [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+var locales = map[Locale]localeInfo{
+	EnglishUS: {
+		title:        "English (US)",
+		order:        monthFirst,
+		months:       englishMonths,
+		weekdays:     englishWeekdays,
+		firstWeekday: time.Sunday,
+		clock:        "3:04 PM",
+		longDate:     func(weekday, month string, day int) string { return fmt.Sprintf("%s, %s %d", weekday, month, day) },
+		monthYear:    func(month string, year int) string { return fmt.Sprintf("%s %d", month, year) },
+		ordinal:      englishOrdinal,
+	},
+	EnglishGB: {
+		title:        "English (UK)",
+		order:        dayFirst,
+		months:       englishMonths,
+		weekdays:     englishWeekdays,
+		firstWeekday: time.Monday,
+		clock:        "15:04",
+		longDate:     func(weekday, month string, day int) string { return fmt.Sprintf("%s %d %s", weekday, day, month) },
+		monthYear:    func(month string, year int) string { return fmt.Sprintf("%s %d", month, year) },
+		ordinal:      englishOrdinal,
+	},
+	Spanish: {
+		title:        "Español",
+		catalog:      spanish,
+		order:        dayFirst,
+		months:       [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		weekdays:     [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		firstWeekday: time.Monday,
+		clock:        "15:04",
+		longDate:     func(weekday, month string, day int) string { return fmt.Sprintf("%s, %d de %s", weekday, day, month) },
+		monthYear:    func(month string, year int) string { return fmt.Sprintf("%s de %d", month, year) },
+		ordinal:      func(n int) string { return fmt.Sprintf("%d.º", n) },
+	},
+}
+
+// englishOrdinal formats n with its suffix: 1st, 2nd, 3rd, 11th, 22nd...
+func englishOrdinal(n int) string {
+	suffix := "th"
+	switch n % 100 {
+	case 11, 12, 13:
+	default:
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// Title is the locale's name in its own language, e.g. "Español".
+func (l Locale) Title() string {
+	return locales[l].title
+}
+
+// Parse returns the locale named s.
+func Parse(s string) (Locale, error) {
+	if l := Locale(s); slices.Contains(Locales, l) {
+		return l, nil
+	}
+	return "", fmt.Errorf("unknown locale %q", s)
+}
+
+// Match picks the supported locale closest to a POSIX locale or language
+// tag such as "es_MX.UTF-8" or "en-GB", as found in $LANG.
+func Match(tag string) (Locale, bool) {
+	tag, _, _ = strings.Cut(tag, ".")
+	tag = strings.ReplaceAll(tag, "_", "-")
+	lang, region, _ := strings.Cut(tag, "-")
+	switch strings.ToLower(lang) {
+	case "en":
+		if strings.EqualFold(region, "GB") {
+			return EnglishGB, true
+		}
+		return EnglishUS, true
+	case "es":
+		return Spanish, true
+	}
+	return "", false
+}
+
+// Printer renders messages and dates for one locale.
+type Printer struct {
+	locale Locale
+	info   localeInfo
+}
+
+// For returns the printer for l, or for the default locale when l isn't
+// supported.
+func For(l Locale) Printer {
+	info, ok := locales[l]
+	if !ok {
+		l = Locales[0]
+		info = locales[l]
+	}
+	return Printer{l, info}
+}
+
+func (p Printer) Locale() Locale {
+	return p.locale
+}
+
+// T translates message and formats it with args like fmt.Sprintf. Arguments
+// can be reordered in translations with explicit indexes such as %[2]s.
+func (p Printer) T(message string, args ...any) string {
+	if translated, ok := p.info.catalog[message]; ok {
+		message = translated
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Message is an error whose text can be translated. Its Error method gives
+// the English text.
+type Message struct {
+	Format string
+	Args   []any
+}
+
+// Errorf returns an error that Printer.Error can translate.
+func Errorf(format string, args ...any) error {
+	return &Message{format, args}
+}
+
+func (m *Message) Error() string {
+	return fmt.Sprintf(m.Format, m.Args...)
+}
+
+// Error translates err if it was made with Errorf, and otherwise returns its
+// text unchanged.
+func (p Printer) Error(err error) string {
+	if m, ok := err.(*Message); ok {
+		return p.T(m.Format, m.Args...)
+	}
+	return err.Error()
+}
+
+// Date formats a numeric date in the locale's order, e.g. 3/14/2024 or
+// 14/3/2024.
+func (p Printer) Date(year int, month time.Month, day int) string {
+	return p.DayMonth(month, day) + fmt.Sprintf("/%d", year)
+}
+
+// DayMonth formats a numeric date without the year, e.g. 3/14 or 14/3.
+func (p Printer) DayMonth(month time.Month, day int) string {
+	if p.info.order == dayFirst {
+		return fmt.Sprintf("%d/%d", day, month)
+	}
+	return fmt.Sprintf("%d/%d", month, day)
+}
+
+// Month returns the name of a Gregorian month.
+func (p Printer) Month(month time.Month) string {
+	return p.info.months[month-1]
+}
+
+// Weekday returns the name of a day of the week.
+func (p Printer) Weekday(day time.Weekday) string {
+	return p.info.weekdays[day]
+}
+
+// WeekdayAbbr returns the two letter abbreviation of a day of the week, as
+// used for calendar column headings.
+func (p Printer) WeekdayAbbr(day time.Weekday) string {
+	name := []rune(p.Weekday(day))
+	return string(name[:2])
+}
+
+// FirstWeekday is the day of the week calendars start on.
+func (p Printer) FirstWeekday() time.Weekday {
+	return p.info.firstWeekday
+}
+
+// Clock formats t's time of day, e.g. "9:00 AM" or "09:00".
+func (p Printer) Clock(t time.Time) string {
+	return t.Format(p.info.clock)
+}
+
+// LongDate formats t's weekday, day and month, e.g. "Monday, January 2".
+func (p Printer) LongDate(t time.Time) string {
+	return p.info.longDate(p.Weekday(t.Weekday()), p.Month(t.Month()), t.Day())
+}
+
+// MonthYear formats t's month and year, e.g. "January 2024".
+func (p Printer) MonthYear(t time.Time) string {
+	return p.info.monthYear(p.Month(t.Month()), t.Year())
+}
+
+// Ordinal formats n as an ordinal number, e.g. "1st" or "1.º".
+func (p Printer) Ordinal(n int) string {
+	return p.info.ordinal(n)
+}
@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"errors"
+	"maps"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDates(t *testing.T) {
+	day := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		locale    Locale
+		date      string
+		long      string
+		monthYear string
+	}{
+		{EnglishUS, "3/4/2024", "Monday, March 4", "March 2024"},
+		{EnglishGB, "4/3/2024", "Monday 4 March", "March 2024"},
+		{Spanish, "4/3/2024", "lunes, 4 de marzo", "marzo de 2024"},
+	}
+	for _, tt := range tests {
+		p := For(tt.locale)
+		if got := p.Date(2024, time.March, 4); got != tt.date {
+			t.Errorf("%s: Date = %q, want %q", tt.locale, got, tt.date)
+		}
+		if got := p.LongDate(day); got != tt.long {
+			t.Errorf("%s: LongDate = %q, want %q", tt.locale, got, tt.long)
+		}
+		if got := p.MonthYear(day); got != tt.monthYear {
+			t.Errorf("%s: MonthYear = %q, want %q", tt.locale, got, tt.monthYear)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	es := For(Spanish)
+	if got := es.T("%s's %s", "Ann", "34.º cumpleaños"); got != "34.º cumpleaños de Ann" {
+		t.Errorf("reordered translation = %q", got)
+	}
+	if got := es.T("Not in any catalog %d", 1); got != "Not in any catalog 1" {
+		t.Errorf("missing translation = %q, want the English", got)
+	}
+	if got := For("fr").T("%d days", 3); got != "3 days" {
+		t.Errorf("unsupported locale = %q, want the default", got)
+	}
+
+	err := Errorf("year must be number between 1 and %d", 2024)
+	if err.Error() != "year must be number between 1 and 2024" {
+		t.Errorf("Error() = %q, want the English", err.Error())
+	}
+	if got := es.Error(err); got != "el año debe ser un número entre 1 y 2024" {
+		t.Errorf("translated error = %q", got)
+	}
+	if got := es.Error(errors.New("disk full")); got != "disk full" {
+		t.Errorf("plain error = %q", got)
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	en := For(EnglishUS)
+	for n, want := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 112: "112th"} {
+		if got := en.Ordinal(n); got != want {
+			t.Errorf("Ordinal(%d) = %q, want %q", n, got, want)
+		}
+	}
+	if got := For(Spanish).Ordinal(21); got != "21.º" {
+		t.Errorf("Spanish Ordinal(21) = %q", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for tag, want := range map[string]Locale{
+		"en_US.UTF-8": EnglishUS,
+		"en_GB.UTF-8": EnglishGB,
+		"en-AU":       EnglishUS,
+		"es_MX.UTF-8": Spanish,
+		"es":          Spanish,
+	} {
+		if got, ok := Match(tag); !ok || got != want {
+			t.Errorf("Match(%q) = %q, %v, want %q", tag, got, ok, want)
+		}
+	}
+	for _, tag := range []string{"C", "POSIX", "fr_FR.UTF-8", ""} {
+		if got, ok := Match(tag); ok {
+			t.Errorf("Match(%q) = %q, want no match", tag, got)
+		}
+	}
+}
+
+// verbs maps each argument a format uses to its verb, e.g. "%[2]s of %[1]d"
+// to {1: 'd', 2: 's'}.
+func verbs(format string) map[int]byte {
+	used := map[int]byte{}
+	arg := 1
+	for _, m := range verbPattern.FindAllStringSubmatch(format, -1) {
+		if m[1] != "" {
+			arg, _ = strconv.Atoi(m[1])
+		}
+		used[arg] = m[2][0]
+		arg++
+	}
+	return used
+}
+
+var verbPattern = regexp.MustCompile(`%(?:\[(\d+)\])?([a-z])`)
+
+// Every translation must use the same arguments, with the same verbs, as its
+// English message.
+func TestCatalogVerbs(t *testing.T) {
+	for message, translation := range spanish {
+		if !maps.Equal(verbs(message), verbs(translation)) {
+			t.Errorf("%q is translated as %q, which formats different arguments", message, translation)
+		}
+	}
+}
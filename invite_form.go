@@ -0,0 +1,173 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/phone"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"slices"
+)
+
+// INVITE FORM KEYMAPS
+type ifKeyMap struct {
+	Back key.Binding
+	Quit key.Binding
+}
+
+func (k ifKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Back, k.Quit}
+}
+
+func (k ifKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{
+		k.Back,
+	}}
+}
+
+var ifKeys = ifKeyMap{
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// INVITE FORM MODEL
+
+// IfModel invites a phone number to one of the account's lists. The
+// invitation waits on the lists screen of whoever signs in with that number.
+type IfModel struct {
+	session *session
+	list    store.List
+	form    *huh.Form
+	width   int
+	km      ifKeyMap
+	banner  errorBanner
+}
+
+// INVITE FORM INITIALIZATION AND VALIDATION
+
+// validateInvitee accepts any valid number, read as from *country, other
+// than the account's own.
+func validateInvitee(self string, country *string) func(string) error {
+	return func(s string) error {
+		number, err := phone.Parse(s, *country)
+		if err != nil {
+			return err
+		}
+		if number.E164() == self {
+			return i18n.Errorf("that's your own number")
+		}
+		return nil
+	}
+}
+
+func EmptyInviteForm(s *session, l store.List) IfModel {
+	country := "US"
+	role := store.Editor
+	f := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Key("country").
+				Title(s.T("Country")).
+				Options(countryOptions()...).
+				Value(&country),
+			huh.NewInput().
+				Key("phone").
+				Title(s.T("Phone Number")).
+				Description(s.T("Who to share the list with. They'll see the invitation when they sign in.")).
+				Validate(validateInvitee(s.phoneNumber, &country)),
+			huh.NewSelect[store.Role]().
+				Key("role").
+				Title(s.T("Role")).
+				Description(s.T("Editors can change the list; viewers only get its reminders.")).
+				Options(
+					huh.NewOption(roleTitle(s.printer, store.Editor), store.Editor),
+					huh.NewOption(roleTitle(s.printer, store.Viewer), store.Viewer),
+				).
+				Value(&role),
+			huh.NewConfirm().
+				Key("confirm").
+				Title(s.T("Send Invitation?")).
+				Affirmative(s.T("Yep")).
+				Negative(s.T("Nope")),
+		),
+	).WithShowHelp(false).WithShowErrors(false).WithTheme(s.styles.Form).WithKeyMap(formKeys(s.printer))
+	return IfModel{
+		session: s,
+		list:    l,
+		form:    f,
+		km:      localizeKeys(s.printer, ifKeys),
+	}
+}
+
+// INVITE FORM COMMANDS
+
+func invite(st store.ListStore, phoneNumber string, listID int, invitee string, role store.Role) tea.Cmd {
+	return func() tea.Msg {
+		if err := st.Invite(context.Background(), phoneNumber, listID, invitee, role); err != nil {
+			return dbErrMsg{err}
+		}
+		return dbSuccessMsg{}
+	}
+}
+
+// INVITE FORM UPDATE-VIEW LOOP
+
+func (m *IfModel) Init() tea.Cmd {
+	return m.form.PrevField()
+}
+
+func (m *IfModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 80) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		if key.Matches(msg, m.km.Back) {
+			return m, popScreen()
+		}
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	case dbSuccessMsg:
+		return m, popScreen()
+	}
+	f, cmd := m.form.Update(msg)
+	m.form = f.(*huh.Form)
+	if m.form.State == huh.StateCompleted {
+		if !m.form.GetBool("confirm") {
+			return m, popScreen()
+		}
+		// The number has already been validated, so this can't fail.
+		number, _ := phone.Parse(m.form.GetString("phone"), m.form.GetString("country"))
+		role, _ := m.form.Get("role").(store.Role)
+		return m, invite(m.session.store, m.session.phoneNumber, m.list.ID, number.E164(), role)
+	}
+	return m, cmd
+}
+
+func (m *IfModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("Invite to %s", listName(m.session.printer, m.list.Name))))
+	body := m.session.styles.Base.Render(m.form.View() + m.session.styles.FormErrors(m.form, m.session.printer))
+	footer := m.appBoundaryView(m.form.Help().ShortHelpView(slices.Concat(m.km.ShortHelp(), m.form.KeyBinds())))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *IfModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Center,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"slices"
+	"strings"
+)
+
+// LIST FORM KEYMAPS
+type lfKeyMap struct {
+	Back key.Binding
+	Quit key.Binding
+}
+
+func (k lfKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Back, k.Quit}
+}
+
+func (k lfKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{
+		k.Back,
+	}}
+}
+
+var lfKeys = lfKeyMap{
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// LIST FORM MODEL
+
+// LfModel names a new list owned by the account.
+type LfModel struct {
+	session *session
+	form    *huh.Form
+	width   int
+	km      lfKeyMap
+	banner  errorBanner
+}
+
+// LIST FORM INITIALIZATION AND VALIDATION
+
+// validateName requires a name for lists, organizations and their members.
+func validateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return i18n.Errorf("name can't be empty")
+	}
+	return nil
+}
+
+func EmptyListForm(s *session) LfModel {
+	f := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Key("name").
+				Title(s.T("Name")).
+				Description(s.T("What's the list for, e.g. \"Family\"?")).
+				Validate(validateName),
+			huh.NewConfirm().
+				Key("confirm").
+				Title(s.T("Save Changes?")).
+				Affirmative(s.T("Yep")).
+				Negative(s.T("Nope")),
+		),
+	).WithShowHelp(false).WithShowErrors(false).WithTheme(s.styles.Form).WithKeyMap(formKeys(s.printer))
+	return LfModel{
+		session: s,
+		form:    f,
+		km:      localizeKeys(s.printer, lfKeys),
+	}
+}
+
+// LIST FORM COMMANDS
+
+func createList(st store.ListStore, phoneNumber string, name string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := st.CreateList(context.Background(), phoneNumber, name); err != nil {
+			return dbErrMsg{err}
+		}
+		return dbSuccessMsg{}
+	}
+}
+
+// LIST FORM UPDATE-VIEW LOOP
+
+func (m *LfModel) Init() tea.Cmd {
+	return m.form.PrevField()
+}
+
+func (m *LfModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 80) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		if key.Matches(msg, m.km.Back) {
+			return m, popScreen()
+		}
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	case dbSuccessMsg:
+		return m, popScreen()
+	}
+	f, cmd := m.form.Update(msg)
+	m.form = f.(*huh.Form)
+	if m.form.State == huh.StateCompleted {
+		if !m.form.GetBool("confirm") {
+			return m, popScreen()
+		}
+		return m, createList(m.session.store, m.session.phoneNumber, strings.TrimSpace(m.form.GetString("name")))
+	}
+	return m, cmd
+}
+
+func (m *LfModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("New List")))
+	body := m.session.styles.Base.Render(m.form.View() + m.session.styles.FormErrors(m.form, m.session.printer))
+	footer := m.appBoundaryView(m.form.Help().ShortHelpView(slices.Concat(m.km.ShortHelp(), m.form.KeyBinds())))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *LfModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Center,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/phone"
+	"ashwindharne/bdaybot/store"
+	"context"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// findList returns the list with the given ID.
+func findList(lists []store.List, id int) (store.List, bool) {
+	for _, l := range lists {
+		if l.ID == id {
+			return l, true
+		}
+	}
+	return store.List{}, false
+}
+
+// editableLists returns the lists an account can add events to.
+func editableLists(lists []store.List) []store.List {
+	var editable []store.List
+	for _, l := range lists {
+		if l.Role.CanEdit() {
+			editable = append(editable, l)
+		}
+	}
+	return editable
+}
+
+// listName is a list's name. Only the name every account's first list is
+// given is translated; the rest are as their owners typed them.
+func listName(p i18n.Printer, name string) string {
+	if name == store.PersonalList {
+		return p.T(store.PersonalList)
+	}
+	return name
+}
+
+// listTitle names a list, adding its owner's number when it's someone
+// else's, since everyone's first list has the same name.
+func listTitle(p i18n.Printer, l store.List) string {
+	if l.Role == store.Owner {
+		return listName(p, l.Name)
+	}
+	return listName(p, l.Name) + " · " + phone.FormatNational(l.Owner)
+}
+
+// roleTitle is how a member's role is shown.
+func roleTitle(p i18n.Printer, r store.Role) string {
+	switch r {
+	case store.Owner:
+		return p.T("Owner")
+	case store.Editor:
+		return p.T("Editor")
+	case store.Viewer:
+		return p.T("Viewer")
+	}
+	return string(r)
+}
+
+// readOnlyError explains why a change to a list someone can only view was
+// refused.
+func readOnlyError(p i18n.Printer, l store.List) error {
+	return i18n.Errorf("you can only view %s", listTitle(p, l))
+}
+
+// LIST COMMANDS
+
+type listsRetrievalMsg struct {
+	lists []store.List
+}
+
+func getLists(st store.ListStore, phoneNumber string) tea.Cmd {
+	return func() tea.Msg {
+		lists, err := st.ListLists(context.Background(), phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return listsRetrievalMsg{lists}
+	}
+}
+
+// editEvent opens the form for editing e, or shows on banner why it can't
+// be edited.
+func editEvent(s *session, lists []store.List, e store.Event, banner *errorBanner) tea.Cmd {
+	if l, ok := findList(lists, e.ListID); ok && !l.Role.CanEdit() {
+		return banner.Show(readOnlyError(s.printer, l))
+	}
+	form := EditBirthdayForm(s, e.ID)
+	return pushScreen(&form)
+}
+
+type invitationsRetrievalMsg struct {
+	invitations []store.Invitation
+}
+
+func getInvitations(st store.ListStore, phoneNumber string) tea.Cmd {
+	return func() tea.Msg {
+		invitations, err := st.ListInvitations(context.Background(), phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return invitationsRetrievalMsg{invitations}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/i18n"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/huh"
+	"reflect"
+	"time"
+)
+
+// localizeKeys returns a copy of a keymap struct with the help text of its
+// bindings translated. Keymaps are declared in English; screens localize
+// theirs when they're built.
+func localizeKeys[K any](p i18n.Printer, km K) K {
+	translateBindings(reflect.ValueOf(&km).Elem(), p)
+	return km
+}
+
+// formKeys returns huh's default keymap with its help text translated, for
+// use with huh.Form.WithKeyMap.
+func formKeys(p i18n.Printer) *huh.KeyMap {
+	km := huh.NewDefaultKeyMap()
+	translateBindings(reflect.ValueOf(km).Elem(), p)
+	return km
+}
+
+func translateBindings(v reflect.Value, p i18n.Printer) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if binding, ok := v.Addr().Interface().(*key.Binding); ok {
+			help := binding.Help()
+			binding.SetHelp(help.Key, p.T(help.Desc))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				translateBindings(v.Field(i), p)
+			}
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			translateBindings(v.Elem(), p)
+		}
+	}
+}
+
+// monthName returns the localized name of a month of a calendar system.
+// Gregorian months come from the locale; the names of other calendars'
+// months are translated like any other string.
+func monthName(p i18n.Printer, system calendar.System, month int) string {
+	if system == calendar.Gregorian || system == "" {
+		return p.Month(time.Month(month))
+	}
+	return p.T(system.MonthName(month))
+}
+
+// localeOptions offers every supported locale, each named in its own
+// language.
+func localeOptions() []huh.Option[string] {
+	var options []huh.Option[string]
+	for _, l := range i18n.Locales {
+		options = append(options, huh.NewOption(l.Title(), string(l)))
+	}
+	return options
+}
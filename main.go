@@ -1,8 +1,14 @@
 package main
 
 import (
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/collect"
+	"ashwindharne/bdaybot/health"
+	"ashwindharne/bdaybot/metrics"
+	"ashwindharne/bdaybot/store"
 	"context"
-	"database/sql"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"flag"
 	"fmt"
@@ -15,37 +21,98 @@ import (
 	"github.com/charmbracelet/wish/bubbletea"
 	"github.com/charmbracelet/wish/logging"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
 	"syscall"
 	"time"
 )
 
-const (
-	dbPath = "db.sqlite"
-)
+func teaHandler(st store.Store, c clock.Clock, links *collect.Links) bubbletea.Handler {
+	return func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+		// This should never fail, as we are using the activeterm middleware.
+		//pty, _, _ := s.Pty()
+
+		// When running a Bubble Tea app over SSH, you shouldn't use the default
+		// lipgloss.NewStyle function.
+		// That function will use the color profile from the os.Stdin, which is the
+		// server, not the client.
+		// We provide a MakeRenderer function in the bubbletea middleware package,
+		// so you can easily get the correct renderer for the current session, and
+		// use it to create the styles.
+		// The recommended way to use these styles is to then pass them down to
+		// your Bubble Tea model.
+		renderer := bubbletea.MakeRenderer(s)
+		sess := newSession(st, renderer)
+		sess.clock = c
+		sess.links = links
+		sess.actor = store.Actor{Source: store.SourceSSH, Key: keyFingerprint(s.PublicKey())}
+		if locale, ok := envLocale(s.Environ()); ok {
+			sess.setLocale(string(locale))
+		}
+		pnf := EmptyPhoneNumberForm(sess)
+		return EmptyRootModel(sess, &pnf), []tea.ProgramOption{tea.WithAltScreen()}
+	}
+}
+
+// keyFingerprint formats a public key's SHA256 fingerprint the way
+// ssh-keygen -l does, or returns "" for sessions without a key.
+func keyFingerprint(key ssh.PublicKey) string {
+	if key == nil {
+		return ""
+	}
+	sum := sha256.Sum256(key.Marshal())
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
 
-func teaHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
-	// This should never fail, as we are using the activeterm middleware.
-	//pty, _, _ := s.Pty()
+// recoverMiddleware keeps a panic in one session from reaching the server. It
+// logs the panic and tells the user what happened before the session closes.
+func recoverMiddleware() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			defer func() {
+				if v := recover(); v != nil {
+					log.Error("Recovered from panic", "user", s.User(), "panic", v, "stack", string(debug.Stack()))
+					// Leave the alternate screen and show the cursor again.
+					wish.Print(s, "\x1b[?1049l\x1b[?25h")
+					wish.Fatalln(s, "Sorry, something went wrong and it's been logged. Please reconnect.")
+				}
+			}()
+			next(s)
+		}
+	}
+}
 
-	// When running a Bubble Tea app over SSH, you shouldn't use the default
-	// lipgloss.NewStyle function.
-	// That function will use the color profile from the os.Stdin, which is the
-	// server, not the client.
-	// We provide a MakeRenderer function in the bubbletea middleware package,
-	// so you can easily get the correct renderer for the current session, and
-	// use it to create the styles.
-	// The recommended way to use these styles is to then pass them down to
-	// your Bubble Tea model.
-	renderer := bubbletea.MakeRenderer(s)
+// sessionBuckets range from a quick look to an afternoon left open.
+var sessionBuckets = []float64{5, 15, 30, 60, 120, 300, 600, 1800, 3600, 4 * 3600}
 
-	db, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		panic(err)
+// sessionMetricsMiddleware counts the sessions that are open and how long
+// each one lasts.
+func sessionMetricsMiddleware(reg *metrics.Registry) wish.Middleware {
+	active := reg.Gauge("bdaybot_ssh_sessions_active", "SSH sessions open now.")
+	duration := reg.Histogram("bdaybot_ssh_session_duration_seconds", "How long SSH sessions stay open.", sessionBuckets)
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			active.Add(1)
+			defer active.Add(-1)
+			defer duration.ObserveSince(time.Now())
+			next(s)
+		}
+	}
+}
+
+// serveMetrics serves the metrics and health checks on addr until the
+// process exits.
+func serveMetrics(addr string, reg *metrics.Registry, st *store.SQLite) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", reg)
+	health.Routes(mux, st)
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	log.Info("Serving metrics", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil {
+		log.Error("Could not serve metrics", "error", err)
 	}
-	pnf := EmptyPhoneNumberForm(db, renderer, NewStyles(renderer))
-	return EmptyRootModel(&pnf), []tea.ProgramOption{tea.WithAltScreen()}
 }
 
 const (
@@ -53,13 +120,28 @@ const (
 	port = "23234"
 )
 
-func runWishServer(dbPath string) {
+func runWishServer(dbPath string, c clock.Clock, links *collect.Links, metricsAddr string) {
+	reg := metrics.NewRegistry()
+	queries := reg.Histogram("bdaybot_db_query_duration_seconds", "Time taken by database statements.", metrics.DefaultBuckets, "op")
+	st, err := store.OpenObserved(dbPath, func(op string, took time.Duration) {
+		queries.Observe(took.Seconds(), op)
+	})
+	if err != nil {
+		log.Fatal("Could not open database", "error", err)
+	}
+	defer st.Close()
+	st.SetClock(c)
+	if metricsAddr != "" {
+		go serveMetrics(metricsAddr, reg, st)
+	}
 	s, err := wish.NewServer(
 		wish.WithAddress(net.JoinHostPort(host, port)),
 		wish.WithHostKeyPath(".ssh/id_ed25519"),
 		wish.WithMiddleware(
-			bubbletea.Middleware(teaHandler),
+			bubbletea.Middleware(teaHandler(st, c, links)),
 			activeterm.Middleware(), // Bubble Tea apps usually require a PTY.
+			recoverMiddleware(),
+			sessionMetricsMiddleware(reg),
 			logging.Middleware(),
 		),
 	)
@@ -86,15 +168,27 @@ func runWishServer(dbPath string) {
 	}
 }
 
-func runApp(dbPath string) {
-	db, err := sql.Open("sqlite", dbPath)
+func runApp(dbPath string, theme string, c clock.Clock, links *collect.Links) {
+	st, err := store.Open(dbPath)
 	if err != nil {
-		panic(err)
+		fmt.Printf("Could not open database: %v\n", err)
+		os.Exit(1)
 	}
-	renderer := lipgloss.DefaultRenderer()
-	styles := NewStyles(renderer)
-	pnf := EmptyPhoneNumberForm(db, renderer, styles)
-	p := tea.NewProgram(EmptyRootModel(&pnf), tea.WithAltScreen())
+	defer st.Close()
+	st.SetClock(c)
+	sess := newSession(st, lipgloss.DefaultRenderer())
+	sess.clock = c
+	sess.links = links
+	sess.actor = store.Actor{Source: store.SourceLocal}
+	if locale, ok := envLocale(os.Environ()); ok {
+		sess.setLocale(string(locale))
+	}
+	if theme != "" {
+		sess.fixedTheme = theme
+		sess.setTheme(theme)
+	}
+	pnf := EmptyPhoneNumberForm(sess)
+	p := tea.NewProgram(EmptyRootModel(sess, &pnf), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		os.Exit(1)
@@ -104,10 +198,30 @@ func runApp(dbPath string) {
 func main() {
 	dbPathPtr := flag.String("db", "db.sqlite", "path to sqlite database")
 	serverPtr := flag.Bool("server", false, "run as SSH server")
+	metricsPtr := flag.String("metrics", "", "with -server, serve metrics and health checks on this address, e.g. :9090")
+	themePtr := flag.String("theme", "", "color theme for the local app, overriding the account's (default, high-contrast, colorblind or dracula)")
+	nowPtr := flag.String("now", "", "pretend the app started at this date (2024-12-28) or time (2024-12-28T09:00:00-05:00), for trying out upcoming reminders")
 	flag.Parse()
+	c, err := clock.FromFlag(*nowPtr)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	if *themePtr != "" {
+		if _, err := ThemeNamed(*themePtr); err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+	}
+	// Lists get collection links only when cmd/server is set up to serve
+	// them.
+	var links *collect.Links
+	if l, ok := collect.FromEnv(); ok {
+		links = &l
+	}
 	if *serverPtr {
-		runWishServer(*dbPathPtr)
+		runWishServer(*dbPathPtr, c, links, *metricsPtr)
 	} else {
-		runApp(*dbPathPtr)
+		runApp(*dbPathPtr, *themePtr, c, links)
 	}
 }
@@ -0,0 +1,235 @@
+// Package metrics keeps counters, gauges and histograms and serves them in
+// the Prometheus text format, for scraping at /metrics.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets suit latencies in seconds, from a few milliseconds to ten
+// seconds. They're the Prometheus client's defaults.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds the metrics a process exports. It's an http.Handler that
+// writes them all.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []*metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+type kind string
+
+const (
+	counter   kind = "counter"
+	gauge     kind = "gauge"
+	histogram kind = "histogram"
+)
+
+// metric is a family of series that share a name and differ by their
+// label values.
+type metric struct {
+	name    string
+	help    string
+	kind    kind
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// counts are the observations in each bucket, not cumulative, for
+	// histograms.
+	counts []uint64
+	count  uint64
+}
+
+func (r *Registry) register(name, help string, k kind, buckets []float64, labels []string) *metric {
+	m := &metric{name: name, help: help, kind: k, labels: labels, buckets: buckets, series: map[string]*series{}}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// with runs f on the series for labelValues, creating it the first time.
+// It panics when the number of values doesn't match the metric's labels, as
+// that's a bug in the caller.
+func (r *Registry) with(m *metric, labelValues []string, f func(*series)) {
+	if len(labelValues) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s has labels %v, got values %v", m.name, m.labels, labelValues))
+	}
+	key := strings.Join(labelValues, "\xff")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{labelValues: slices.Clone(labelValues), counts: make([]uint64, len(m.buckets))}
+		m.series[key] = s
+	}
+	f(s)
+}
+
+// Counter only goes up, like the number of messages sent.
+type Counter struct {
+	r *Registry
+	m *metric
+}
+
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{r, r.register(name, help, counter, nil, labels)}
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which mustn't be negative.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: counters can't go down")
+	}
+	c.r.with(c.m, labelValues, func(s *series) { s.value += v })
+}
+
+// Gauge goes up and down, like the number of open sessions.
+type Gauge struct {
+	r *Registry
+	m *metric
+}
+
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r, r.register(name, help, gauge, nil, labels)}
+}
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.r.with(g.m, labelValues, func(s *series) { s.value = v })
+}
+
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.r.with(g.m, labelValues, func(s *series) { s.value += v })
+}
+
+// SetToTime sets the gauge to t as a Unix timestamp, the way Prometheus
+// expects times.
+func (g *Gauge) SetToTime(t time.Time, labelValues ...string) {
+	g.Set(float64(t.UnixNano())/1e9, labelValues...)
+}
+
+// Histogram counts observations, like request latencies, into buckets.
+type Histogram struct {
+	r *Registry
+	m *metric
+}
+
+// Histogram adds a histogram whose buckets have the given upper bounds, in
+// increasing order.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{r, r.register(name, help, histogram, buckets, labels)}
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.r.with(h.m, labelValues, func(s *series) {
+		if i, _ := slices.BinarySearch(h.m.buckets, v); i < len(s.counts) {
+			s.counts[i]++
+		}
+		s.count++
+		s.value += v
+	})
+}
+
+// ObserveSince observes the seconds since start.
+func (h *Histogram) ObserveSince(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// WRITING
+
+// WriteTo writes every metric in the Prometheus text format, with series
+// sorted by their labels so the output is stable.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	r.mu.Lock()
+	for _, m := range r.metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", m.name, m.kind)
+		keys := make([]string, 0, len(m.series))
+		for k := range m.series {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			m.write(&b, m.series[k])
+		}
+	}
+	r.mu.Unlock()
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (m *metric) write(b *strings.Builder, s *series) {
+	if m.kind != histogram {
+		fmt.Fprintf(b, "%s%s %s\n", m.name, labelPairs(m.labels, s.labelValues, "", ""), formatFloat(s.value))
+		return
+	}
+	var cumulative uint64
+	for i, upper := range m.buckets {
+		cumulative += s.counts[i]
+		fmt.Fprintf(b, "%s_bucket%s %d\n", m.name, labelPairs(m.labels, s.labelValues, "le", formatFloat(upper)), cumulative)
+	}
+	fmt.Fprintf(b, "%s_bucket%s %d\n", m.name, labelPairs(m.labels, s.labelValues, "le", "+Inf"), s.count)
+	fmt.Fprintf(b, "%s_sum%s %s\n", m.name, labelPairs(m.labels, s.labelValues, "", ""), formatFloat(s.value))
+	fmt.Fprintf(b, "%s_count%s %d\n", m.name, labelPairs(m.labels, s.labelValues, "", ""), s.count)
+}
+
+// labelPairs renders {name="value",...}, with an extra pair when extra
+// isn't empty, or nothing when there are no labels.
+func labelPairs(names, values []string, extra, extraValue string) string {
+	var pairs []string
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extra != "" {
+		pairs = append(pairs, extra+`="`+extraValue+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
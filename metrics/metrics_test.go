@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	r := NewRegistry()
+	sent := r.Counter("sent_total", "Messages sent.", "channel")
+	sent.Inc("sms")
+	sent.Add(2, "webhook")
+	sent.Inc("sms")
+	open := r.Gauge("open", "Open sessions.")
+	open.Add(3)
+	open.Add(-1)
+	latency := r.Histogram("latency_seconds", "How long it took.", []float64{.1, 1}, "op")
+	latency.Observe(.05, "query")
+	latency.Observe(.5, "query")
+	latency.Observe(5, "query")
+	r.Counter("quoted_total", "A \\ backslash\nand a newline.", "v").Inc("say \"hi\"")
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP sent_total Messages sent.
+# TYPE sent_total counter
+sent_total{channel="sms"} 2
+sent_total{channel="webhook"} 2
+# HELP open Open sessions.
+# TYPE open gauge
+open 2
+# HELP latency_seconds How long it took.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="query",le="0.1"} 1
+latency_seconds_bucket{op="query",le="1"} 2
+latency_seconds_bucket{op="query",le="+Inf"} 3
+latency_seconds_sum{op="query"} 5.55
+latency_seconds_count{op="query"} 3
+# HELP quoted_total A \\ backslash\nand a newline.
+# TYPE quoted_total counter
+quoted_total{v="say \"hi\""} 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWrongLabels(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Inc with a missing label value didn't panic")
+		}
+	}()
+	NewRegistry().Counter("sent_total", "Messages sent.", "channel").Inc()
+}
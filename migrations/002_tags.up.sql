@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS tags
+(
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    name              TEXT    NOT NULL,
+    phone_number_id   INTEGER NOT NULL,
+    notification_days INTEGER,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (phone_number_id) REFERENCES phone_numbers (id),
+    UNIQUE (phone_number_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS birthday_tags
+(
+    birthday_id INTEGER NOT NULL,
+    tag_id      INTEGER NOT NULL,
+    PRIMARY KEY (birthday_id, tag_id),
+    FOREIGN KEY (birthday_id) REFERENCES birthdays (id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS tags_phone_number_id ON tags (phone_number_id);
+CREATE INDEX IF NOT EXISTS birthday_tags_tag_id ON birthday_tags (tag_id);
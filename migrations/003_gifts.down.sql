@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS gifts;
+ALTER TABLE phone_numbers DROP COLUMN include_gift_ideas;
+ALTER TABLE birthdays DROP COLUMN notes;
\ No newline at end of file
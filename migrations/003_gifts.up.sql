@@ -0,0 +1,18 @@
+ALTER TABLE birthdays ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+ALTER TABLE phone_numbers ADD COLUMN include_gift_ideas BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS gifts
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    birthday_id INTEGER NOT NULL,
+    idea        TEXT    NOT NULL,
+    status      TEXT    NOT NULL DEFAULT 'idea' CHECK (status IN ('idea', 'bought', 'given')),
+    year        INTEGER,
+    price_cents INTEGER,
+    link        TEXT    NOT NULL DEFAULT '',
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (birthday_id) REFERENCES birthdays (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS gifts_birthday_id ON gifts (birthday_id);
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS gifts_event_id;
+DROP INDEX IF EXISTS events_phone_number_id;
+
+DELETE FROM gifts WHERE event_id IN (SELECT id FROM events WHERE event_type != 'birthday');
+DELETE FROM event_tags WHERE event_id IN (SELECT id FROM events WHERE event_type != 'birthday');
+DELETE FROM events WHERE event_type != 'birthday';
+ALTER TABLE gifts RENAME COLUMN event_id TO birthday_id;
+ALTER TABLE event_tags RENAME COLUMN event_id TO birthday_id;
+ALTER TABLE event_tags RENAME TO birthday_tags;
+
+ALTER TABLE events DROP COLUMN label;
+ALTER TABLE events DROP COLUMN event_type;
+ALTER TABLE events RENAME TO birthdays;
+
+CREATE INDEX IF NOT EXISTS birthdays_phone_number_id ON birthdays (phone_number_id);
+CREATE INDEX IF NOT EXISTS gifts_birthday_id ON gifts (birthday_id);
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE birthdays RENAME TO events;
+ALTER TABLE events ADD COLUMN event_type TEXT NOT NULL DEFAULT 'birthday'
+    CHECK (event_type IN ('birthday', 'anniversary', 'memorial', 'custom'));
+ALTER TABLE events ADD COLUMN label TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE birthday_tags RENAME TO event_tags;
+ALTER TABLE event_tags RENAME COLUMN birthday_id TO event_id;
+ALTER TABLE gifts RENAME COLUMN birthday_id TO event_id;
+
+DROP INDEX IF EXISTS birthdays_phone_number_id;
+DROP INDEX IF EXISTS gifts_birthday_id;
+CREATE INDEX IF NOT EXISTS events_phone_number_id ON events (phone_number_id);
+CREATE INDEX IF NOT EXISTS gifts_event_id ON gifts (event_id);
@@ -0,0 +1,4 @@
+DELETE FROM gifts WHERE event_id IN (SELECT id FROM events WHERE calendar != 'gregorian');
+DELETE FROM event_tags WHERE event_id IN (SELECT id FROM events WHERE calendar != 'gregorian');
+DELETE FROM events WHERE calendar != 'gregorian';
+ALTER TABLE events DROP COLUMN calendar;
\ No newline at end of file
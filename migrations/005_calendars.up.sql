@@ -0,0 +1,2 @@
+ALTER TABLE events ADD COLUMN calendar TEXT NOT NULL DEFAULT 'gregorian'
+    CHECK (calendar IN ('gregorian', 'chinese', 'hebrew'));
@@ -0,0 +1,35 @@
+ALTER TABLE phone_numbers ADD COLUMN display_timezone TEXT NOT NULL DEFAULT 'EST';
+ALTER TABLE phone_numbers ADD COLUMN notification_hour_utc INTEGER NOT NULL DEFAULT 7;
+
+UPDATE phone_numbers
+SET display_timezone = CASE timezone
+    WHEN 'America/New_York' THEN 'EST'
+    WHEN 'America/Chicago' THEN 'CST'
+    WHEN 'America/Denver' THEN 'MST'
+    WHEN 'America/Los_Angeles' THEN 'PST'
+    WHEN 'America/Anchorage' THEN 'AKST'
+    WHEN 'Pacific/Honolulu' THEN 'HST'
+    ELSE timezone
+END,
+notification_hour_utc = (notification_hour + 24 - CASE timezone
+    WHEN 'America/New_York' THEN -5
+    WHEN 'America/Toronto' THEN -5
+    WHEN 'America/Chicago' THEN -6
+    WHEN 'America/Mexico_City' THEN -6
+    WHEN 'America/Denver' THEN -7
+    WHEN 'America/Los_Angeles' THEN -8
+    WHEN 'America/Anchorage' THEN -9
+    WHEN 'Pacific/Honolulu' THEN -10
+    WHEN 'Europe/Dublin' THEN 0
+    WHEN 'Europe/London' THEN 0
+    WHEN 'Europe/Paris' THEN 1
+    WHEN 'Europe/Berlin' THEN 1
+    WHEN 'Asia/Kolkata' THEN 5
+    WHEN 'Asia/Singapore' THEN 8
+    WHEN 'Australia/Sydney' THEN 10
+    WHEN 'Pacific/Auckland' THEN 12
+    ELSE 0
+END) % 24;
+
+ALTER TABLE phone_numbers DROP COLUMN timezone;
+ALTER TABLE phone_numbers DROP COLUMN notification_hour;
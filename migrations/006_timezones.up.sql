@@ -0,0 +1,44 @@
+-- Accounts now keep an IANA timezone and a notification hour in that zone,
+-- so reminders follow daylight saving time. Existing abbreviations map to
+-- the matching zone, and hours are converted using each zone's standard
+-- offset, which is what the old UTC hour was chosen against.
+ALTER TABLE phone_numbers ADD COLUMN timezone TEXT NOT NULL DEFAULT 'America/New_York';
+ALTER TABLE phone_numbers ADD COLUMN notification_hour INTEGER NOT NULL DEFAULT 9
+    CHECK (notification_hour BETWEEN 0 AND 23);
+
+UPDATE phone_numbers
+SET timezone = CASE
+    WHEN upper(display_timezone) IN ('EST', 'EDT', 'ET') THEN 'America/New_York'
+    WHEN upper(display_timezone) IN ('CST', 'CDT', 'CT') THEN 'America/Chicago'
+    WHEN upper(display_timezone) IN ('MST', 'MDT', 'MT') THEN 'America/Denver'
+    WHEN upper(display_timezone) IN ('PST', 'PDT', 'PT') THEN 'America/Los_Angeles'
+    WHEN upper(display_timezone) IN ('AKST', 'AKDT') THEN 'America/Anchorage'
+    WHEN upper(display_timezone) IN ('HST') THEN 'Pacific/Honolulu'
+    WHEN upper(display_timezone) IN ('UTC', 'GMT', 'Z') THEN 'UTC'
+    WHEN display_timezone LIKE '%/%' THEN display_timezone
+    ELSE 'America/New_York'
+END;
+
+UPDATE phone_numbers
+SET notification_hour = (notification_hour_utc + 24 + CASE timezone
+    WHEN 'America/New_York' THEN -5
+    WHEN 'America/Toronto' THEN -5
+    WHEN 'America/Chicago' THEN -6
+    WHEN 'America/Mexico_City' THEN -6
+    WHEN 'America/Denver' THEN -7
+    WHEN 'America/Los_Angeles' THEN -8
+    WHEN 'America/Anchorage' THEN -9
+    WHEN 'Pacific/Honolulu' THEN -10
+    WHEN 'Europe/Dublin' THEN 0
+    WHEN 'Europe/London' THEN 0
+    WHEN 'Europe/Paris' THEN 1
+    WHEN 'Europe/Berlin' THEN 1
+    WHEN 'Asia/Kolkata' THEN 5
+    WHEN 'Asia/Singapore' THEN 8
+    WHEN 'Australia/Sydney' THEN 10
+    WHEN 'Pacific/Auckland' THEN 12
+    ELSE 0
+END) % 24;
+
+ALTER TABLE phone_numbers DROP COLUMN display_timezone;
+ALTER TABLE phone_numbers DROP COLUMN notification_hour_utc;
@@ -0,0 +1 @@
+ALTER TABLE phone_numbers DROP COLUMN theme;
@@ -0,0 +1,2 @@
+-- The name of a built-in theme; unknown names fall back to the default.
+ALTER TABLE phone_numbers ADD COLUMN theme TEXT NOT NULL DEFAULT 'default';
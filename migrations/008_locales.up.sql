@@ -0,0 +1,3 @@
+-- A supported locale such as en-US, en-GB or es; unknown values fall back
+-- to en-US.
+ALTER TABLE phone_numbers ADD COLUMN locale TEXT NOT NULL DEFAULT 'en-US';
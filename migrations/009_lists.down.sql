@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS list_invitations_phone_number;
+DROP INDEX IF EXISTS list_members_phone_number_id;
+DROP INDEX IF EXISTS events_list_id;
+
+-- Tags that members other than the owner put on shared events go with them.
+DELETE FROM event_tags
+WHERE tag_id IN (
+    SELECT tags.id FROM tags
+    JOIN events ON events.id = event_tags.event_id
+    WHERE tags.phone_number_id != events.phone_number_id
+);
+
+ALTER TABLE events DROP COLUMN list_id;
+DROP TABLE IF EXISTS list_invitations;
+DROP TABLE IF EXISTS list_members;
+DROP TABLE IF EXISTS lists;
@@ -0,0 +1,52 @@
+-- Events now belong to lists, which their owner can share with other
+-- accounts. Every account gets a personal list holding its existing events.
+-- events.phone_number_id is kept as the list's owner.
+CREATE TABLE IF NOT EXISTS lists
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT    NOT NULL,
+    owner_id   INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (owner_id) REFERENCES phone_numbers (id)
+);
+
+CREATE TABLE IF NOT EXISTS list_members
+(
+    list_id         INTEGER NOT NULL,
+    phone_number_id INTEGER NOT NULL,
+    role            TEXT    NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (list_id, phone_number_id),
+    FOREIGN KEY (list_id) REFERENCES lists (id) ON DELETE CASCADE,
+    FOREIGN KEY (phone_number_id) REFERENCES phone_numbers (id) ON DELETE CASCADE
+);
+
+-- Invitations are addressed to a phone number, which may not have an
+-- account yet.
+CREATE TABLE IF NOT EXISTS list_invitations
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    list_id      INTEGER NOT NULL,
+    phone_number TEXT    NOT NULL,
+    role         TEXT    NOT NULL CHECK (role IN ('editor', 'viewer')),
+    invited_by   INTEGER NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (list_id) REFERENCES lists (id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES phone_numbers (id),
+    UNIQUE (list_id, phone_number)
+);
+
+-- No REFERENCES clause, so that the down migration can drop the column.
+ALTER TABLE events ADD COLUMN list_id INTEGER;
+
+INSERT INTO lists (name, owner_id)
+SELECT 'Personal', id FROM phone_numbers ORDER BY id;
+INSERT INTO list_members (list_id, phone_number_id, role)
+SELECT id, owner_id, 'owner' FROM lists;
+UPDATE events
+SET list_id = (SELECT lists.id FROM lists WHERE lists.owner_id = events.phone_number_id);
+
+CREATE INDEX IF NOT EXISTS events_list_id ON events (list_id);
+CREATE INDEX IF NOT EXISTS list_members_phone_number_id ON list_members (phone_number_id);
+CREATE INDEX IF NOT EXISTS list_invitations_phone_number ON list_invitations (phone_number);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS organization_members_phone_number;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,34 @@
+-- Organizations announce their members' birthdays to a team channel through
+-- a webhook, rather than texting each person. Members are listed by phone
+-- number, so an admin can add people before they sign in.
+CREATE TABLE IF NOT EXISTS organizations
+(
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    name              TEXT    NOT NULL,
+    webhook_url       TEXT    NOT NULL DEFAULT '',
+    timezone          TEXT    NOT NULL DEFAULT 'UTC',
+    announcement_hour INTEGER NOT NULL DEFAULT 9 CHECK (announcement_hour BETWEEN 0 AND 23),
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- month and day are 0 until the birthday is entered, and year is 0 when it
+-- isn't known.
+CREATE TABLE IF NOT EXISTS organization_members
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    organization_id INTEGER NOT NULL,
+    phone_number    TEXT    NOT NULL,
+    name            TEXT    NOT NULL DEFAULT '',
+    role            TEXT    NOT NULL CHECK (role IN ('admin', 'member')),
+    month           INTEGER NOT NULL DEFAULT 0,
+    day             INTEGER NOT NULL DEFAULT 0,
+    year            INTEGER NOT NULL DEFAULT 0,
+    share_year      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE CASCADE,
+    UNIQUE (organization_id, phone_number)
+);
+
+CREATE INDEX IF NOT EXISTS organization_members_phone_number ON organization_members (phone_number);
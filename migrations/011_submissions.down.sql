@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS birthday_submissions_list_id;
+DROP TABLE IF EXISTS birthday_submissions;
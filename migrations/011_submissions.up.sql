@@ -0,0 +1,16 @@
+-- Birthdays people submit through a list's collection link wait here until
+-- the list's owner approves them, which turns them into events, or rejects
+-- them.
+CREATE TABLE IF NOT EXISTS birthday_submissions
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    list_id    INTEGER NOT NULL,
+    name       TEXT    NOT NULL,
+    month      INTEGER NOT NULL CHECK (month BETWEEN 1 AND 12),
+    day        INTEGER NOT NULL CHECK (day BETWEEN 1 AND 31),
+    year       INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (list_id) REFERENCES lists (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS birthday_submissions_list_id ON birthday_submissions (list_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS api_tokens_phone_number_id;
+DROP TABLE IF EXISTS api_tokens;
@@ -0,0 +1,14 @@
+-- API tokens let other tools use cmd/server's API as an account. Only a hash
+-- of each token is kept; the token itself is shown once, when it's created.
+CREATE TABLE IF NOT EXISTS api_tokens
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    phone_number_id INTEGER NOT NULL,
+    name            TEXT    NOT NULL,
+    token_hash      TEXT    NOT NULL UNIQUE,
+    last_used_at    DATETIME,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (phone_number_id) REFERENCES phone_numbers (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS api_tokens_phone_number_id ON api_tokens (phone_number_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS audit_events_subject;
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,23 @@
+-- The audit log records who created, changed or deleted an event, or changed
+-- an account's settings, and what the values were before and after. Rows
+-- don't reference the event or account, so they outlive both.
+CREATE TABLE IF NOT EXISTS audit_events
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- The account that made the change, and how it was connected: the
+    -- source is "ssh", "local" or "api", and the key is the SSH key's
+    -- fingerprint or the API token's name and ID.
+    phone_number    TEXT    NOT NULL,
+    source          TEXT    NOT NULL DEFAULT '',
+    key_fingerprint TEXT    NOT NULL DEFAULT '',
+    action          TEXT    NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    -- An event's ID, or the ID of the account whose settings changed.
+    subject         TEXT    NOT NULL CHECK (subject IN ('event', 'settings')),
+    subject_id      INTEGER NOT NULL,
+    -- JSON of the values, NULL before a create and after a delete.
+    before          TEXT,
+    after           TEXT,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_subject ON audit_events (subject, subject_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS phone_numbers_deletion_due_at;
+ALTER TABLE phone_numbers DROP COLUMN deletion_due_at;
@@ -0,0 +1,5 @@
+-- When an account that asked to be deleted will be, once its grace period
+-- is over. NULL unless a deletion is scheduled.
+ALTER TABLE phone_numbers ADD COLUMN deletion_due_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS phone_numbers_deletion_due_at ON phone_numbers (deletion_due_at);
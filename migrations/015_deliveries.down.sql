@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS deliveries_phone_number;
+DROP INDEX IF EXISTS deliveries_created_at;
+DROP TABLE IF EXISTS deliveries;
@@ -0,0 +1,19 @@
+-- The delivery log records every reminder and announcement the notifier
+-- sent or tried to send. admin purge deletes entries older than the
+-- retention period.
+CREATE TABLE IF NOT EXISTS deliveries
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- "sms" for reminders, texted to phone_number, or "webhook" for
+    -- announcements, posted to organization_id's team channel.
+    channel         TEXT    NOT NULL,
+    phone_number    TEXT    NOT NULL DEFAULT '',
+    organization_id INTEGER,
+    text            TEXT    NOT NULL,
+    -- Why it couldn't be sent, or empty when it was.
+    error           TEXT    NOT NULL DEFAULT '',
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS deliveries_created_at ON deliveries (created_at);
+CREATE INDEX IF NOT EXISTS deliveries_phone_number ON deliveries (phone_number);
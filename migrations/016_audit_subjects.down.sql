@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS audit_events_old
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    phone_number    TEXT    NOT NULL,
+    source          TEXT    NOT NULL DEFAULT '',
+    key_fingerprint TEXT    NOT NULL DEFAULT '',
+    action          TEXT    NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    subject         TEXT    NOT NULL CHECK (subject IN ('event', 'settings')),
+    subject_id      INTEGER NOT NULL,
+    before          TEXT,
+    after           TEXT,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO audit_events_old (id, phone_number, source, key_fingerprint, action, subject, subject_id, before, after, created_at)
+SELECT id, phone_number, source, key_fingerprint, action, subject, subject_id, before, after, created_at
+FROM audit_events
+WHERE subject IN ('event', 'settings');
+
+DROP TABLE audit_events;
+ALTER TABLE audit_events_old RENAME TO audit_events;
+
+CREATE INDEX IF NOT EXISTS audit_events_subject ON audit_events (subject, subject_id);
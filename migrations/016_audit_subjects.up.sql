@@ -0,0 +1,28 @@
+-- Scheduling and cancelling an account's deletion, and creating and revoking
+-- API tokens, are audited too. SQLite can't change a CHECK constraint, so
+-- the table is rebuilt with the new subjects.
+CREATE TABLE IF NOT EXISTS audit_events_new
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    phone_number    TEXT    NOT NULL,
+    source          TEXT    NOT NULL DEFAULT '',
+    key_fingerprint TEXT    NOT NULL DEFAULT '',
+    action          TEXT    NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    -- An event's ID, the ID of the account whose settings changed or whose
+    -- deletion was scheduled, or an API token's ID.
+    subject         TEXT    NOT NULL CHECK (subject IN ('event', 'settings', 'deletion', 'api_token')),
+    subject_id      INTEGER NOT NULL,
+    before          TEXT,
+    after           TEXT,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO audit_events_new (id, phone_number, source, key_fingerprint, action, subject, subject_id, before, after, created_at)
+SELECT id, phone_number, source, key_fingerprint, action, subject, subject_id, before, after, created_at
+FROM audit_events;
+
+DROP TABLE audit_events;
+ALTER TABLE audit_events_new RENAME TO audit_events;
+
+CREATE INDEX IF NOT EXISTS audit_events_subject ON audit_events (subject, subject_id);
+CREATE INDEX IF NOT EXISTS audit_events_phone_number ON audit_events (phone_number);
@@ -0,0 +1,4 @@
+-- A list's link_version is signed into its collection link. Bumping it
+-- makes the old link invalid, so an owner can replace a leaked link without
+-- the server's secret changing for every list.
+ALTER TABLE lists ADD COLUMN link_version INTEGER NOT NULL DEFAULT 0;
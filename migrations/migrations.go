@@ -0,0 +1,27 @@
+// Package migrations holds the SQL files that lay out the database, named
+// NNN_name.up.sql and NNN_name.down.sql for golang-migrate, which records
+// the version it applied in the schema_migrations table.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// Latest returns the version of the newest migration.
+func Latest() int {
+	files, _ := fs.Glob(FS, "*.up.sql")
+	latest := 0
+	for _, f := range files {
+		prefix, _, _ := strings.Cut(f, "_")
+		if v, err := strconv.Atoi(prefix); err == nil && v > latest {
+			latest = v
+		}
+	}
+	return latest
+}
@@ -0,0 +1,131 @@
+package notifier
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/store"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Announcement is the post that tells an organization's team channel whose
+// birthday it is.
+type Announcement struct {
+	Organization store.Organization
+	// Locale is the locale the post is written in.
+	Locale string
+	// At is when the run that posts it happens, in the organization's
+	// timezone.
+	At        time.Time
+	Birthdays []store.RosterEntry
+}
+
+// Text renders the post in its locale. Ages are only given for members who
+// share their year.
+func (a Announcement) Text() string {
+	p := i18n.For(i18n.Locale(a.Locale))
+	var names []string
+	for _, m := range a.Birthdays {
+		if m.Year != 0 {
+			names = append(names, fmt.Sprintf("%s (%d)", m.Name, a.At.Year()-m.Year))
+		} else {
+			names = append(names, m.Name)
+		}
+	}
+	if len(names) > 1 {
+		names = append(names[:len(names)-2], p.T("%s and %s", names[len(names)-2], names[len(names)-1]))
+	}
+	return p.T("🎂 Happy birthday to %s from everyone at %s!", strings.Join(names, ", "), a.Organization.Name)
+}
+
+// Announcements returns the announcements that the run at now should post:
+// one for each organization with birthdays today whose announcement hour it
+// is.
+func Announcements(ctx context.Context, st store.OrgStore, now time.Time) ([]Announcement, error) {
+	rosters, err := st.ListRosters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return announcements(rosters, now), nil
+}
+
+// PlanAnnouncements is Plan for announcements: it simulates the hourly runs
+// of the notifier for days days starting at from, and returns every
+// announcement they would post in order.
+func PlanAnnouncements(ctx context.Context, st store.OrgStore, from time.Time, days int) ([]Announcement, error) {
+	rosters, err := st.ListRosters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var planned []Announcement
+	end := from.AddDate(0, 0, days)
+	for now := from.Truncate(time.Hour); now.Before(end); now = now.Add(time.Hour) {
+		planned = append(planned, announcements(rosters, now)...)
+	}
+	return planned, nil
+}
+
+// announcements returns the announcements for rosters that the run at now
+// should post.
+func announcements(rosters []store.Roster, now time.Time) []Announcement {
+	var announcements []Announcement
+	for _, r := range rosters {
+		loc, err := time.LoadLocation(r.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		if !reminderDue(now, loc, r.AnnouncementHour) {
+			continue
+		}
+		localNow := now.In(loc)
+		a := Announcement{Organization: r.Organization, Locale: r.Locale, At: localNow}
+		for _, m := range r.Members {
+			if m.Month != 0 && calendar.DaysUntil(calendar.Gregorian, m.Month, m.Day, localNow) == 0 {
+				a.Birthdays = append(a.Birthdays, m)
+			}
+		}
+		if len(a.Birthdays) > 0 {
+			announcements = append(announcements, a)
+		}
+	}
+	return announcements
+}
+
+// Poster posts a message to a team channel's webhook.
+type Poster interface {
+	Post(ctx context.Context, url string, text string) error
+}
+
+// WebhookPoster posts messages as JSON with a "text" field, the format of
+// Slack's incoming webhooks, which Mattermost and Google Chat accept too.
+type WebhookPoster struct {
+	Client *http.Client
+}
+
+func (p WebhookPoster) Post(ctx context.Context, url string, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
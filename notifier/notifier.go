@@ -0,0 +1,198 @@
+// Package notifier decides which reminders are due and renders their
+// messages. cmd/notify runs it every hour; the app uses it to show an account
+// its upcoming reminders.
+package notifier
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"fmt"
+	"github.com/charmbracelet/log"
+	"io"
+	"strings"
+	"time"
+)
+
+// Channel is how reminders are delivered. Text messages are the only
+// channel so far.
+const Channel = "sms"
+
+type Reminder struct {
+	store.Candidate
+	// At is when the run that sends the reminder happens, in the account's
+	// timezone.
+	At         time.Time
+	Occurrence time.Time
+	// DaysBefore is how many days ahead of the occurrence it's sent.
+	DaysBefore     int
+	GiftIdeas      []string
+	LastYearsGifts []string
+}
+
+// Message renders the text sent for a reminder in the account's locale,
+// listing open gift ideas and last year's gifts when the account has opted
+// in.
+func (r Reminder) Message() string {
+	p := i18n.For(i18n.Locale(r.Locale))
+	headline := events.Headline(p, r.Type, r.Label, r.Name, r.Year, r.Occurrence.Year())
+	lines := []string{p.T("Reminder: %s on %s.", headline, p.DayMonth(r.Occurrence.Month(), r.Occurrence.Day()))}
+	if len(r.GiftIdeas) > 0 {
+		lines = append(lines, p.T("Gift ideas: %s", strings.Join(r.GiftIdeas, ", ")))
+	}
+	if len(r.LastYearsGifts) > 0 {
+		lines = append(lines, p.T("Last year you gave: %s", strings.Join(r.LastYearsGifts, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TestMessage is the text sent when someone asks for a test message.
+func TestMessage(p i18n.Printer) string {
+	return p.T("This is a test message from bdaybot. Your reminders will be sent to this number.")
+}
+
+// reminderDue reports whether the run at now is the one that should send
+// reminders for an account that wants them at hour in loc. The notifier runs
+// hourly, so exactly one run per local day is due: when the hour is skipped
+// by a DST change the first run after the gap is due, and when the hour
+// repeats only its first occurrence is.
+func reminderDue(now time.Time, loc *time.Location, hour int) bool {
+	year, month, day := now.In(loc).Date()
+	target := time.Date(year, month, day, hour, 0, 0, 0, loc)
+	if target.Hour() != hour {
+		// time.Date lands an hour that doesn't exist before the gap.
+		target = target.Add(time.Hour)
+	}
+	return !now.Before(target) && now.Before(target.Add(time.Hour))
+}
+
+// loadGifts fills in a reminder's open gift ideas and the gifts given for
+// last year's occasion.
+func loadGifts(ctx context.Context, st store.BirthdayStore, r *Reminder) error {
+	gifts, err := st.ListGifts(ctx, r.PhoneNumber, r.ID)
+	if err != nil {
+		return err
+	}
+	lastYear := int64(r.Occurrence.Year() - 1)
+	for _, g := range gifts {
+		switch {
+		case g.Status != "given":
+			r.GiftIdeas = append(r.GiftIdeas, g.Idea)
+		case g.Year.Valid && g.Year.Int64 == lastYear:
+			r.LastYearsGifts = append(r.LastYearsGifts, g.Idea)
+		}
+	}
+	return nil
+}
+
+// Due returns the reminders that the run at now should send.
+func Due(ctx context.Context, st store.BirthdayStore, now time.Time) ([]Reminder, error) {
+	candidates, err := st.ListCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return due(ctx, st, candidates, now)
+}
+
+// due returns the reminders for candidates that the run at now should send.
+// Whether an event is inside its window depends on its calendar and on the
+// date in the user's timezone, so the date math happens here rather than in
+// the store.
+func due(ctx context.Context, st store.BirthdayStore, candidates []store.Candidate, now time.Time) ([]Reminder, error) {
+	var reminders []Reminder
+	for _, c := range candidates {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		if !reminderDue(now, loc, c.NotificationHour) {
+			continue
+		}
+		localNow := now.In(loc)
+		daysBefore := calendar.DaysUntil(c.Calendar, c.Month, c.Day, localNow)
+		if daysBefore >= c.NotificationDays {
+			continue
+		}
+		r := Reminder{
+			Candidate:  c,
+			At:         localNow,
+			Occurrence: calendar.NextOccurrence(c.Calendar, c.Month, c.Day, localNow),
+			DaysBefore: daysBefore,
+		}
+		if c.IncludeGiftIdeas {
+			if err := loadGifts(ctx, st, &r); err != nil {
+				return nil, err
+			}
+		}
+		reminders = append(reminders, r)
+	}
+	return reminders, nil
+}
+
+// Plan simulates the hourly runs of the notifier for days days starting at
+// from, and returns every reminder they would send in order. Nothing is sent
+// or written.
+func Plan(ctx context.Context, st store.BirthdayStore, from time.Time, days int) ([]Reminder, error) {
+	candidates, err := st.ListCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return plan(ctx, st, candidates, from, days)
+}
+
+// PlanAccount is Plan for the reminders of one account.
+func PlanAccount(ctx context.Context, st store.BirthdayStore, phoneNumber string, from time.Time, days int) ([]Reminder, error) {
+	candidates, err := st.ListCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var own []store.Candidate
+	for _, c := range candidates {
+		if c.PhoneNumber == phoneNumber {
+			own = append(own, c)
+		}
+	}
+	return plan(ctx, st, own, from, days)
+}
+
+func plan(ctx context.Context, st store.BirthdayStore, candidates []store.Candidate, from time.Time, days int) ([]Reminder, error) {
+	var planned []Reminder
+	end := from.AddDate(0, 0, days)
+	for now := from.Truncate(time.Hour); now.Before(end); now = now.Add(time.Hour) {
+		reminders, err := due(ctx, st, candidates, now)
+		if err != nil {
+			return nil, err
+		}
+		planned = append(planned, reminders...)
+	}
+	return planned, nil
+}
+
+// Sender delivers a message to a phone number.
+type Sender interface {
+	Send(ctx context.Context, to string, text string) error
+}
+
+// WriterSender writes messages to W instead of delivering them. It stands in
+// for an SMS provider until one is configured.
+type WriterSender struct {
+	W io.Writer
+}
+
+func (s WriterSender) Send(ctx context.Context, to string, text string) error {
+	_, err := fmt.Fprintf(s.W, "Sending message to %s: %s\n", to, text)
+	return err
+}
+
+// LogSender logs messages instead of delivering them, for where standard
+// output is taken, such as the app's own terminal.
+type LogSender struct {
+	Logger *log.Logger
+}
+
+func (s LogSender) Send(ctx context.Context, to string, text string) error {
+	s.Logger.Info("Sending message", "to", to, "text", text)
+	return nil
+}
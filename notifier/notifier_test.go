@@ -0,0 +1,195 @@
+package notifier
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReminderDue(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	tests := []struct {
+		name string
+		now  string // UTC
+		hour int
+		want bool
+	}{
+		{"standard time", "2024-01-10T14:00:00Z", 9, true},
+		{"standard time, wrong hour", "2024-01-10T13:00:00Z", 9, false},
+		{"daylight time", "2024-07-10T13:00:00Z", 9, true},
+		{"daylight time, standard offset", "2024-07-10T14:00:00Z", 9, false},
+		{"skipped hour runs after the gap", "2024-03-10T07:00:00Z", 2, true},
+		{"skipped hour runs once", "2024-03-10T06:00:00Z", 2, false},
+		{"repeated hour, first", "2024-11-03T05:00:00Z", 1, true},
+		{"repeated hour, second", "2024-11-03T06:00:00Z", 1, false},
+		{"mid-hour run", "2024-01-10T14:20:00Z", 9, true},
+	}
+	for _, tt := range tests {
+		now, _ := time.Parse(time.RFC3339, tt.now)
+		if got := reminderDue(now, ny, tt.hour); got != tt.want {
+			t.Errorf("%s: reminderDue(%s, %d) = %v, want %v", tt.name, tt.now, tt.hour, got, tt.want)
+		}
+	}
+}
+
+func TestDue(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	st.EnsureAccount(ctx, "+15555550100", "UTC")
+	settings, _ := st.GetSettings(ctx, "+15555550100")
+	settings.IncludeGiftIdeas = true
+	st.UpdateSettings(ctx, "+15555550100", settings)
+	annId, _ := st.CreateEvent(ctx, "+15555550100", store.Event{
+		Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 20, Year: 1990,
+	})
+	st.CreateEvent(ctx, "+15555550100", store.Event{
+		Name: "Bob", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 6, Day: 1, Year: 1985,
+	})
+	st.CreateGift(ctx, "+15555550100", store.Gift{EventID: annId, Idea: "Book", Status: "idea"})
+	st.CreateGift(ctx, "+15555550100", store.Gift{EventID: annId, Idea: "Scarf", Status: "given", Year: sql.NullInt64{Int64: 2023, Valid: true}})
+
+	now := time.Date(2024, time.March, 10, 9, 15, 0, 0, time.UTC)
+	reminders, err := Due(ctx, st, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 1 {
+		t.Fatalf("got %d reminders, want 1", len(reminders))
+	}
+	want := "Reminder: Ann's 34th birthday on 3/20.\nGift ideas: Book\nLast year you gave: Scarf"
+	if got := reminders[0].Message(); got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+
+	if reminders, _ := Due(ctx, st, now.Add(time.Hour)); len(reminders) != 0 {
+		t.Errorf("got %d reminders outside the notification hour, want 0", len(reminders))
+	}
+
+	settings.Locale = "es"
+	st.UpdateSettings(ctx, "+15555550100", settings)
+	reminders, _ = Due(ctx, st, now)
+	want = "Recordatorio: 34.º cumpleaños de Ann el 20/3.\nIdeas de regalo: Book\nEl año pasado regalaste: Scarf"
+	if got := reminders[0].Message(); got != want {
+		t.Errorf("Spanish message = %q, want %q", got, want)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	st.EnsureAccount(ctx, "+15555550100", "America/New_York")
+	st.CreateEvent(ctx, "+15555550100", store.Event{
+		Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 20, Year: 1990,
+	})
+
+	// Two weeks of notice, starting across the change to daylight time.
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	plan, err := Plan(ctx, st, from, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 14 {
+		t.Fatalf("got %d planned messages, want 14", len(plan))
+	}
+	for i, m := range plan {
+		if want := 13 - i; m.DaysBefore != want {
+			t.Errorf("message %d is %d days before, want %d", i, m.DaysBefore, want)
+		}
+		if m.At.Hour() != 9 {
+			t.Errorf("message %d is sent at %s, want 9:00 local time", i, m.At.Format(time.Kitchen))
+		}
+		if m.PhoneNumber != "+15555550100" || m.Occurrence.Format(time.DateOnly) != "2024-03-20" {
+			t.Errorf("message %d = %+v", i, m)
+		}
+	}
+	if want := "Reminder: Ann's 34th birthday on 3/20."; plan[0].Message() != want {
+		t.Errorf("text = %q, want %q", plan[0].Message(), want)
+	}
+
+	st.EnsureAccount(ctx, "+15555550199", "UTC")
+	st.CreateEvent(ctx, "+15555550199", store.Event{
+		Name: "Cy", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 25,
+	})
+	own, err := PlanAccount(ctx, st, "+15555550100", from, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(own) != 14 {
+		t.Errorf("got %d planned messages for one of two accounts, want 14", len(own))
+	}
+}
+
+func TestAnnouncements(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	const admin = "+15555550100"
+	orgID, _ := st.CreateOrganization(ctx, admin, store.Organization{
+		Name: "Acme", WebhookURL: "https://hooks.example.com/1", Timezone: "UTC", AnnouncementHour: 9,
+	})
+	for _, e := range []store.RosterEntry{
+		{PhoneNumber: "+15555550101", Name: "Ann", Month: 3, Day: 10, Year: 1990, ShareYear: true},
+		{PhoneNumber: "+15555550102", Name: "Bob", Month: 3, Day: 10, Year: 1985, ShareYear: false},
+		{PhoneNumber: "+15555550103", Name: "Cy", Month: 3, Day: 11},
+	} {
+		e.OrganizationID, e.Role = orgID, store.OrgMember
+		if _, err := st.AddToRoster(ctx, admin, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Date(2024, time.March, 10, 9, 0, 0, 0, time.UTC)
+	announcements, err := Announcements(ctx, st, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(announcements) != 1 {
+		t.Fatalf("got %d announcements, want 1", len(announcements))
+	}
+	want := "🎂 Happy birthday to Ann (34) and Bob from everyone at Acme!"
+	if got := announcements[0].Text(); got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	if announcements, _ := Announcements(ctx, st, now.Add(time.Hour)); len(announcements) != 0 {
+		t.Errorf("got %d announcements outside the announcement hour, want 0", len(announcements))
+	}
+
+	planned, err := PlanAnnouncements(ctx, st, now.AddDate(0, 0, -1), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(planned) != 2 || planned[0].Birthdays[0].Name != "Ann" || planned[1].Birthdays[0].Name != "Cy" {
+		t.Errorf("planned announcements = %+v, want Ann and Bob's then Cy's", planned)
+	}
+
+	// Posts are in the admin's language.
+	st.EnsureAccount(ctx, admin, "UTC")
+	settings, _ := st.GetSettings(ctx, admin)
+	settings.Locale = "es"
+	st.UpdateSettings(ctx, admin, settings)
+	announcements, _ = Announcements(ctx, st, now)
+	if got, want := announcements[0].Text(), "🎂 ¡Feliz cumpleaños a Ann (34) y Bob de parte de todo el equipo de Acme!"; got != want {
+		t.Errorf("text in Spanish = %q, want %q", got, want)
+	}
+
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+	if err := (WebhookPoster{Client: server.Client()}).Post(ctx, server.URL, want); err != nil {
+		t.Fatal(err)
+	}
+	if got["text"] != want {
+		t.Errorf("posted %v, want the text", got)
+	}
+}
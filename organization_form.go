@@ -0,0 +1,215 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ORGANIZATION FORM KEYMAPS
+type ofKeyMap struct {
+	Back key.Binding
+	Quit key.Binding
+}
+
+func (k ofKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Back, k.Quit}
+}
+
+func (k ofKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{
+		k.Back,
+	}}
+}
+
+var ofKeys = ofKeyMap{
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// ORGANIZATION FORM MODEL
+
+// OfModel starts an organization, or changes an existing one's name and
+// where and when its birthdays are announced.
+type OfModel struct {
+	session *session
+	org     store.Organization
+	form    *huh.Form
+	width   int
+	km      ofKeyMap
+	banner  errorBanner
+}
+
+// ORGANIZATION FORM INITIALIZATION AND VALIDATION
+
+// validateWebhookURL accepts an http(s) link, or nothing to turn
+// announcements off.
+func validateWebhookURL(s string) error {
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return i18n.Errorf("must be a link starting with https://")
+	}
+	return nil
+}
+
+func PopulatedOrganizationForm(o store.Organization, styles *Styles, p i18n.Printer) *huh.Form {
+	name, webhookURL, timezone, hour := o.Name, o.WebhookURL, o.Timezone, o.AnnouncementHour
+	var hours []huh.Option[int]
+	for h := 0; h < 24; h++ {
+		hours = append(hours, huh.NewOption(formatHour(p, h), h))
+	}
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Key("name").
+				Title(p.T("Name")).
+				Description(p.T("Your team or company, as announcements should name it.")).
+				Value(&name).
+				Validate(validateName),
+			huh.NewInput().
+				Key("webhookURL").
+				Title(p.T("Webhook")).
+				Description(p.T("Incoming webhook of the team channel to announce birthdays in. Leave blank to turn announcements off.")).
+				Value(&webhookURL).
+				Validate(validateWebhookURL),
+			huh.NewSelect[string]().
+				Key("timezone").
+				Title(p.T("Timezone")).
+				Description(p.T("Where the team's day starts. Type / to search.")).
+				Options(huh.NewOptions(timezoneOptions(timezone)...)...).
+				Height(8).
+				Value(&timezone).
+				Validate(validateTimezone),
+			huh.NewSelect[int]().
+				Key("announcementHour").
+				Title(p.T("Announcement Time")).
+				Description(p.T("When to post on the day of a birthday.")).
+				Options(hours...).
+				Height(8).
+				Value(&hour),
+			huh.NewConfirm().
+				Key("confirm").
+				Title(p.T("Save Changes?")).
+				Affirmative(p.T("Yep")).
+				Negative(p.T("Nope")),
+		),
+	).WithShowHelp(false).WithShowErrors(false).WithTheme(styles.Form).WithKeyMap(formKeys(p))
+}
+
+func NewOrganizationForm(s *session) OfModel {
+	o := store.Organization{Timezone: "UTC", AnnouncementHour: 9}
+	return OfModel{
+		session: s,
+		org:     o,
+		form:    PopulatedOrganizationForm(o, s.styles, s.printer),
+		km:      localizeKeys(s.printer, ofKeys),
+	}
+}
+
+func EditOrganizationForm(s *session, o store.Organization) OfModel {
+	of := NewOrganizationForm(s)
+	of.org = o
+	of.form = PopulatedOrganizationForm(o, s.styles, s.printer)
+	return of
+}
+
+// ORGANIZATION FORM COMMANDS
+
+func saveOrganization(st store.OrgStore, phoneNumber string, o store.Organization) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		var err error
+		if o.ID == 0 {
+			_, err = st.CreateOrganization(ctx, phoneNumber, o)
+		} else {
+			err = st.UpdateOrganization(ctx, phoneNumber, o)
+		}
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return dbSuccessMsg{}
+	}
+}
+
+// ORGANIZATION FORM UPDATE-VIEW LOOP
+
+func (m *OfModel) Init() tea.Cmd {
+	if m.org.ID != 0 {
+		return m.form.PrevField()
+	}
+	// New organizations start in the account's own timezone.
+	return getSettings(m.session.store, m.session.phoneNumber)
+}
+
+func (m *OfModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 80) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		if key.Matches(msg, m.km.Back) {
+			return m, popScreen()
+		}
+	case settingsRetrievalMsg:
+		m.org.Timezone = msg.settings.Timezone
+		m.form = PopulatedOrganizationForm(m.org, m.session.styles, m.session.printer)
+		return m, m.form.PrevField()
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	case dbSuccessMsg:
+		return m, popScreen()
+	}
+	f, cmd := m.form.Update(msg)
+	m.form = f.(*huh.Form)
+	if m.form.State == huh.StateCompleted {
+		if !m.form.GetBool("confirm") {
+			return m, popScreen()
+		}
+		o := m.org
+		o.Name = strings.TrimSpace(m.form.GetString("name"))
+		o.WebhookURL = strings.TrimSpace(m.form.GetString("webhookURL"))
+		o.Timezone = m.form.GetString("timezone")
+		o.AnnouncementHour = m.form.GetInt("announcementHour")
+		return m, saveOrganization(m.session.store, m.session.phoneNumber, o)
+	}
+	return m, cmd
+}
+
+func (m *OfModel) View() string {
+	title := m.session.T("New Organization")
+	if m.org.ID != 0 {
+		title = m.org.Name
+	}
+	header := m.banner.View(m.session, m.appBoundaryView(title))
+	body := m.session.styles.Base.Render(m.form.View() + m.session.styles.FormErrors(m.form, m.session.printer))
+	footer := m.appBoundaryView(m.form.Help().ShortHelpView(slices.Concat(m.km.ShortHelp(), m.form.KeyBinds())))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *OfModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Center,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
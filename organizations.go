@@ -0,0 +1,251 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/phone"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"time"
+)
+
+// ORGANIZATIONS KEYMAPS
+type osKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Roster key.Binding
+	New    key.Binding
+	Back   key.Binding
+	Help   key.Binding
+	Quit   key.Binding
+}
+
+func (k osKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Roster, k.New, k.Back, k.Help, k.Quit}
+}
+
+func (k osKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Roster}, // first column
+		{k.New, k.Back, k.Quit},  // second column
+	}
+}
+
+var osKeys = osKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Roster: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "roster"),
+	),
+	New: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new organization"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Help: rootKeys.Help,
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c", "q"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// ORGANIZATIONS MODEL
+
+// OsModel lists the organizations whose roster the account is on.
+type OsModel struct {
+	session *session
+	table   table.Model
+	orgs    []store.Organization
+	loaded  bool
+	width   int
+	help    help.Model
+	km      osKeyMap
+	banner  errorBanner
+}
+
+// ORGANIZATIONS INITIALIZATION
+
+func osColumns(p i18n.Printer) []table.Column {
+	return []table.Column{
+		{Title: p.T("Name"), Width: 30},
+		{Title: p.T("Role"), Width: 10},
+		{Title: p.T("Announcements"), Width: 36},
+	}
+}
+
+func EmptyOrganizations(s *session) OsModel {
+	t := table.New(
+		table.WithColumns(osColumns(s.printer)),
+		table.WithFocused(true),
+		table.WithHeight(8),
+	)
+	t.SetStyles(s.styles.Table)
+
+	return OsModel{
+		session: s,
+		table:   t,
+		help:    s.styles.NewHelp(),
+		km:      localizeKeys(s.printer, osKeys),
+	}
+}
+
+// orgRoleTitle is how a member's role in an organization is shown.
+func orgRoleTitle(p i18n.Printer, r store.OrgRole) string {
+	switch r {
+	case store.OrgAdmin:
+		return p.T("Admin")
+	case store.OrgMember:
+		return p.T("Member")
+	}
+	return string(r)
+}
+
+// announcementSummary says when an organization's birthdays are announced.
+func announcementSummary(p i18n.Printer, o store.Organization) string {
+	if o.WebhookURL == "" {
+		return p.T("Off, no webhook")
+	}
+	return p.T("Daily at %s (%s)", formatHour(p, o.AnnouncementHour), o.Timezone)
+}
+
+// rosterName is how a member is listed, by their number until they have a
+// name.
+func rosterName(e store.RosterEntry) string {
+	if e.Name == "" {
+		return phone.FormatNational(e.PhoneNumber)
+	}
+	return e.Name
+}
+
+// formatRosterBirthday shows a member's birthday, with the year only when
+// it's known to whoever is looking.
+func formatRosterBirthday(p i18n.Printer, e store.RosterEntry) string {
+	switch {
+	case e.Month == 0:
+		return p.T("Not entered")
+	case e.Year == 0:
+		return p.DayMonth(time.Month(e.Month), e.Day)
+	}
+	return p.Date(e.Year, time.Month(e.Month), e.Day)
+}
+
+// ORGANIZATIONS COMMANDS
+
+type organizationsRetrievalMsg struct {
+	orgs []store.Organization
+}
+
+func getOrganizations(st store.OrgStore, phoneNumber string) tea.Cmd {
+	return func() tea.Msg {
+		orgs, err := st.ListOrganizations(context.Background(), phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return organizationsRetrievalMsg{orgs}
+	}
+}
+
+func (m *OsModel) setRows() {
+	p := m.session.printer
+	var rows []table.Row
+	for _, o := range m.orgs {
+		rows = append(rows, []string{o.Name, orgRoleTitle(p, o.Role), announcementSummary(p, o)})
+	}
+	m.table.SetRows(rows)
+}
+
+// selected returns the highlighted organization, if there is one.
+func (m *OsModel) selected() (store.Organization, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.orgs) {
+		return store.Organization{}, false
+	}
+	return m.orgs[i], true
+}
+
+// ORGANIZATIONS UPDATE-VIEW LOOP
+
+func (m *OsModel) Init() tea.Cmd {
+	// Init runs again when a screen on top of this one closes, so new and
+	// renamed organizations show.
+	m.table.SetStyles(m.session.styles.Table)
+	m.help.Styles = m.session.styles.KeyHelp
+	return getOrganizations(m.session.store, m.session.phoneNumber)
+}
+
+func (m *OsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 120) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.km.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.km.Back):
+			return m, popScreen()
+		case key.Matches(msg, m.km.New):
+			of := NewOrganizationForm(m.session)
+			return m, pushScreen(&of)
+		case key.Matches(msg, m.km.Roster):
+			if o, ok := m.selected(); ok {
+				rs := EmptyRoster(m.session, o)
+				return m, pushScreen(&rs)
+			}
+			return m, nil
+		}
+	case organizationsRetrievalMsg:
+		m.orgs = msg.orgs
+		m.loaded = true
+		m.setRows()
+		return m, nil
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m *OsModel) KeyMap() help.KeyMap {
+	return m.km
+}
+
+func (m *OsModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("Organizations")))
+	var content string
+	if m.loaded && len(m.orgs) == 0 {
+		content = m.session.styles.Help.Render(m.session.T("You're not on any organization's roster. Press n to start one for your team.")) + "\n"
+	} else {
+		content = m.table.View() + "\n"
+	}
+	body := m.session.styles.Base.Render(content)
+	footer := m.appBoundaryView(m.help.ShortHelpView(m.km.ShortHelp()))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *OsModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Left,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
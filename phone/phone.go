@@ -0,0 +1,238 @@
+// Package phone parses, validates and formats phone numbers in E.164 form.
+// Numbers may be entered internationally ("+44 20 7946 0958", "0044...") or
+// in a country's national format ("020 7946 0958") given that country. Only
+// the countries listed in Countries get length checks and national
+// formatting; other country codes are accepted as plain E.164. Parse errors
+// are made with i18n.Errorf, so the UI can show them translated.
+package phone
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"strings"
+)
+
+// Country describes how numbers are written in one country.
+type Country struct {
+	Code        string // ISO 3166-1 alpha-2
+	Name        string
+	DialCode    string
+	TrunkPrefix string // dialled before national numbers, e.g. "0"
+	MinLength   int    // of the national significant number
+	MaxLength   int
+	Timezone    string // IANA zone most of the country's users are in
+	groups      func(nsn string) []int
+}
+
+// Countries lists the supported countries in the order they're offered in
+// forms.
+var Countries = []Country{
+	{"US", "United States", "1", "1", 10, 10, "America/New_York", nanpGroups},
+	{"CA", "Canada", "1", "1", 10, 10, "America/Toronto", nanpGroups},
+	{"GB", "United Kingdom", "44", "0", 9, 10, "Europe/London", gbGroups},
+	{"IN", "India", "91", "0", 10, 10, "Asia/Kolkata", fixedGroups(5, 5)},
+	{"IE", "Ireland", "353", "0", 7, 9, "Europe/Dublin", fixedGroups(2, 3, 4)},
+	{"AU", "Australia", "61", "0", 9, 9, "Australia/Sydney", auGroups},
+	{"NZ", "New Zealand", "64", "0", 8, 10, "Pacific/Auckland", fixedGroups(2, 3, 5)},
+	{"DE", "Germany", "49", "0", 6, 13, "Europe/Berlin", fixedGroups(3, 10)},
+	{"FR", "France", "33", "0", 9, 9, "Europe/Paris", fixedGroups(1, 2, 2, 2, 2)},
+	{"MX", "Mexico", "52", "", 10, 10, "America/Mexico_City", fixedGroups(2, 4, 4)},
+	{"SG", "Singapore", "65", "", 8, 8, "Asia/Singapore", fixedGroups(4, 4)},
+}
+
+// canadianAreaCodes are the NANP area codes assigned to Canada; every other
+// +1 number is treated as American.
+var canadianAreaCodes = map[string]bool{
+	"204": true, "226": true, "236": true, "249": true, "250": true, "263": true,
+	"289": true, "306": true, "343": true, "354": true, "365": true, "367": true,
+	"368": true, "382": true, "387": true, "403": true, "416": true, "418": true,
+	"428": true, "431": true, "437": true, "438": true, "450": true, "468": true,
+	"474": true, "506": true, "514": true, "519": true, "548": true, "579": true,
+	"581": true, "584": true, "587": true, "604": true, "613": true, "639": true,
+	"647": true, "672": true, "683": true, "705": true, "709": true, "742": true,
+	"753": true, "778": true, "780": true, "782": true, "807": true, "819": true,
+	"825": true, "867": true, "873": true, "879": true, "902": true, "905": true,
+}
+
+// CountryByCode looks up a supported country by its ISO code.
+func CountryByCode(code string) (Country, bool) {
+	for _, c := range Countries {
+		if strings.EqualFold(c.Code, code) {
+			return c, true
+		}
+	}
+	return Country{}, false
+}
+
+// Number is a parsed phone number. Country is the zero value when the
+// country code isn't one of Countries.
+type Number struct {
+	Country  Country
+	dialCode string
+	nsn      string // national significant number
+}
+
+// Parse normalizes input to a phone number. International input is
+// recognized by a leading "+" or "00"; anything else is read as a national
+// number of defaultCountry. Spaces, dashes, dots and parentheses are ignored.
+func Parse(input string, defaultCountry string) (Number, error) {
+	digits, international, err := clean(input)
+	if err != nil {
+		return Number{}, err
+	}
+	if digits == "" {
+		return Number{}, i18n.Errorf("enter a phone number")
+	}
+
+	var n Number
+	if international {
+		n = splitInternational(digits)
+	} else {
+		c, ok := CountryByCode(defaultCountry)
+		if !ok {
+			return Number{}, i18n.Errorf("start with + and the country code")
+		}
+		n = Number{Country: c, dialCode: c.DialCode, nsn: digits}
+	}
+	// National significant numbers never start with the trunk prefix, so
+	// it's safe to drop it, including the "+44 (0)20..." style.
+	if n.Country.TrunkPrefix != "" {
+		n.nsn = strings.TrimPrefix(n.nsn, n.Country.TrunkPrefix)
+	}
+	// The US and Canada share +1; the area code tells them apart.
+	if n.Country.DialCode == "1" && len(n.nsn) >= 3 {
+		n.Country, _ = CountryByCode("US")
+		if canadianAreaCodes[n.nsn[:3]] {
+			n.Country, _ = CountryByCode("CA")
+		}
+	}
+
+	if n.Country.Code == "" {
+		// E.164 allows at most 15 digits including the country code.
+		if total := len(n.dialCode) + len(n.nsn); total < 8 || total > 15 {
+			return Number{}, i18n.Errorf("not a valid international number")
+		}
+		return n, nil
+	}
+	if len(n.nsn) < n.Country.MinLength || len(n.nsn) > n.Country.MaxLength {
+		if n.Country.MinLength == n.Country.MaxLength {
+			return Number{}, i18n.Errorf("%s numbers have %d digits", n.Country.Name, n.Country.MinLength)
+		}
+		return Number{}, i18n.Errorf("%s numbers have %d to %d digits", n.Country.Name, n.Country.MinLength, n.Country.MaxLength)
+	}
+	if n.Country.DialCode == "1" && (n.nsn[0] < '2' || n.nsn[3] < '2') {
+		return Number{}, i18n.Errorf("not a valid %s number", n.Country.Name)
+	}
+	return n, nil
+}
+
+// clean strips formatting characters and reports whether the number was
+// written internationally.
+func clean(input string) (string, bool, error) {
+	input = strings.TrimSpace(input)
+	international := strings.HasPrefix(input, "+")
+	var b strings.Builder
+	for i, r := range input {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ', r == '-', r == '.', r == '(', r == ')':
+		default:
+			return "", false, i18n.Errorf("numbers only")
+		}
+	}
+	digits := b.String()
+	if !international && strings.HasPrefix(digits, "00") {
+		return digits[2:], true, nil
+	}
+	return digits, international, nil
+}
+
+// splitInternational separates the country code from the rest of the
+// number. Country codes are prefix-free, so the first match wins.
+func splitInternational(digits string) Number {
+	for _, c := range Countries {
+		if strings.HasPrefix(digits, c.DialCode) {
+			return Number{Country: c, dialCode: c.DialCode, nsn: digits[len(c.DialCode):]}
+		}
+	}
+	// Unknown country codes are one to three digits; without a table we
+	// can't tell where the code ends, so keep it with the first digit.
+	return Number{dialCode: digits[:min(1, len(digits))], nsn: digits[min(1, len(digits)):]}
+}
+
+// E164 returns the number in its normalized, stored form, e.g.
+// "+442079460958".
+func (n Number) E164() string {
+	return "+" + n.dialCode + n.nsn
+}
+
+// National formats the number the way it's written within its country,
+// e.g. "020 7946 0958". Numbers from unsupported countries are shown in
+// E.164 form.
+func (n Number) National() string {
+	if n.Country.Code == "" {
+		return n.E164()
+	}
+	var parts []string
+	rest := n.nsn
+	for _, size := range n.Country.groups(n.nsn) {
+		if len(rest) <= size {
+			break
+		}
+		parts = append(parts, rest[:size])
+		rest = rest[size:]
+	}
+	parts = append(parts, rest)
+	if n.Country.DialCode == "1" {
+		return "(" + parts[0] + ") " + strings.Join(parts[1:], "-")
+	}
+	if n.Country.TrunkPrefix != "" {
+		parts[0] = n.Country.TrunkPrefix + parts[0]
+	}
+	return strings.Join(parts, " ")
+}
+
+// Timezone returns a sensible default IANA zone for the number's owner, or
+// "UTC" when the country isn't known.
+func (n Number) Timezone() string {
+	if n.Country.Timezone == "" {
+		return "UTC"
+	}
+	return n.Country.Timezone
+}
+
+// FormatNational formats a stored E.164 number for display, falling back to
+// the input unchanged if it doesn't parse.
+func FormatNational(e164 string) string {
+	n, err := Parse(e164, "")
+	if err != nil {
+		return e164
+	}
+	return n.National()
+}
+
+func fixedGroups(sizes ...int) func(string) []int {
+	return func(string) []int { return sizes }
+}
+
+func nanpGroups(string) []int {
+	return []int{3, 3}
+}
+
+// gbGroups splits London-style numbers (area code 20) as 020 XXXX XXXX and
+// everything else, mobiles included, as 07XXX XXXXXX.
+func gbGroups(nsn string) []int {
+	if strings.HasPrefix(nsn, "2") {
+		return []int{2, 4}
+	}
+	return []int{4}
+}
+
+// auGroups splits mobiles as 04XX XXX XXX and landlines as 0X XXXX XXXX.
+func auGroups(nsn string) []int {
+	if strings.HasPrefix(nsn, "4") {
+		return []int{3, 3}
+	}
+	return []int{1, 4}
+}
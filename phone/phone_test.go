@@ -0,0 +1,49 @@
+package phone
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input, country         string
+		e164, national, region string
+	}{
+		{"+15555550100", "", "+15555550100", "(555) 555-0100", "US"},
+		{"(555) 555-0100", "US", "+15555550100", "(555) 555-0100", "US"},
+		{"1-416-555-0199", "US", "+14165550199", "(416) 555-0199", "CA"},
+		{"+44 (0)20 7946 0958", "", "+442079460958", "020 7946 0958", "GB"},
+		{"07700 900123", "GB", "+447700900123", "07700 900123", "GB"},
+		{"0044 7700 900123", "US", "+447700900123", "07700 900123", "GB"},
+		{"098765 43210", "IN", "+919876543210", "098765 43210", "IN"},
+		{"+91 98765-43210", "", "+919876543210", "098765 43210", "IN"},
+		{"+61 412 345 678", "", "+61412345678", "0412 345 678", "AU"},
+		{"+86 138 0013 8000", "", "+8613800138000", "+8613800138000", ""},
+	}
+	for _, tt := range tests {
+		n, err := Parse(tt.input, tt.country)
+		if err != nil {
+			t.Errorf("Parse(%q, %q): %v", tt.input, tt.country, err)
+			continue
+		}
+		if n.E164() != tt.e164 || n.National() != tt.national || n.Country.Code != tt.region {
+			t.Errorf("Parse(%q, %q) = %s, %s, %q; want %s, %s, %q", tt.input, tt.country,
+				n.E164(), n.National(), n.Country.Code, tt.e164, tt.national, tt.region)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct{ input, country string }{
+		{"", "US"},
+		{"555-0100", "US"},
+		{"+1 055 555 0100", ""},
+		{"555 555 0100 ext 2", "US"},
+		{"+44 20 7946", ""},
+		{"98765 4321", "IN"},
+		{"5555550100", ""},
+	}
+	for _, tt := range tests {
+		if n, err := Parse(tt.input, tt.country); err == nil {
+			t.Errorf("Parse(%q, %q) = %s, want error", tt.input, tt.country, n.E164())
+		}
+	}
+}
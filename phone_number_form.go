@@ -1,60 +1,57 @@
 package main
 
 import (
-	"database/sql"
+	"ashwindharne/bdaybot/phone"
+	"ashwindharne/bdaybot/store"
+	"context"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
-	"strconv"
 )
 
 type PhoneNumberFormModel struct {
+	session     *session
 	phoneNumber string
 	form        *huh.Form
 	width       int
 	height      int
-	styles      *Styles
-	lg          *lipgloss.Renderer
-	db          *sql.DB
+	banner      errorBanner
 }
 
 // PHONE NUMBER FORM INITIALIZATION AND VALIDATION
-func validateUSPhoneNumber(phoneNumber string) error {
-	if len(phoneNumber) < 12 || len(phoneNumber) > 12 {
-		return fmt.Errorf("must be exactly 12 digits")
-	}
 
-	if phoneNumber[0:2] != "+1" {
-		return fmt.Errorf("not a valid US phone number")
-	}
-	if _, err := strconv.ParseInt(phoneNumber[2:], 10, 64); err != nil {
-		return fmt.Errorf("numbers only")
+func countryOptions() []huh.Option[string] {
+	var options []huh.Option[string]
+	for _, c := range phone.Countries {
+		options = append(options, huh.NewOption(fmt.Sprintf("%s (+%s)", c.Name, c.DialCode), c.Code))
 	}
-	return nil
+	return options
 }
 
-func EmptyPhoneNumberForm(
-	db *sql.DB,
-	renderer *lipgloss.Renderer,
-	styles *Styles,
-) PhoneNumberFormModel {
+func EmptyPhoneNumberForm(s *session) PhoneNumberFormModel {
 	m := PhoneNumberFormModel{
-		phoneNumber: "+1",
-		db:          db,
-		lg:          renderer,
-		styles:      styles,
+		session: s,
 	}
+	country := "US"
 	f := huh.NewForm(
 		huh.NewGroup(
+			huh.NewSelect[string]().
+				Key("country").
+				Title(s.T("Country")).
+				Options(countryOptions()...).
+				Value(&country),
 			huh.NewInput().
 				Key("phone").
-				Title("Enter your phone number.").
-				Description("Please enter the phone number that you would like alerts to be sent to.").
-				Validate(validateUSPhoneNumber).
+				Title(s.T("Enter your phone number.")).
+				Description(s.T("Please enter the phone number that you would like alerts to be sent to. Numbers from other countries can be entered with + and the country code.")).
+				Validate(func(s string) error {
+					_, err := phone.Parse(s, country)
+					return err
+				}).
 				Value(&m.phoneNumber),
 		),
-	).WithShowHelp(false)
+	).WithShowHelp(false).WithShowErrors(false).WithTheme(s.styles.Form).WithKeyMap(formKeys(s.printer))
 	f.PrevGroup()
 	m.form = f
 	return m
@@ -62,16 +59,24 @@ func EmptyPhoneNumberForm(
 
 // PHONE NUMBER FORM COMMANDS
 
-func insertOrIgnorePhoneNumber(db *sql.DB, phoneNumber string) tea.Cmd {
+type signedInMsg struct {
+	settings store.Settings
+}
+
+// insertOrIgnorePhoneNumber registers a new number with a default timezone
+// for its country, and loads the account's settings. Existing numbers keep
+// their settings.
+func insertOrIgnorePhoneNumber(st store.AccountStore, number phone.Number) tea.Cmd {
 	return func() tea.Msg {
-		_, err := db.Exec(`
-INSERT OR IGNORE INTO phone_numbers (phone_number, verified)
-values (?, TRUE);
-`, phoneNumber)
+		ctx := context.Background()
+		if err := st.EnsureAccount(ctx, number.E164(), number.Timezone()); err != nil {
+			return dbErrMsg{err}
+		}
+		settings, err := st.GetSettings(ctx, number.E164())
 		if err != nil {
 			return dbErrMsg{err}
 		}
-		return dbSuccessMsg{}
+		return signedInMsg{settings}
 	}
 }
 
@@ -84,45 +89,47 @@ func (m *PhoneNumberFormModel) Init() tea.Cmd {
 func (m *PhoneNumberFormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.width = min(msg.Width, 80) - m.styles.Base.GetHorizontalFrameSize()
-		m.height = min(msg.Height, 20) - m.styles.Base.GetVerticalFrameSize()
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
-			return m, tea.Quit
-		}
-	case dbSuccessMsg:
-		bt := EmptyBirthdayTable(
-			m.phoneNumber,
-			m.db,
-			m.lg,
-			m.styles,
-		)
-		return EmptyRootModel(m).Navigate(&bt)
+		m.width = min(msg.Width, 80) - m.session.styles.Base.GetHorizontalFrameSize()
+		m.height = min(msg.Height, 20) - m.session.styles.Base.GetVerticalFrameSize()
+	case signedInMsg:
+		m.session.phoneNumber = m.phoneNumber
+		m.session.setTheme(msg.settings.Theme)
+		m.session.setLocale(msg.settings.Locale)
+		bt := EmptyBirthdayTable(m.session)
+		return m, replaceScreen(&bt)
+	case dbErrMsg:
+		// Let the number be submitted again.
+		m.form.State = huh.StateNormal
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
 	}
 	f, cmd := m.form.Update(msg)
 	m.form = f.(*huh.Form)
 	if m.form.State == huh.StateCompleted {
-		m.phoneNumber = m.form.GetString("phone")
-		return m, insertOrIgnorePhoneNumber(m.db, m.phoneNumber)
+		// The input has already been validated, so this can't fail.
+		number, _ := phone.Parse(m.form.GetString("phone"), m.form.GetString("country"))
+		m.phoneNumber = number.E164()
+		return m, insertOrIgnorePhoneNumber(m.session.store, number)
 	}
 	return m, cmd
 }
 
 func (m *PhoneNumberFormModel) View() string {
 
-	header := m.appBoundaryView("Birthday Bot")
-	body := m.form.View()
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("Birthday Bot")))
+	body := m.form.View() + m.session.styles.FormErrors(m.form, m.session.printer)
 	footer := m.appBoundaryView(m.form.Help().ShortHelpView(m.form.KeyBinds()))
 	return header + "\n" + body + "\n" + footer
 }
 
 func (m *PhoneNumberFormModel) appBoundaryView(text string) string {
-	return lipgloss.PlaceHorizontal(
+	return m.session.lg.PlaceHorizontal(
 		m.width,
 		lipgloss.Center,
-		m.styles.HeaderText.Render(text),
+		m.session.styles.HeaderText.Render(text),
 		lipgloss.WithWhitespaceChars("/"),
-		lipgloss.WithWhitespaceForeground(indigo),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
 	)
 }
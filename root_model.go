@@ -1,30 +1,227 @@
 package main
 
 import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"runtime/debug"
 )
 
+// ROOT MODEL KEYMAPS
+
+type rootKeyMap struct {
+	Help key.Binding
+	Quit key.Binding
+}
+
+var rootKeys = rootKeyMap{
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "more"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// keyMapper is implemented by screens whose full help can be toggled with
+// "?". Screens built around text inputs leave it out, so "?" can be typed.
+type keyMapper interface {
+	KeyMap() help.KeyMap
+}
+
+// ROOT MODEL
+
+// RootModel is the router. It keeps a stack of screens, the session they
+// share and the last window size, and handles the keys that work everywhere.
+// It also keeps a panic in any screen, or in a command it started, from
+// ending the session: the panic is logged and an apology shown instead.
 type RootModel struct {
-	model tea.Model
+	session  *session
+	stack    []tea.Model
+	size     tea.WindowSizeMsg
+	help     help.Model
+	showHelp bool
+	crashed  bool
 }
 
-func EmptyRootModel(page tea.Model) RootModel {
-	return RootModel{model: page}
+func EmptyRootModel(s *session, page tea.Model) RootModel {
+	return RootModel{
+		session: s,
+		stack:   []tea.Model{page},
+		help:    s.styles.NewHelp(),
+	}
 }
 
-func (r RootModel) Init() tea.Cmd {
-	return nil
+// ROOT MODEL NAVIGATION
+
+type pushMsg struct {
+	screen tea.Model
+}
+
+type popMsg struct{}
+
+type replaceMsg struct {
+	screen tea.Model
+}
+
+// pushScreen shows screen on top of the current one, which is returned to
+// when screen is popped.
+func pushScreen(screen tea.Model) tea.Cmd {
+	return func() tea.Msg { return pushMsg{screen} }
+}
+
+// popScreen closes the current screen and returns to the one beneath it.
+// The uncovered screen is initialized again, so it reloads anything that
+// changed while it was covered.
+func popScreen() tea.Cmd {
+	return func() tea.Msg { return popMsg{} }
 }
 
-func (r RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	return r.model.Update(msg)
+// replaceScreen swaps the current screen for screen, so there's no going
+// back to it.
+func replaceScreen(screen tea.Model) tea.Cmd {
+	return func() tea.Msg { return replaceMsg{screen} }
+}
+
+func (r RootModel) top() tea.Model {
+	return r.stack[len(r.stack)-1]
+}
+
+// show initializes the screen on top of the stack and replays the window
+// size to it, since it missed the tea.WindowSizeMsg sent at startup.
+func (r RootModel) show() (RootModel, tea.Cmd) {
+	r.showHelp = false
+	screen := r.top()
+	cmds := []tea.Cmd{screen.Init()}
+	if r.size.Width > 0 {
+		var cmd tea.Cmd
+		screen, cmd = screen.Update(r.size)
+		cmds = append(cmds, cmd)
+	}
+	r.stack[len(r.stack)-1] = screen
+	return r, tea.Batch(cmds...)
+}
+
+// ROOT MODEL PANIC RECOVERY
+
+// panicMsg reports a panic recovered inside a command.
+type panicMsg struct {
+	value any
+	stack []byte
+}
+
+// recoverCmd runs cmd, turning a panic into a panicMsg. Commands run on their
+// own goroutines, where an unrecovered panic would take down the server.
+func recoverCmd(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer func() {
+			if v := recover(); v != nil {
+				msg = panicMsg{v, debug.Stack()}
+			}
+		}()
+		msg = cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for i := range batch {
+				batch[i] = recoverCmd(batch[i])
+			}
+		}
+		return msg
+	}
+}
+
+func (r RootModel) crash(value any, stack []byte) RootModel {
+	log.Error("Recovered from panic", "panic", value, "stack", string(stack))
+	r.crashed = true
+	return r
+}
+
+func (r RootModel) crashView() string {
+	return "\n  " + r.session.T("Sorry, something went wrong and it's been logged.") +
+		"\n\n  " + r.session.T("Press esc to go back or q to quit.") + "\n"
+}
+
+// ROOT MODEL UPDATE-VIEW LOOP
+
+func (r RootModel) Init() tea.Cmd {
+	return recoverCmd(r.top().Init())
 }
 
-func (r RootModel) View() string {
-	return r.model.View()
+func (r RootModel) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	defer func() {
+		if v := recover(); v != nil {
+			model, cmd = r.crash(v, debug.Stack()), nil
+		}
+	}()
+	switch msg := msg.(type) {
+	case panicMsg:
+		return r.crash(msg.value, msg.stack), nil
+	case tea.WindowSizeMsg:
+		r.size = msg
+		r.help.Width = msg.Width
+	case tea.KeyMsg:
+		if key.Matches(msg, rootKeys.Quit) {
+			return r, tea.Quit
+		}
+		if r.crashed {
+			switch msg.String() {
+			case "esc":
+				r.crashed = false
+				if len(r.stack) > 1 {
+					return r.Update(popMsg{})
+				}
+			case "q":
+				return r, tea.Quit
+			}
+			return r, nil
+		}
+		if _, ok := r.top().(keyMapper); ok && key.Matches(msg, rootKeys.Help) {
+			r.showHelp = !r.showHelp
+			return r, nil
+		}
+	case pushMsg:
+		r.stack = append(r.stack, msg.screen)
+		r, cmd = r.show()
+		return r, recoverCmd(cmd)
+	case popMsg:
+		if len(r.stack) == 1 {
+			return r, nil
+		}
+		r.stack = r.stack[:len(r.stack)-1]
+		r, cmd = r.show()
+		return r, recoverCmd(cmd)
+	case replaceMsg:
+		r.stack = append(r.stack[:len(r.stack)-1], msg.screen)
+		r, cmd = r.show()
+		return r, recoverCmd(cmd)
+	}
+	if r.crashed {
+		return r, nil
+	}
+	screen, cmd := r.top().Update(msg)
+	r.stack[len(r.stack)-1] = screen
+	return r, recoverCmd(cmd)
 }
 
-func (r RootModel) Navigate(model tea.Model) (tea.Model, tea.Cmd) {
-	r.model = model
-	return r.model, r.model.Init()
+func (r RootModel) View() (view string) {
+	if r.crashed {
+		return r.crashView()
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			log.Error("Recovered from panic", "panic", v, "stack", string(debug.Stack()))
+			view = r.crashView()
+		}
+	}()
+	view = r.top().View()
+	if km, ok := r.top().(keyMapper); ok && r.showHelp {
+		r.help.Styles = r.session.styles.KeyHelp
+		view += "\n" + r.session.styles.Base.Render(r.help.FullHelpView(km.KeyMap().FullHelp()))
+	}
+	return view
 }
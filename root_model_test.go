@@ -0,0 +1,94 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"testing"
+)
+
+// stubScreen records what the router sends it.
+type stubScreen struct {
+	inits int
+	size  tea.WindowSizeMsg
+	keys  []string
+}
+
+func (s *stubScreen) Init() tea.Cmd {
+	s.inits++
+	return nil
+}
+
+func (s *stubScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.size = msg
+	case tea.KeyMsg:
+		s.keys = append(s.keys, msg.String())
+	}
+	return s, nil
+}
+
+func (s *stubScreen) View() string {
+	return ""
+}
+
+func update(t *testing.T, r RootModel, msg tea.Msg) (RootModel, tea.Cmd) {
+	t.Helper()
+	next, cmd := r.Update(msg)
+	return next.(RootModel), cmd
+}
+
+func TestRouterStack(t *testing.T) {
+	sess := newSession(nil, lipgloss.DefaultRenderer())
+	bottom, top := &stubScreen{}, &stubScreen{}
+	r := EmptyRootModel(sess, bottom)
+	r, _ = update(t, r, tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	r, _ = update(t, r, pushScreen(top)())
+	if len(r.stack) != 2 || r.top() != top {
+		t.Fatalf("stack after push = %v", r.stack)
+	}
+	if top.inits != 1 || top.size.Width != 100 {
+		t.Errorf("pushed screen: inits = %d, size = %v; want it initialized and sized", top.inits, top.size)
+	}
+
+	r, _ = update(t, r, popScreen()())
+	if len(r.stack) != 1 || r.top() != bottom {
+		t.Fatalf("stack after pop = %v", r.stack)
+	}
+	if bottom.inits != 1 {
+		t.Errorf("uncovered screen inits = %d, want it reloaded", bottom.inits)
+	}
+
+	// The last screen can't be popped.
+	r, _ = update(t, r, popScreen()())
+	if len(r.stack) != 1 {
+		t.Errorf("popping the last screen left %d screens", len(r.stack))
+	}
+
+	replacement := &stubScreen{}
+	r, _ = update(t, r, replaceScreen(replacement)())
+	if len(r.stack) != 1 || r.top() != replacement {
+		t.Errorf("stack after replace = %v", r.stack)
+	}
+}
+
+func TestRouterGlobalKeys(t *testing.T) {
+	sess := newSession(nil, lipgloss.DefaultRenderer())
+	screen := &stubScreen{}
+	r := EmptyRootModel(sess, screen)
+
+	// Screens without a keymap get "?" like any other key.
+	r, _ = update(t, r, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if r.showHelp || len(screen.keys) != 1 {
+		t.Errorf("showHelp = %v, screen keys = %v; want ? passed through", r.showHelp, screen.keys)
+	}
+
+	_, cmd := update(t, r, tea.KeyMsg{Type: tea.KeyCtrlC})
+	if cmd == nil || cmd() != tea.Quit() {
+		t.Error("ctrl+c didn't quit")
+	}
+	if len(screen.keys) != 1 {
+		t.Errorf("screen saw ctrl+c: %v", screen.keys)
+	}
+}
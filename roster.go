@@ -0,0 +1,306 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/phone"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"slices"
+	"strings"
+)
+
+// ROSTER KEYMAPS
+type rsKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Mine     key.Binding
+	Add      key.Binding
+	Edit     key.Binding
+	Remove   key.Binding
+	Settings key.Binding
+	Back     key.Binding
+	Help     key.Binding
+	Quit     key.Binding
+}
+
+func (k rsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Mine, k.Add, k.Edit, k.Remove, k.Settings, k.Back, k.Help, k.Quit}
+}
+
+func (k rsKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Mine, k.Add, k.Edit},  // first column
+		{k.Remove, k.Settings, k.Back, k.Quit}, // second column
+	}
+}
+
+var rsKeys = rsKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Mine: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "my birthday"),
+	),
+	Add: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "add member"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e", "enter"),
+		key.WithHelp("e", "edit member"),
+	),
+	Remove: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "remove member"),
+	),
+	Settings: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "announcements"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Help: rootKeys.Help,
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c", "q"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// ROSTER MODEL
+
+// RsModel shows an organization's roster. Admins manage it and the
+// organization's announcements; members can only enter their own birthday.
+type RsModel struct {
+	session *session
+	org     store.Organization
+	roster  []store.RosterEntry
+	table   table.Model
+	width   int
+	help    help.Model
+	km      rsKeyMap
+	banner  errorBanner
+}
+
+// ROSTER INITIALIZATION
+
+func rsColumns(p i18n.Printer) []table.Column {
+	return []table.Column{
+		{Title: p.T("Name"), Width: 24},
+		{Title: p.T("Phone Number"), Width: 18},
+		{Title: p.T("Role"), Width: 10},
+		{Title: p.T("Birthday"), Width: 14},
+	}
+}
+
+func EmptyRoster(s *session, o store.Organization) RsModel {
+	t := table.New(
+		table.WithColumns(rsColumns(s.printer)),
+		table.WithFocused(true),
+		table.WithHeight(12),
+	)
+	t.SetStyles(s.styles.Table)
+
+	return RsModel{
+		session: s,
+		org:     o,
+		table:   t,
+		help:    s.styles.NewHelp(),
+		km:      localizeKeys(s.printer, rsKeys),
+	}
+}
+
+// ROSTER COMMANDS
+
+type rosterRetrievalMsg struct {
+	org    store.Organization
+	roster []store.RosterEntry
+}
+
+type rosterEntryRemovedMsg struct{}
+
+// getRoster loads the organization along with its roster, since an admin
+// may have just changed its settings.
+func getRoster(st store.OrgStore, phoneNumber string, orgID int) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		orgs, err := st.ListOrganizations(ctx, phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		i := slices.IndexFunc(orgs, func(o store.Organization) bool { return o.ID == orgID })
+		if i < 0 {
+			return dbErrMsg{store.ErrNotFound}
+		}
+		roster, err := st.ListRoster(ctx, phoneNumber, orgID)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return rosterRetrievalMsg{orgs[i], roster}
+	}
+}
+
+func removeFromRoster(st store.OrgStore, phoneNumber string, id int) tea.Cmd {
+	return func() tea.Msg {
+		if err := st.RemoveFromRoster(context.Background(), phoneNumber, id); err != nil {
+			return dbErrMsg{err}
+		}
+		return rosterEntryRemovedMsg{}
+	}
+}
+
+func (m *RsModel) setRows() {
+	p := m.session.printer
+	var rows []table.Row
+	for _, e := range m.roster {
+		rows = append(rows, []string{
+			rosterName(e),
+			phone.FormatNational(e.PhoneNumber),
+			orgRoleTitle(p, e.Role),
+			formatRosterBirthday(p, e),
+		})
+	}
+	m.table.SetRows(rows)
+}
+
+// selected returns the highlighted member, if there is one.
+func (m *RsModel) selected() (store.RosterEntry, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.roster) {
+		return store.RosterEntry{}, false
+	}
+	return m.roster[i], true
+}
+
+// own returns the account's own entry.
+func (m *RsModel) own() (store.RosterEntry, bool) {
+	i := slices.IndexFunc(m.roster, func(e store.RosterEntry) bool { return e.PhoneNumber == m.session.phoneNumber })
+	if i < 0 {
+		return store.RosterEntry{}, false
+	}
+	return m.roster[i], true
+}
+
+func (m *RsModel) isAdmin() bool {
+	return m.org.Role == store.OrgAdmin
+}
+
+// notAdmin explains why a member can't do what only admins can.
+func (m *RsModel) notAdmin() tea.Cmd {
+	return m.banner.Show(i18n.Errorf("only admins of %s can do that", m.org.Name))
+}
+
+// editEntry opens the form for e.
+func (m *RsModel) editEntry(e store.RosterEntry) tea.Cmd {
+	rf := EditRosterForm(m.session, m.org, e)
+	return pushScreen(&rf)
+}
+
+// ROSTER UPDATE-VIEW LOOP
+
+func (m *RsModel) Init() tea.Cmd {
+	// Init runs again when a form on top of this screen closes.
+	m.table.SetStyles(m.session.styles.Table)
+	m.help.Styles = m.session.styles.KeyHelp
+	return getRoster(m.session.store, m.session.phoneNumber, m.org.ID)
+}
+
+func (m *RsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 120) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.km.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.km.Back):
+			return m, popScreen()
+		case key.Matches(msg, m.km.Mine):
+			if e, ok := m.own(); ok {
+				return m, m.editEntry(e)
+			}
+			return m, nil
+		case key.Matches(msg, m.km.Edit):
+			e, ok := m.selected()
+			switch {
+			case !ok:
+				return m, nil
+			case !m.isAdmin() && e.PhoneNumber != m.session.phoneNumber:
+				return m, m.notAdmin()
+			}
+			return m, m.editEntry(e)
+		case key.Matches(msg, m.km.Add, m.km.Remove, m.km.Settings) && !m.isAdmin():
+			return m, m.notAdmin()
+		case key.Matches(msg, m.km.Add):
+			rf := AddRosterForm(m.session, m.org, m.roster)
+			return m, pushScreen(&rf)
+		case key.Matches(msg, m.km.Remove):
+			e, ok := m.selected()
+			switch {
+			case !ok:
+				return m, nil
+			case e.PhoneNumber == m.session.phoneNumber:
+				return m, m.banner.Show(i18n.Errorf("you can't remove yourself"))
+			}
+			return m, removeFromRoster(m.session.store, m.session.phoneNumber, e.ID)
+		case key.Matches(msg, m.km.Settings):
+			of := EditOrganizationForm(m.session, m.org)
+			return m, pushScreen(&of)
+		}
+	case rosterRetrievalMsg:
+		m.org = msg.org
+		m.roster = msg.roster
+		m.setRows()
+		return m, nil
+	case rosterEntryRemovedMsg:
+		return m, getRoster(m.session.store, m.session.phoneNumber, m.org.ID)
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m *RsModel) KeyMap() help.KeyMap {
+	return m.km
+}
+
+func (m *RsModel) View() string {
+	p := m.session.printer
+	header := m.banner.View(m.session, m.appBoundaryView(m.org.Name))
+	var b strings.Builder
+	b.WriteString(m.session.styles.StatusHeader.Render(m.session.T("Announcements")) + " " + announcementSummary(p, m.org) + "\n\n")
+	b.WriteString(m.table.View() + "\n")
+	if e, ok := m.own(); ok && e.Month == 0 {
+		b.WriteString("\n" + m.session.styles.Highlight.Render(m.session.T("Press m to enter your birthday.")) + "\n")
+	}
+	body := m.session.styles.Base.Render(b.String())
+	footer := m.appBoundaryView(m.help.ShortHelpView(m.km.ShortHelp()))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *RsModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Left,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/phone"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ROSTER FORM KEYMAPS
+type rfKeyMap struct {
+	Back key.Binding
+	Quit key.Binding
+}
+
+func (k rfKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Back, k.Quit}
+}
+
+func (k rfKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{
+		k.Back,
+	}}
+}
+
+var rfKeys = rfKeyMap{
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// ROSTER FORM MODEL
+
+// RfModel adds someone to an organization's roster or edits an entry. Which
+// fields show depends on who's editing whom: only admins pick roles, nobody
+// changes their own, and a year kept private is only shown to its member.
+type RfModel struct {
+	session *session
+	org     store.Organization
+	entry   store.RosterEntry
+	form    *huh.Form
+	width   int
+	km      rfKeyMap
+	banner  errorBanner
+}
+
+// ROSTER FORM INITIALIZATION AND VALIDATION
+
+// validateRosterDay requires a day once a month is picked.
+func validateRosterDay(month *int) func(string) error {
+	return func(day string) error {
+		if *month == 0 && day == "" {
+			return nil
+		}
+		return events.ValidateDay(day)
+	}
+}
+
+// validateOptionalYear is events.ValidateYear for years that can be left
+// out.
+func validateOptionalYear(thisYear int) func(string) error {
+	return func(year string) error {
+		if year == "" {
+			return nil
+		}
+		return events.ValidateYear(thisYear)(year)
+	}
+}
+
+// validateNewMember accepts any valid number, read as from *country, that
+// isn't on the roster yet.
+func validateNewMember(roster []store.RosterEntry, country *string) func(string) error {
+	return func(s string) error {
+		number, err := phone.Parse(s, *country)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(roster, func(e store.RosterEntry) bool { return e.PhoneNumber == number.E164() }) {
+			return i18n.Errorf("already on the roster")
+		}
+		return nil
+	}
+}
+
+func PopulatedRosterForm(s *session, e store.RosterEntry, roster []store.RosterEntry, isAdmin bool) *huh.Form {
+	p := s.printer
+	adding := e.ID == 0
+	self := e.PhoneNumber == s.phoneNumber
+	country := "US"
+	role := e.Role
+	if role == "" {
+		role = store.OrgMember
+	}
+	month := e.Month
+	day, year := "", ""
+	if e.Day != 0 {
+		day = strconv.Itoa(e.Day)
+	}
+	if e.Year != 0 {
+		year = strconv.Itoa(e.Year)
+	}
+	shareYear := e.ShareYear
+	monthOptions := []huh.Option[int]{huh.NewOption(p.T("Not entered"), 0)}
+	for m := time.January; m <= time.December; m++ {
+		monthOptions = append(monthOptions, huh.NewOption(p.Month(m), int(m)))
+	}
+
+	var fields []huh.Field
+	if adding {
+		fields = append(fields,
+			huh.NewSelect[string]().
+				Key("country").
+				Title(p.T("Country")).
+				Options(countryOptions()...).
+				Value(&country),
+			huh.NewInput().
+				Key("phone").
+				Title(p.T("Phone Number")).
+				Description(p.T("They can enter their own birthday by signing in with this number.")).
+				Validate(validateNewMember(roster, &country)),
+		)
+	}
+	fields = append(fields,
+		huh.NewInput().
+			Key("name").
+			Title(p.T("Name")).
+			Description(p.T("As the team knows them.")).
+			Value(&e.Name).
+			Validate(validateName),
+	)
+	if isAdmin && !self {
+		fields = append(fields,
+			huh.NewSelect[store.OrgRole]().
+				Key("role").
+				Title(p.T("Role")).
+				Description(p.T("Admins manage the roster and announcements.")).
+				Options(
+					huh.NewOption(orgRoleTitle(p, store.OrgMember), store.OrgMember),
+					huh.NewOption(orgRoleTitle(p, store.OrgAdmin), store.OrgAdmin),
+				).
+				Value(&role),
+		)
+	}
+	fields = append(fields,
+		huh.NewSelect[int]().
+			Key("month").
+			Title(p.T("Month")).
+			Options(monthOptions...).
+			Height(8).
+			Value(&month),
+		huh.NewInput().
+			Key("day").
+			Title(p.T("Day")).
+			Value(&day).
+			CharLimit(2).
+			Validate(validateRosterDay(&month)),
+	)
+	if self || adding || e.ShareYear {
+		fields = append(fields,
+			huh.NewInput().
+				Key("year").
+				Title(p.T("Year")).
+				Description(p.T("Optional. Announcements give ages only when the year is shared.")).
+				Value(&year).
+				CharLimit(4).
+				Validate(validateOptionalYear(s.clock.Now().Year())),
+		)
+	}
+	if self {
+		fields = append(fields,
+			huh.NewConfirm().
+				Key("shareYear").
+				Title(p.T("Share Your Birth Year?")).
+				Description(p.T("Turn off to keep your age out of the roster and announcements.")).
+				Affirmative(p.T("Yep")).
+				Negative(p.T("Nope")).
+				Value(&shareYear),
+		)
+	}
+	fields = append(fields,
+		huh.NewConfirm().
+			Key("confirm").
+			Title(p.T("Save Changes?")).
+			Affirmative(p.T("Yep")).
+			Negative(p.T("Nope")),
+	)
+	return huh.NewForm(huh.NewGroup(fields...)).WithShowHelp(false).WithShowErrors(false).WithTheme(s.styles.Form).WithKeyMap(formKeys(p))
+}
+
+func AddRosterForm(s *session, o store.Organization, roster []store.RosterEntry) RfModel {
+	e := store.RosterEntry{OrganizationID: o.ID, Role: store.OrgMember, ShareYear: true}
+	return RfModel{
+		session: s,
+		org:     o,
+		entry:   e,
+		form:    PopulatedRosterForm(s, e, roster, o.Role == store.OrgAdmin),
+		km:      localizeKeys(s.printer, rfKeys),
+	}
+}
+
+func EditRosterForm(s *session, o store.Organization, e store.RosterEntry) RfModel {
+	return RfModel{
+		session: s,
+		org:     o,
+		entry:   e,
+		form:    PopulatedRosterForm(s, e, nil, o.Role == store.OrgAdmin),
+		km:      localizeKeys(s.printer, rfKeys),
+	}
+}
+
+// ROSTER FORM COMMANDS
+
+func saveRosterEntry(st store.OrgStore, phoneNumber string, e store.RosterEntry) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		var err error
+		if e.ID == 0 {
+			_, err = st.AddToRoster(ctx, phoneNumber, e)
+		} else {
+			err = st.UpdateRosterEntry(ctx, phoneNumber, e)
+		}
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return dbSuccessMsg{}
+	}
+}
+
+// formEntry reads the submitted entry back out of the form, keeping what
+// the form didn't show. The inputs have already been validated, so
+// conversion errors can't occur.
+func (m *RfModel) formEntry() store.RosterEntry {
+	e := m.entry
+	if e.ID == 0 {
+		number, _ := phone.Parse(m.form.GetString("phone"), m.form.GetString("country"))
+		e.PhoneNumber = number.E164()
+	}
+	e.Name = strings.TrimSpace(m.form.GetString("name"))
+	if role, ok := m.form.Get("role").(store.OrgRole); ok {
+		e.Role = role
+	}
+	e.Month = m.form.GetInt("month")
+	e.Day, _ = strconv.Atoi(m.form.GetString("day"))
+	if e.Month == 0 {
+		e.Day = 0
+	}
+	if year, ok := m.form.Get("year").(string); ok {
+		e.Year, _ = strconv.Atoi(year)
+	}
+	if share, ok := m.form.Get("shareYear").(bool); ok {
+		e.ShareYear = share
+	}
+	return e
+}
+
+// ROSTER FORM UPDATE-VIEW LOOP
+
+func (m *RfModel) Init() tea.Cmd {
+	return m.form.PrevField()
+}
+
+func (m *RfModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 80) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		if key.Matches(msg, m.km.Back) {
+			return m, popScreen()
+		}
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	case dbSuccessMsg:
+		return m, popScreen()
+	}
+	f, cmd := m.form.Update(msg)
+	m.form = f.(*huh.Form)
+	if m.form.State == huh.StateCompleted {
+		if !m.form.GetBool("confirm") {
+			return m, popScreen()
+		}
+		return m, saveRosterEntry(m.session.store, m.session.phoneNumber, m.formEntry())
+	}
+	return m, cmd
+}
+
+func (m *RfModel) View() string {
+	title := m.session.T("Add to %s", m.org.Name)
+	switch {
+	case m.entry.PhoneNumber == m.session.phoneNumber:
+		title = m.session.T("Your Birthday · %s", m.org.Name)
+	case m.entry.ID != 0:
+		title = rosterName(m.entry) + " · " + m.org.Name
+	}
+	header := m.banner.View(m.session, m.appBoundaryView(title))
+	body := m.session.styles.Base.Render(m.form.View() + m.session.styles.FormErrors(m.form, m.session.printer))
+	footer := m.appBoundaryView(m.form.Help().ShortHelpView(slices.Concat(m.km.ShortHelp(), m.form.KeyBinds())))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *RfModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Center,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/collect"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/store"
+	"context"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPhoneNumber = "+15555550100"
+
+// testRenderer returns a renderer with a fixed color profile, so output
+// doesn't depend on the terminal running the tests.
+func testRenderer(profile termenv.Profile) *lipgloss.Renderer {
+	lg := lipgloss.NewRenderer(io.Discard)
+	lg.SetColorProfile(profile)
+	lg.SetHasDarkBackground(true)
+	return lg
+}
+
+// testSession returns a signed in session whose renderer has no colors,
+// while the global renderer is switched to true color. Anything a screen
+// draws with the global renderer instead of the session's then shows up as
+// escape codes in its view.
+func testSession(t *testing.T) *session {
+	t.Helper()
+	global := lipgloss.DefaultRenderer()
+	lipgloss.SetDefaultRenderer(testRenderer(termenv.TrueColor))
+	t.Cleanup(func() { lipgloss.SetDefaultRenderer(global) })
+
+	ctx := context.Background()
+	st := store.NewMemory()
+	st.EnsureAccount(ctx, testPhoneNumber, "America/New_York")
+	st.CreateEvent(ctx, testPhoneNumber, store.Event{
+		Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 14, Year: 1990,
+		Notes: "Likes tea", Tags: []string{"family"},
+	})
+	sess := newSession(st, testRenderer(termenv.Ascii))
+	sess.phoneNumber = testPhoneNumber
+	return sess
+}
+
+// runCmd runs cmd, giving up on commands that wait, such as cursor blinks.
+func runCmd(cmd tea.Cmd) tea.Msg {
+	msgs := make(chan tea.Msg, 1)
+	go func() { msgs <- cmd() }()
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(50 * time.Millisecond):
+		return nil
+	}
+}
+
+// settle feeds the messages from cmd, and from the commands they lead to,
+// back into m until there's nothing left to do straight away.
+func settle(m tea.Model, cmd tea.Cmd) tea.Model {
+	queue := []tea.Cmd{cmd}
+	for steps := 0; len(queue) > 0 && steps < 100; steps++ {
+		cmd, queue = queue[0], queue[1:]
+		if cmd == nil {
+			continue
+		}
+		switch msg := runCmd(cmd).(type) {
+		case nil:
+		case tea.BatchMsg:
+			queue = append(queue, msg...)
+		default:
+			m, cmd = m.Update(msg)
+			queue = append(queue, cmd)
+		}
+	}
+	return m
+}
+
+// openScreen pushes screen onto a sized router and waits for it to load.
+func openScreen(sess *session, screen tea.Model) tea.Model {
+	bt := EmptyBirthdayTable(sess)
+	root := EmptyRootModel(sess, &bt)
+	m := settle(root, root.Init())
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	return settle(m, pushScreen(screen))
+}
+
+func TestScreensUseSessionRenderer(t *testing.T) {
+	sess := testSession(t)
+	events, _ := sess.store.ListEvents(context.Background(), testPhoneNumber)
+	id := events[0].ID
+	org := store.Organization{Name: "Acme", Timezone: "UTC", AnnouncementHour: 9}
+	org.ID, _ = sess.store.CreateOrganization(context.Background(), testPhoneNumber, org)
+	org.Role = store.OrgAdmin
+	screens := map[string]func() tea.Model{
+		"phone number": func() tea.Model { m := EmptyPhoneNumberForm(sess); return &m },
+		"table":        func() tea.Model { m := EmptyBirthdayTable(sess); return &m },
+		"calendar":     func() tea.Model { m := EmptyBirthdayCalendar(sess); return &m },
+		"detail":       func() tea.Model { m := EmptyBirthdayDetail(sess, id); return &m },
+		"new event":    func() tea.Model { m := EmptyBirthdayForm(sess); return &m },
+		"edit event":   func() tea.Model { m := EditBirthdayForm(sess, id); return &m },
+		"gift":         func() tea.Model { m := EmptyGiftForm(sess, id); return &m },
+		"settings":     func() tea.Model { m := EmptySettingsForm(sess); return &m },
+		"upcoming":     func() tea.Model { m := EmptyUpcomingReminders(sess); return &m },
+		"lists":        func() tea.Model { m := EmptyBirthdayLists(sess); return &m },
+		"new list":     func() tea.Model { m := EmptyListForm(sess); return &m },
+		"invite":       func() tea.Model { m := EmptyInviteForm(sess, store.List{Name: store.PersonalList}); return &m },
+		"orgs":         func() tea.Model { m := EmptyOrganizations(sess); return &m },
+		"new org":      func() tea.Model { m := NewOrganizationForm(sess); return &m },
+		"roster":       func() tea.Model { m := EmptyRoster(sess, org); return &m },
+		"add member":   func() tea.Model { m := AddRosterForm(sess, org, nil); return &m },
+		"submissions": func() tea.Model {
+			m := EmptySubmissions(sess, store.List{ID: events[0].ListID, Role: store.Owner})
+			return &m
+		},
+		"api tokens":     func() tea.Model { m := EmptyAPITokens(sess); return &m },
+		"new api token":  func() tea.Model { m := EmptyAPITokenForm(sess); return &m },
+		"history":        func() tea.Model { m := EmptyBirthdayHistory(sess, events[0]); return &m },
+		"delete account": func() tea.Model { m := EmptyAccountDeletion(sess); return &m },
+	}
+	for _, theme := range Themes {
+		sess.setTheme(theme.Name)
+		for name, screen := range screens {
+			t.Run(theme.Name+"/"+name, func(t *testing.T) {
+				view := openScreen(sess, screen()).View()
+				if strings.TrimSpace(view) == "" {
+					t.Fatal("empty view")
+				}
+				if i := strings.Index(view, "\x1b["); i >= 0 {
+					t.Errorf("view has escape codes from the global renderer near %q", view[max(0, i-40):i])
+				}
+			})
+		}
+	}
+}
+
+func TestScreensFollowLocale(t *testing.T) {
+	sess := testSession(t)
+	sess.clock = clock.Fixed(time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC))
+	sess.setLocale("es")
+	bt := EmptyBirthdayTable(sess)
+	table := openScreen(sess, &bt).View()
+	for _, want := range []string{"Recordatorios de cumpleaños", "14/3/1990", "34.º cumpleaños", "10 días", "crear evento"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("table view is missing %q:\n%s", want, table)
+		}
+	}
+	bc := EmptyBirthdayCalendar(sess)
+	calendar := openScreen(sess, &bc).View()
+	for _, want := range []string{"marzo de 2024", "lunes, 4 de marzo", "lu    ma"} {
+		if !strings.Contains(calendar, want) {
+			t.Errorf("calendar view is missing %q:\n%s", want, calendar)
+		}
+	}
+}
+
+func TestBirthdayCalendar(t *testing.T) {
+	ctx := context.Background()
+	sess := testSession(t)
+	// It's already March 15 on the server, but still March 14 in New York.
+	sess.clock = clock.Fixed(time.Date(2024, time.March, 15, 2, 0, 0, 0, time.UTC))
+	bob, _ := sess.store.CreateEvent(ctx, testPhoneNumber, store.Event{
+		Name: "Bob", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 14, Year: 1985,
+	})
+	bc := EmptyBirthdayCalendar(sess)
+	m := openScreen(sess, &bc)
+	if want := time.Date(2024, time.March, 14, 0, 0, 0, 0, time.UTC); !bc.selected.Equal(want) {
+		t.Errorf("selected %v, want %v", bc.selected, want)
+	}
+
+	// Bob is deleted while he's selected, so only Ann is left on the day.
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyTab} })
+	sess.store.DeleteEvent(ctx, testPhoneNumber, bob)
+	m = settle(m, bc.Init())
+	if bc.personIdx != 0 {
+		t.Errorf("person %d selected after the reload, want 0", bc.personIdx)
+	}
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")} })
+	if m.(RootModel).crashed {
+		t.Error("editing the remaining person crashed")
+	}
+
+	bc.selected = time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	for _, want := range []time.Time{
+		time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.March, 29, 0, 0, 0, 0, time.UTC),
+	} {
+		bc.moveSelection(0, 1)
+		if !bc.selected.Equal(want) {
+			t.Errorf("next month selected %v, want %v", bc.selected, want)
+		}
+	}
+	bc.selected = time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)
+	if bc.moveSelection(0, -1); !bc.selected.Equal(time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("previous month selected %v, want February 29", bc.selected)
+	}
+}
+
+// recordingSender keeps the messages sent through it.
+type recordingSender struct {
+	sent []string
+}
+
+func (s *recordingSender) Send(ctx context.Context, to string, text string) error {
+	s.sent = append(s.sent, to+": "+text)
+	return nil
+}
+
+func TestUpcomingReminders(t *testing.T) {
+	sess := testSession(t)
+	sess.clock = clock.Fixed(time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC))
+	sender := &recordingSender{}
+	sess.sender = sender
+	ur := EmptyUpcomingReminders(sess)
+	m := openScreen(sess, &ur)
+	view := m.View()
+	// It's 7 AM in New York, so today's 9 AM reminder is still to come, and
+	// they run until Ann's birthday on the 14th.
+	for _, want := range []string{"Monday, March 4 · 9:00 AM", "Thursday, March 14 · 9:00 AM", "Reminder: Ann's 34th birthday on 3/14."} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view is missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "March 15") {
+		t.Errorf("view lists a reminder after the birthday:\n%s", view)
+	}
+
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")} })
+	if len(sender.sent) != 1 || !strings.HasPrefix(sender.sent[0], testPhoneNumber+": This is a test message") {
+		t.Errorf("sent %q, want one test message", sender.sent)
+	}
+	if view := m.View(); !strings.Contains(view, "Test message sent to (555) 555-0100.") {
+		t.Errorf("view doesn't confirm the test message:\n%s", view)
+	}
+}
+
+func TestSharedLists(t *testing.T) {
+	ctx := context.Background()
+	sess := testSession(t)
+	sess.clock = clock.Fixed(time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC))
+	const partner = "+15555550111"
+	st := sess.store
+	st.EnsureAccount(ctx, partner, "America/New_York")
+	family, _ := st.CreateList(ctx, partner, "Family")
+	st.CreateEvent(ctx, partner, store.Event{
+		ListID: family, Name: "Bob", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 12, Day: 1, Year: 1985,
+	})
+	if err := st.Invite(ctx, partner, family, testPhoneNumber, store.Viewer); err != nil {
+		t.Fatal(err)
+	}
+
+	ls := EmptyBirthdayLists(sess)
+	m := openScreen(sess, &ls)
+	if view := m.View(); !strings.Contains(view, "(555) 555-0111 invited you to Family as Viewer") {
+		t.Fatalf("view doesn't show the invitation:\n%s", view)
+	}
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")} })
+	if view := m.View(); strings.Contains(view, "invited you") || !strings.Contains(view, "Family") {
+		t.Fatalf("view after accepting:\n%s", view)
+	}
+
+	bt := EmptyBirthdayTable(sess)
+	m = openScreen(sess, &bt)
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyDown} })
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")} })
+	if view := m.View(); !strings.Contains(view, "you can only view Family · (555) 555-0111") {
+		t.Errorf("editing a viewed list's event wasn't refused:\n%s", view)
+	}
+}
+
+func TestReviewSubmissions(t *testing.T) {
+	ctx := context.Background()
+	sess := testSession(t)
+	sess.links = &collect.Links{BaseURL: "https://bdaybot.example.com", Secret: []byte("s3cret")}
+	lists, _ := sess.store.ListLists(ctx, testPhoneNumber)
+	personal := lists[0]
+	for _, name := range []string{"Bob", "Cat"} {
+		sub := store.Submission{ListID: personal.ID, Name: name, Month: 12, Day: 1, Year: 1985}
+		if _, err := sess.store.SubmitBirthday(ctx, sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sb := EmptySubmissions(sess, personal)
+	m := openScreen(sess, &sb)
+	if view := m.View(); !strings.Contains(view, sess.links.URL(personal.ID, 0)) || !strings.Contains(view, "Bob") {
+		t.Fatalf("view doesn't show the link and submissions:\n%s", view)
+	}
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")} })
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")} })
+	if view := m.View(); !strings.Contains(view, "No birthdays are waiting for review.") {
+		t.Errorf("submissions remain after review:\n%s", view)
+	}
+	list, _ := sess.store.ListEvents(ctx, testPhoneNumber)
+	names := []string{}
+	for _, e := range list {
+		names = append(names, e.Name)
+	}
+	if !slices.Equal(names, []string{"Ann", "Bob"}) {
+		t.Errorf("events after review = %v, want Ann and the approved Bob", names)
+	}
+
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")} })
+	if view := m.View(); !strings.Contains(view, sess.links.URL(personal.ID, 1)) {
+		t.Errorf("view doesn't show the new link:\n%s", view)
+	}
+	if l, _ := sess.store.GetList(ctx, personal.ID); l.LinkVersion != 1 {
+		t.Errorf("link version = %d, want 1", l.LinkVersion)
+	}
+}
+
+func TestAPITokens(t *testing.T) {
+	ctx := context.Background()
+	sess := testSession(t)
+	if _, _, err := sess.store.CreateAPIToken(ctx, testPhoneNumber, "Home Assistant"); err != nil {
+		t.Fatal(err)
+	}
+
+	tk := EmptyAPITokens(sess)
+	m := openScreen(sess, &tk)
+	if view := m.View(); !strings.Contains(view, "Home Assistant") || !strings.Contains(view, "Never") {
+		t.Fatalf("view doesn't show the unused token:\n%s", view)
+	}
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")} })
+	if view := m.View(); !strings.Contains(view, "You don't have any API tokens yet.") {
+		t.Errorf("token remains after revoking it:\n%s", view)
+	}
+
+	tf := EmptyAPITokenForm(sess)
+	m = openScreen(sess, &tf)
+	m = settle(m, func() tea.Msg { return apiTokenCreatedMsg{"bdb_secret"} })
+	if view := m.View(); !strings.Contains(view, "bdb_secret") || !strings.Contains(view, "won't be shown again") {
+		t.Errorf("new token's secret isn't shown:\n%s", view)
+	}
+}
+
+func TestEventHistory(t *testing.T) {
+	sess := testSession(t)
+	events, _ := sess.store.ListEvents(context.Background(), testPhoneNumber)
+	e := events[0]
+	e.Day = 15
+	ctx := store.WithActor(context.Background(), store.Actor{Source: store.SourceAPI, Key: "Zapier #3"})
+	if err := sess.store.UpdateEvent(ctx, testPhoneNumber, e); err != nil {
+		t.Fatal(err)
+	}
+
+	bh := EmptyBirthdayHistory(sess, e)
+	m := openScreen(sess, &bh)
+	view := m.View()
+	for _, want := range []string{"History · Ann", "You", "API · Zapier #3", "Day", "14 → 15", "Created"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view doesn't show %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestAccountDeletion(t *testing.T) {
+	ctx := context.Background()
+	sess := testSession(t)
+	yes := func() tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")} }
+
+	ad := EmptyAccountDeletion(sess)
+	m := openScreen(sess, &ad)
+	if view := m.View(); !strings.Contains(view, "Delete Your Account?") || !strings.Contains(view, "14 days") {
+		t.Fatalf("view doesn't ask to delete the account:\n%s", view)
+	}
+	settle(m, yes)
+	if due, _ := sess.store.DeletionDue(ctx, testPhoneNumber); due.IsZero() {
+		t.Fatal("confirming didn't schedule the account's deletion")
+	}
+
+	sf := EmptySettingsForm(sess)
+	m = openScreen(sess, &sf)
+	if view := m.View(); !strings.Contains(view, "Your account will be deleted on") {
+		t.Errorf("settings don't say the account will be deleted:\n%s", view)
+	}
+	ad = EmptyAccountDeletion(sess)
+	m = openScreen(sess, &ad)
+	if view := m.View(); !strings.Contains(view, "Keep Your Account?") {
+		t.Fatalf("view doesn't offer to keep the account:\n%s", view)
+	}
+	settle(m, yes)
+	if due, _ := sess.store.DeletionDue(ctx, testPhoneNumber); !due.IsZero() {
+		t.Errorf("keeping the account left its deletion due %v", due)
+	}
+}
+
+func TestOrganizationRoster(t *testing.T) {
+	ctx := context.Background()
+	sess := testSession(t)
+	const admin = "+15555550111"
+	st := sess.store
+	st.EnsureAccount(ctx, admin, "America/New_York")
+	org := store.Organization{Name: "Acme", WebhookURL: "https://hooks.example.com/acme", Timezone: "UTC", AnnouncementHour: 9}
+	org.ID, _ = st.CreateOrganization(ctx, admin, org)
+	if _, err := st.AddToRoster(ctx, admin, store.RosterEntry{OrganizationID: org.ID, PhoneNumber: testPhoneNumber, Name: "Ann", Role: store.OrgMember, ShareYear: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	orgs := EmptyOrganizations(sess)
+	m := openScreen(sess, &orgs)
+	if view := m.View(); !strings.Contains(view, "Acme") || !strings.Contains(view, "Member") {
+		t.Fatalf("view doesn't list the organization:\n%s", view)
+	}
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyEnter} })
+	if view := m.View(); !strings.Contains(view, "Press m to enter your birthday.") {
+		t.Fatalf("roster doesn't ask for the member's birthday:\n%s", view)
+	}
+	m = settle(m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")} })
+	if view := m.View(); !strings.Contains(view, "only admins of Acme can do that") {
+		t.Errorf("adding as a member wasn't refused:\n%s", view)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/collect"
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/notifier"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"strings"
+)
+
+// session is what every screen of one user's session shares. RootModel
+// creates it once and screens keep a pointer to it, so signing in on the
+// phone number form is seen by every screen that follows.
+type session struct {
+	store   store.Store
+	lg      *lipgloss.Renderer
+	styles  *Styles
+	printer i18n.Printer
+	// phoneNumber is the signed in account, in E.164 form. It's empty until
+	// the phone number form has been submitted.
+	phoneNumber string
+	clock       clock.Clock
+	// sender delivers test messages.
+	sender notifier.Sender
+	// links makes the links that collect birthdays for a list. It's nil
+	// unless COLLECT_URL and COLLECT_SECRET were set, as cmd/server needs.
+	links *collect.Links
+	// actor is recorded in the audit log as making the session's changes.
+	actor store.Actor
+	// fixedTheme, when set, is used instead of the account's theme. The local
+	// app sets it from the -theme flag.
+	fixedTheme string
+}
+
+// newSession builds a session whose styles are rendered for lg, which over
+// SSH must be the renderer of the client's terminal.
+func newSession(st store.Store, lg *lipgloss.Renderer) *session {
+	return &session{
+		store:   st,
+		lg:      lg,
+		styles:  NewStyles(lg, Themes[0]),
+		printer: i18n.For(i18n.Locales[0]),
+		clock:   clock.System,
+		sender:  notifier.LogSender{Logger: log.Default()},
+	}
+}
+
+// ctx is the context of the session's changes to events and settings,
+// which records them as made by its actor.
+func (s *session) ctx() context.Context {
+	return store.WithActor(context.Background(), s.actor)
+}
+
+// T translates a UI string into the session's locale. See i18n.Printer.T.
+func (s *session) T(message string, args ...any) string {
+	return s.printer.T(message, args...)
+}
+
+// setLocale switches the session to the named locale. Unknown names fall
+// back to the default.
+func (s *session) setLocale(name string) {
+	s.printer = i18n.For(i18n.Locale(name))
+}
+
+// envLocale picks the locale to use before anyone has signed in from the
+// usual environment variables, such as an SSH client's $LANG.
+func envLocale(environ []string) (i18n.Locale, bool) {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		for _, kv := range environ {
+			if value, ok := strings.CutPrefix(kv, name+"="); ok && value != "" {
+				return i18n.Match(value)
+			}
+		}
+	}
+	return "", false
+}
+
+// setTheme restyles the session with the named theme, unless a theme was
+// fixed on the command line.
+func (s *session) setTheme(name string) {
+	if s.fixedTheme != "" {
+		name = s.fixedTheme
+	}
+	s.styles = NewStyles(s.lg, themeOrDefault(name))
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/phone"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"database/sql"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// SETTINGS FORM KEYMAPS
+type sfKeyMap struct {
+	Tokens key.Binding
+	Delete key.Binding
+	Back   key.Binding
+	Quit   key.Binding
+}
+
+func (k sfKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Tokens, k.Delete, k.Back, k.Quit}
+}
+
+func (k sfKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{
+		k.Tokens, k.Delete, k.Back,
+	}}
+}
+
+var sfKeys = sfKeyMap{
+	Tokens: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "API tokens"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "delete account"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// SETTINGS FORM MODEL
+
+type SfModel struct {
+	session  *session
+	settings store.Settings
+	// deletionDue is when the account will be deleted, or zero when it
+	// won't be.
+	deletionDue time.Time
+	form        *huh.Form
+	width       int
+	km          sfKeyMap
+	banner      errorBanner
+}
+
+// SETTINGS FORM INITIALIZATION AND VALIDATION
+
+func validateNotificationDays(days string) error {
+	daysInt, err := strconv.Atoi(days)
+	if err != nil || daysInt < 1 || daysInt > 365 {
+		return i18n.Errorf("must be a number between 1 and 365")
+	}
+	return nil
+}
+
+func validateTagNotificationDays(days string) error {
+	if days == "" {
+		return nil
+	}
+	return validateNotificationDays(days)
+}
+
+func tagSettingKey(tag string) string {
+	return "tag:" + tag
+}
+
+func PopulatedSettingsForm(settings store.Settings, styles *Styles, p i18n.Printer) *huh.Form {
+	notificationDays := strconv.Itoa(settings.NotificationDays)
+	timezone := settings.Timezone
+	notificationHour := settings.NotificationHour
+	var hours []huh.Option[int]
+	for hour := 0; hour < 24; hour++ {
+		hours = append(hours, huh.NewOption(formatHour(p, hour), hour))
+	}
+	enabled := settings.Enabled
+	includeGiftIdeas := settings.IncludeGiftIdeas
+	theme := themeOrDefault(settings.Theme).Name
+	locale := string(i18n.For(i18n.Locale(settings.Locale)).Locale())
+	groups := []*huh.Group{
+		huh.NewGroup(
+			huh.NewInput().
+				Key("notificationDays").
+				Title(p.T("Days of Notice")).
+				Description(p.T("How many days ahead of a birthday to start sending reminders.")).
+				Value(&notificationDays).
+				CharLimit(3).
+				Validate(validateNotificationDays),
+			huh.NewSelect[string]().
+				Key("timezone").
+				Title(p.T("Timezone")).
+				Description(p.T("Defaults to your phone number's country. Type / to search.")).
+				Options(huh.NewOptions(timezoneOptions(timezone)...)...).
+				Height(8).
+				Value(&timezone).
+				Validate(validateTimezone),
+			huh.NewSelect[int]().
+				Key("notificationHour").
+				Title(p.T("Reminder Time")).
+				Description(p.T("When to send reminders, in your timezone.")).
+				Options(hours...).
+				Height(8).
+				Value(&notificationHour),
+			huh.NewConfirm().
+				Key("enabled").
+				Title(p.T("Send Reminders?")).
+				Affirmative(p.T("Yep")).
+				Negative(p.T("Nope")).
+				Value(&enabled),
+			huh.NewConfirm().
+				Key("includeGiftIdeas").
+				Title(p.T("Include Gift Ideas in Reminders?")).
+				Affirmative(p.T("Yep")).
+				Negative(p.T("Nope")).
+				Value(&includeGiftIdeas),
+			huh.NewSelect[string]().
+				Key("theme").
+				Title(p.T("Theme")).
+				Description(p.T("Colors for this app. High contrast and colorblind safe themes are included.")).
+				Options(themeOptions(p)...).
+				Value(&theme),
+			huh.NewSelect[string]().
+				Key("locale").
+				Title(p.T("Language")).
+				Description(p.T("Language and date format for this app and your reminders.")).
+				Options(localeOptions()...).
+				Value(&locale),
+		),
+	}
+	if len(settings.Tags) > 0 {
+		var fields []huh.Field
+		for _, tag := range settings.Tags {
+			days := ""
+			if tag.NotificationDays.Valid {
+				days = strconv.FormatInt(tag.NotificationDays.Int64, 10)
+			}
+			fields = append(fields,
+				huh.NewInput().
+					Key(tagSettingKey(tag.Name)).
+					Title(p.T("Days of Notice for #%s", tag.Name)).
+					Description(p.T("Leave blank to use the account setting. Use 1 for day-of only.")).
+					Value(&days).
+					CharLimit(3).
+					Validate(validateTagNotificationDays),
+			)
+		}
+		groups = append(groups, huh.NewGroup(fields...))
+	}
+	groups = append(groups, huh.NewGroup(
+		huh.NewConfirm().
+			Key("confirm").
+			Title(p.T("Save Changes?")).
+			Affirmative(p.T("Yep")).
+			Negative(p.T("Nope")),
+	))
+	return huh.NewForm(groups...).WithShowHelp(false).WithShowErrors(false).WithTheme(styles.Form).WithKeyMap(formKeys(p))
+}
+
+func EmptySettingsForm(s *session) SfModel {
+	return SfModel{
+		session: s,
+		form:    PopulatedSettingsForm(store.Settings{NotificationDays: 14, Timezone: "UTC", NotificationHour: 9, Enabled: true}, s.styles, s.printer),
+		km:      localizeKeys(s.printer, sfKeys),
+	}
+}
+
+// SETTINGS FORM COMMANDS
+
+type settingsRetrievalMsg struct {
+	settings store.Settings
+}
+
+func getSettings(st store.AccountStore, phoneNumber string) tea.Cmd {
+	return func() tea.Msg {
+		s, err := st.GetSettings(context.Background(), phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return settingsRetrievalMsg{s}
+	}
+}
+
+func updateSettings(ctx context.Context, st store.AccountStore, phoneNumber string, settings store.Settings) tea.Cmd {
+	return func() tea.Msg {
+		if err := st.UpdateSettings(ctx, phoneNumber, settings); err != nil {
+			return dbErrMsg{err}
+		}
+		return dbSuccessMsg{}
+	}
+}
+
+// formSettings reads the submitted values back out of the form. The inputs
+// have already been validated, so conversion errors can't occur.
+func (m *SfModel) formSettings() store.Settings {
+	s := store.Settings{
+		Timezone:         m.form.GetString("timezone"),
+		NotificationHour: m.form.GetInt("notificationHour"),
+		Enabled:          m.form.GetBool("enabled"),
+		IncludeGiftIdeas: m.form.GetBool("includeGiftIdeas"),
+		Theme:            m.form.GetString("theme"),
+		Locale:           m.form.GetString("locale"),
+	}
+	s.NotificationDays, _ = strconv.Atoi(m.form.GetString("notificationDays"))
+	for _, tag := range m.settings.Tags {
+		t := store.TagSetting{Name: tag.Name}
+		if days, err := strconv.Atoi(m.form.GetString(tagSettingKey(tag.Name))); err == nil {
+			t.NotificationDays = sql.NullInt64{Int64: int64(days), Valid: true}
+		}
+		s.Tags = append(s.Tags, t)
+	}
+	return s
+}
+
+// SETTINGS FORM UPDATE-VIEW LOOP
+
+func (m *SfModel) Init() tea.Cmd {
+	return tea.Batch(
+		getSettings(m.session.store, m.session.phoneNumber),
+		getDeletionDue(m.session.store, m.session.phoneNumber),
+	)
+}
+
+func (m *SfModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 80) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		if key.Matches(msg, m.km.Back) {
+			return m, popScreen()
+		}
+		if key.Matches(msg, m.km.Tokens) {
+			tk := EmptyAPITokens(m.session)
+			return m, pushScreen(&tk)
+		}
+		if key.Matches(msg, m.km.Delete) {
+			ad := EmptyAccountDeletion(m.session)
+			return m, pushScreen(&ad)
+		}
+	case settingsRetrievalMsg:
+		m.settings = msg.settings
+		m.form = PopulatedSettingsForm(m.settings, m.session.styles, m.session.printer)
+		return m, m.form.Init()
+	case deletionDueMsg:
+		m.deletionDue = msg.due
+		return m, nil
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	case dbSuccessMsg:
+		m.session.setTheme(m.form.GetString("theme"))
+		m.session.setLocale(m.form.GetString("locale"))
+		return m, popScreen()
+	}
+	f, cmd := m.form.Update(msg)
+	m.form = f.(*huh.Form)
+	if m.form.State == huh.StateCompleted {
+		if !m.form.GetBool("confirm") {
+			return m, popScreen()
+		}
+		return m, updateSettings(m.session.ctx(), m.session.store, m.session.phoneNumber, m.formSettings())
+	}
+	return m, cmd
+}
+
+func (m *SfModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("Settings · %s", phone.FormatNational(m.session.phoneNumber))))
+	notice := ""
+	if due := m.deletionDue; !due.IsZero() {
+		date := m.session.printer.Date(due.Year(), due.Month(), due.Day())
+		notice = m.session.styles.Highlight.Render(m.session.T("Your account will be deleted on %s. Press ctrl+x to keep it.", date)) + "\n\n"
+	}
+	body := m.session.styles.Base.Render(notice + m.form.View() + m.session.styles.FormErrors(m.form, m.session.printer))
+	footer := m.appBoundaryView(m.form.Help().ShortHelpView(slices.Concat(m.km.ShortHelp(), m.form.KeyBinds())))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *SfModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Center,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"modernc.org/sqlite"
+	"strings"
+)
+
+// Backup writes a consistent copy of the database to path, which mustn't
+// exist yet, while it stays in use.
+func (s *SQLite) Backup(ctx context.Context, path string) error {
+	_, err := s.db.ExecContext(ctx, `vacuum into ?;`, path)
+	return err
+}
+
+// IntegrityCheck returns the problems SQLite finds with the database's
+// file, if it finds any.
+func (s *SQLite) IntegrityCheck(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `pragma integrity_check;`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return err
+		}
+		if problem != "ok" {
+			problems = append(problems, problem)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return errors.New("integrity check failed: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Restore replaces the contents of the database with those of the database
+// at path, such as a backup. It goes through SQLite's backup API rather than
+// replacing the file, so it's safe while other processes have the database
+// open; they see the restored contents once it's done.
+func (s *SQLite) Restore(ctx context.Context, path string) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(dc any) error {
+		if o, ok := dc.(observedConn); ok {
+			dc = o.Conn
+		}
+		r, ok := dc.(interface {
+			NewRestore(srcURI string) (*sqlite.Backup, error)
+		})
+		if !ok {
+			return errors.New("store: the database driver can't restore backups")
+		}
+		b, err := r.NewRestore(path)
+		if err != nil {
+			return err
+		}
+		if _, err := b.Step(-1); err != nil {
+			b.Finish()
+			return err
+		}
+		return b.Finish()
+	})
+}
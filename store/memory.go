@@ -0,0 +1,1207 @@
+package store
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/events"
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Memory is an in-memory Store for tests. It mirrors the SQLite store's
+// behavior, including ordering and the defaults of new accounts.
+type Memory struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	nextId int
+	// nextAccountId numbers accounts apart from everything else, like
+	// their own table does.
+	nextAccountId int
+	accounts      map[string]*memoryAccount
+	events        map[int]memoryEvent
+	gifts         map[int]Gift
+	lists         map[int]*memoryList
+	invitations   map[int]Invitation
+	submissions   map[int]Submission
+	orgs          map[int]Organization
+	roster        map[int]RosterEntry
+	tokens        map[int]memoryToken
+	audit         []memoryAudit
+	deliveries    []Delivery
+}
+
+type memoryAccount struct {
+	// id orders accounts by when they signed up, like their rows.
+	id       int
+	settings Settings
+	// tags maps tag names to their notification overrides.
+	tags map[string]sql.NullInt64
+	// deletionDue is zero unless the account is scheduled to be deleted.
+	deletionDue time.Time
+}
+
+type memoryEvent struct {
+	event Event
+	// tags maps the phone numbers of members to the tags they gave the
+	// event.
+	tags map[string][]string
+}
+
+type memoryToken struct {
+	token       APIToken
+	phoneNumber string
+	hash        string
+}
+
+// memoryAudit keeps an audit event's records encoded, as they're stored in
+// the database.
+type memoryAudit struct {
+	event     AuditEvent
+	subject   string
+	subjectID int
+	before    sql.NullString
+	after     sql.NullString
+}
+
+type memoryList struct {
+	name  string
+	owner string
+	// members are in the order they joined, starting with the owner.
+	members     []Member
+	linkVersion int
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		clock:       clock.System,
+		accounts:    map[string]*memoryAccount{},
+		events:      map[int]memoryEvent{},
+		gifts:       map[int]Gift{},
+		lists:       map[int]*memoryList{},
+		invitations: map[int]Invitation{},
+		submissions: map[int]Submission{},
+		orgs:        map[int]Organization{},
+		roster:      map[int]RosterEntry{},
+		tokens:      map[int]memoryToken{},
+	}
+}
+
+// SetClock sets the clock that records are stamped from, which is the
+// system clock by default.
+func (m *Memory) SetClock(c clock.Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = c
+}
+
+// timestamp is the current time to the second, as the SQLite store keeps
+// it.
+func (m *Memory) timestamp() time.Time {
+	return m.clock.Now().UTC().Truncate(time.Second)
+}
+
+func (m *Memory) id() int {
+	m.nextId++
+	return m.nextId
+}
+
+func (m *Memory) account(phoneNumber string) (*memoryAccount, error) {
+	a, ok := m.accounts[phoneNumber]
+	if !ok {
+		return nil, fmt.Errorf("store: no account for %s", phoneNumber)
+	}
+	return a, nil
+}
+
+// role returns an account's role on a list, or "" when it isn't a member.
+func (m *Memory) role(phoneNumber string, listID int) Role {
+	if l, ok := m.lists[listID]; ok {
+		for _, member := range l.members {
+			if member.PhoneNumber == phoneNumber {
+				return member.Role
+			}
+		}
+	}
+	return ""
+}
+
+// eventFor returns a copy of an event with the tags phoneNumber gave it, so
+// callers don't share the tag slice with the store.
+func (e memoryEvent) eventFor(phoneNumber string) Event {
+	event := e.event
+	event.Tags = slices.Clone(e.tags[phoneNumber])
+	slices.Sort(event.Tags)
+	return event
+}
+
+// EVENTS
+
+func (m *Memory) ListEvents(ctx context.Context, phoneNumber string) ([]Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := []Event{}
+	for _, e := range m.events {
+		if m.role(phoneNumber, e.event.ListID) != "" {
+			list = append(list, e.eventFor(phoneNumber))
+		}
+	}
+	slices.SortFunc(list, func(a, b Event) int { return cmp.Compare(a.ID, b.ID) })
+	return list, nil
+}
+
+func (m *Memory) GetEvent(ctx context.Context, phoneNumber string, id int) (Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.events[id]
+	if !ok || m.role(phoneNumber, e.event.ListID) == "" {
+		return Event{}, ErrNotFound
+	}
+	return e.eventFor(phoneNumber), nil
+}
+
+func (m *Memory) CreateEvent(ctx context.Context, phoneNumber string, e Event) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, err := m.account(phoneNumber)
+	if err != nil {
+		return 0, err
+	}
+	if e.ListID == 0 {
+		e.ListID = m.personalList(phoneNumber)
+	}
+	if !m.role(phoneNumber, e.ListID).CanEdit() {
+		return 0, ErrReadOnly
+	}
+	e.ID = m.id()
+	m.setTags(a, e.Tags)
+	stored := memoryEvent{event: e, tags: map[string][]string{phoneNumber: uniqueTags(e.Tags)}}
+	stored.event.Tags = nil
+	m.events[e.ID] = stored
+	return e.ID, m.record(ctx, phoneNumber, AuditCreate, "event", e.ID, nil, eventRecord(e))
+}
+
+func (m *Memory) UpdateEvent(ctx context.Context, phoneNumber string, e Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, err := m.account(phoneNumber)
+	if err != nil {
+		return err
+	}
+	role, err := m.eventRole(phoneNumber, e.ID)
+	if err != nil {
+		return err
+	}
+	if !role.CanEdit() {
+		return ErrReadOnly
+	}
+	old := m.events[e.ID]
+	m.setTags(a, e.Tags)
+	before := old.event
+	old.tags[phoneNumber] = uniqueTags(e.Tags)
+	e.ListID = old.event.ListID
+	e.Tags = nil
+	old.event = e
+	m.events[e.ID] = old
+	return m.record(ctx, phoneNumber, AuditUpdate, "event", e.ID, eventRecord(before), eventRecord(e))
+}
+
+func (m *Memory) DeleteEvent(ctx context.Context, phoneNumber string, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	role, err := m.eventRole(phoneNumber, id)
+	if err != nil {
+		return err
+	}
+	if !role.CanEdit() {
+		return ErrReadOnly
+	}
+	e := m.events[id]
+	delete(m.events, id)
+	for giftID, g := range m.gifts {
+		if g.EventID == id {
+			delete(m.gifts, giftID)
+		}
+	}
+	return m.record(ctx, phoneNumber, AuditDelete, "event", id, eventRecord(e.event), nil)
+}
+
+// personalList returns the ID of the first list an account owns.
+func (m *Memory) personalList(phoneNumber string) int {
+	id := 0
+	for listID, l := range m.lists {
+		if l.owner == phoneNumber && (id == 0 || listID < id) {
+			id = listID
+		}
+	}
+	return id
+}
+
+func (m *Memory) setTags(a *memoryAccount, tags []string) {
+	for _, tag := range tags {
+		if _, ok := a.tags[tag]; !ok {
+			a.tags[tag] = sql.NullInt64{}
+		}
+	}
+}
+
+func (m *Memory) ListTags(ctx context.Context, phoneNumber string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tags := []string{}
+	if a, ok := m.accounts[phoneNumber]; ok {
+		for tag := range a.tags {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	return tags, nil
+}
+
+// GIFTS
+
+// eventRole returns an account's role on the list of an event, or
+// ErrNotFound when there's no such event or the account isn't a member.
+func (m *Memory) eventRole(phoneNumber string, eventID int) (Role, error) {
+	e, ok := m.events[eventID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	role := m.role(phoneNumber, e.event.ListID)
+	if role == "" {
+		return "", ErrNotFound
+	}
+	return role, nil
+}
+
+// editableGift returns a gift the account can edit, or ErrNotFound or
+// ErrReadOnly when it can't.
+func (m *Memory) editableGift(phoneNumber string, id int) (Gift, error) {
+	g, ok := m.gifts[id]
+	if !ok {
+		return Gift{}, ErrNotFound
+	}
+	role, err := m.eventRole(phoneNumber, g.EventID)
+	if err != nil {
+		return Gift{}, err
+	}
+	if !role.CanEdit() {
+		return Gift{}, ErrReadOnly
+	}
+	return g, nil
+}
+
+func (m *Memory) ListGifts(ctx context.Context, phoneNumber string, eventID int) ([]Gift, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.eventRole(phoneNumber, eventID); err != nil {
+		return nil, err
+	}
+	gifts := []Gift{}
+	for _, g := range m.gifts {
+		if g.EventID == eventID {
+			gifts = append(gifts, g)
+		}
+	}
+	slices.SortFunc(gifts, func(a, b Gift) int {
+		switch {
+		case a.Year.Valid != b.Year.Valid && !a.Year.Valid:
+			return -1
+		case a.Year.Valid != b.Year.Valid:
+			return 1
+		case a.Year.Int64 != b.Year.Int64:
+			return cmp.Compare(b.Year.Int64, a.Year.Int64)
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return gifts, nil
+}
+
+func (m *Memory) GetGift(ctx context.Context, phoneNumber string, id int) (Gift, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g, ok := m.gifts[id]
+	if !ok {
+		return Gift{}, ErrNotFound
+	}
+	if _, err := m.eventRole(phoneNumber, g.EventID); err != nil {
+		return Gift{}, err
+	}
+	return g, nil
+}
+
+func (m *Memory) CreateGift(ctx context.Context, phoneNumber string, g Gift) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	role, err := m.eventRole(phoneNumber, g.EventID)
+	if err != nil {
+		return 0, err
+	}
+	if !role.CanEdit() {
+		return 0, ErrReadOnly
+	}
+	g.ID = m.id()
+	m.gifts[g.ID] = g
+	return g.ID, nil
+}
+
+func (m *Memory) UpdateGift(ctx context.Context, phoneNumber string, g Gift) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, err := m.editableGift(phoneNumber, g.ID)
+	if err != nil {
+		return err
+	}
+	g.EventID = old.EventID
+	m.gifts[g.ID] = g
+	return nil
+}
+
+func (m *Memory) DeleteGift(ctx context.Context, phoneNumber string, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.editableGift(phoneNumber, id); err != nil {
+		return err
+	}
+	delete(m.gifts, id)
+	return nil
+}
+
+// REMINDERS
+
+func (m *Memory) ListCandidates(ctx context.Context) ([]Candidate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var candidates []Candidate
+	for _, e := range m.events {
+		for _, member := range m.lists[e.event.ListID].members {
+			a := m.accounts[member.PhoneNumber]
+			if !a.settings.Enabled || !a.deletionDue.IsZero() {
+				continue
+			}
+			c := Candidate{
+				Event:            e.eventFor(""),
+				PhoneNumber:      member.PhoneNumber,
+				Timezone:         a.settings.Timezone,
+				NotificationHour: a.settings.NotificationHour,
+				IncludeGiftIdeas: a.settings.IncludeGiftIdeas,
+				Locale:           a.settings.Locale,
+				NotificationDays: a.settings.NotificationDays,
+			}
+			window := sql.NullInt64{}
+			for _, tag := range e.tags[member.PhoneNumber] {
+				if days := a.tags[tag]; days.Valid && (!window.Valid || days.Int64 > window.Int64) {
+					window = days
+				}
+			}
+			if window.Valid {
+				c.NotificationDays = int(window.Int64)
+			}
+			candidates = append(candidates, c)
+		}
+	}
+	slices.SortFunc(candidates, func(a, b Candidate) int {
+		return cmp.Or(cmp.Compare(a.ID, b.ID), cmp.Compare(m.accounts[a.PhoneNumber].id, m.accounts[b.PhoneNumber].id))
+	})
+	return candidates, nil
+}
+
+// LISTS
+
+func (m *Memory) ListLists(ctx context.Context, phoneNumber string) ([]List, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lists := []List{}
+	for id, l := range m.lists {
+		if role := m.role(phoneNumber, id); role != "" {
+			lists = append(lists, List{ID: id, Name: l.name, Owner: l.owner, Role: role, LinkVersion: l.linkVersion})
+		}
+	}
+	// Lists the account owns come first.
+	shared := func(l List) int {
+		if l.Role == Owner {
+			return 0
+		}
+		return 1
+	}
+	slices.SortFunc(lists, func(a, b List) int {
+		return cmp.Or(cmp.Compare(shared(a), shared(b)), cmp.Compare(a.ID, b.ID))
+	})
+	return lists, nil
+}
+
+func (m *Memory) CreateList(ctx context.Context, phoneNumber string, name string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.account(phoneNumber); err != nil {
+		return 0, err
+	}
+	return m.createList(phoneNumber, name), nil
+}
+
+func (m *Memory) createList(phoneNumber string, name string) int {
+	id := m.id()
+	m.lists[id] = &memoryList{
+		name:    name,
+		owner:   phoneNumber,
+		members: []Member{{PhoneNumber: phoneNumber, Role: Owner}},
+	}
+	return id
+}
+
+func (m *Memory) ListMembers(ctx context.Context, phoneNumber string, listID int) ([]Member, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.role(phoneNumber, listID) == "" {
+		return nil, ErrNotFound
+	}
+	return append([]Member{}, m.lists[listID].members...), nil
+}
+
+func (m *Memory) Invite(ctx context.Context, phoneNumber string, listID int, invitee string, role Role) error {
+	if role != Editor && role != Viewer {
+		return fmt.Errorf("store: can't invite as %q", role)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.ownsList(phoneNumber, listID); err != nil {
+		return err
+	}
+	for id, i := range m.invitations {
+		if i.ListID == listID && i.To == invitee {
+			i.Role = role
+			m.invitations[id] = i
+			return nil
+		}
+	}
+	id := m.id()
+	m.invitations[id] = Invitation{ID: id, ListID: listID, To: invitee, Role: role}
+	return nil
+}
+
+func (m *Memory) ListInvitations(ctx context.Context, phoneNumber string) ([]Invitation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	invitations := []Invitation{}
+	for _, i := range m.invitations {
+		if i.To == phoneNumber {
+			l := m.lists[i.ListID]
+			i.ListName, i.From = l.name, l.owner
+			invitations = append(invitations, i)
+		}
+	}
+	slices.SortFunc(invitations, func(a, b Invitation) int { return cmp.Compare(a.ID, b.ID) })
+	return invitations, nil
+}
+
+func (m *Memory) RespondToInvitation(ctx context.Context, phoneNumber string, id int, accept bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i, ok := m.invitations[id]
+	if !ok || i.To != phoneNumber {
+		return ErrNotFound
+	}
+	delete(m.invitations, id)
+	if !accept {
+		return nil
+	}
+	if _, err := m.account(phoneNumber); err != nil {
+		return err
+	}
+	l := m.lists[i.ListID]
+	for j, member := range l.members {
+		if member.PhoneNumber == phoneNumber {
+			// Members invited again take the new role, but an owner stays
+			// one.
+			if member.Role != Owner {
+				l.members[j].Role = i.Role
+			}
+			return nil
+		}
+	}
+	l.members = append(l.members, Member{PhoneNumber: phoneNumber, Role: i.Role})
+	return nil
+}
+
+// SUBMISSIONS
+
+func (m *Memory) GetList(ctx context.Context, listID int) (List, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.lists[listID]
+	if !ok {
+		return List{}, ErrNotFound
+	}
+	return List{ID: listID, Name: l.name, Owner: l.owner, LinkVersion: l.linkVersion}, nil
+}
+
+func (m *Memory) SubmitBirthday(ctx context.Context, sub Submission) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.lists[sub.ListID]; !ok {
+		return 0, ErrNotFound
+	}
+	sub.ID = m.id()
+	// Stamped to the second, like CURRENT_TIMESTAMP.
+	sub.SubmittedAt = m.timestamp()
+	m.submissions[sub.ID] = sub
+	return sub.ID, nil
+}
+
+// ownsList returns ErrReadOnly unless an account owns a list, and
+// ErrNotFound when there's no such list.
+func (m *Memory) ownsList(phoneNumber string, listID int) error {
+	l, ok := m.lists[listID]
+	if !ok {
+		return ErrNotFound
+	}
+	if l.owner != phoneNumber {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+func (m *Memory) ListSubmissions(ctx context.Context, phoneNumber string, listID int) ([]Submission, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.ownsList(phoneNumber, listID); err != nil {
+		return nil, err
+	}
+	submissions := []Submission{}
+	for _, sub := range m.submissions {
+		if sub.ListID == listID {
+			submissions = append(submissions, sub)
+		}
+	}
+	slices.SortFunc(submissions, func(a, b Submission) int {
+		return cmp.Or(a.SubmittedAt.Compare(b.SubmittedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return submissions, nil
+}
+
+func (m *Memory) RegenerateLink(ctx context.Context, phoneNumber string, listID int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.ownsList(phoneNumber, listID); err != nil {
+		return 0, err
+	}
+	l := m.lists[listID]
+	l.linkVersion++
+	return l.linkVersion, nil
+}
+
+func (m *Memory) ReviewSubmission(ctx context.Context, phoneNumber string, id int, approve bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub, ok := m.submissions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := m.ownsList(phoneNumber, sub.ListID); err != nil {
+		return err
+	}
+	delete(m.submissions, id)
+	if approve {
+		e := Event{
+			ID:       m.id(),
+			ListID:   sub.ListID,
+			Name:     sub.Name,
+			Type:     events.Birthday,
+			Calendar: calendar.Gregorian,
+			Month:    sub.Month,
+			Day:      sub.Day,
+			Year:     sub.Year,
+		}
+		m.events[e.ID] = memoryEvent{event: e, tags: map[string][]string{}}
+		return m.record(ctx, phoneNumber, AuditCreate, "event", e.ID, nil, eventRecord(e))
+	}
+	return nil
+}
+
+// ORGANIZATIONS
+
+// orgRole returns an account's role in an organization, or ErrNotFound
+// when it isn't on the roster.
+func (m *Memory) orgRole(phoneNumber string, orgID int) (OrgRole, error) {
+	for _, e := range m.roster {
+		if e.OrganizationID == orgID && e.PhoneNumber == phoneNumber {
+			return e.Role, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// rosterOf returns an organization's members by name, with the years they
+// don't share left out except viewer's own.
+func (m *Memory) rosterOf(orgID int, viewer string) []RosterEntry {
+	roster := []RosterEntry{}
+	for _, e := range m.roster {
+		if e.OrganizationID != orgID {
+			continue
+		}
+		if !e.ShareYear && e.PhoneNumber != viewer {
+			e.Year = 0
+		}
+		roster = append(roster, e)
+	}
+	slices.SortFunc(roster, func(a, b RosterEntry) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return roster
+}
+
+func (m *Memory) ListOrganizations(ctx context.Context, phoneNumber string) ([]Organization, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	orgs := []Organization{}
+	for _, e := range m.roster {
+		if e.PhoneNumber == phoneNumber {
+			o := m.orgs[e.OrganizationID]
+			o.Role = e.Role
+			orgs = append(orgs, o)
+		}
+	}
+	slices.SortFunc(orgs, func(a, b Organization) int { return cmp.Compare(a.ID, b.ID) })
+	return orgs, nil
+}
+
+func (m *Memory) CreateOrganization(ctx context.Context, phoneNumber string, o Organization) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o.ID = m.id()
+	o.Role = ""
+	m.orgs[o.ID] = o
+	id := m.id()
+	m.roster[id] = RosterEntry{ID: id, OrganizationID: o.ID, PhoneNumber: phoneNumber, Role: OrgAdmin, ShareYear: true}
+	return o.ID, nil
+}
+
+func (m *Memory) UpdateOrganization(ctx context.Context, phoneNumber string, o Organization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	role, err := m.orgRole(phoneNumber, o.ID)
+	if err != nil {
+		return err
+	}
+	if role != OrgAdmin {
+		return ErrNotAdmin
+	}
+	o.Role = ""
+	m.orgs[o.ID] = o
+	return nil
+}
+
+func (m *Memory) ListRoster(ctx context.Context, phoneNumber string, orgID int) ([]RosterEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.orgRole(phoneNumber, orgID); err != nil {
+		return nil, err
+	}
+	return m.rosterOf(orgID, phoneNumber), nil
+}
+
+func (m *Memory) AddToRoster(ctx context.Context, phoneNumber string, e RosterEntry) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	role, err := m.orgRole(phoneNumber, e.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	if role != OrgAdmin {
+		return 0, ErrNotAdmin
+	}
+	if _, err := m.orgRole(e.PhoneNumber, e.OrganizationID); err == nil {
+		return 0, fmt.Errorf("store: %s is already on the roster", e.PhoneNumber)
+	}
+	e.ID = m.id()
+	m.roster[e.ID] = e
+	return e.ID, nil
+}
+
+func (m *Memory) UpdateRosterEntry(ctx context.Context, phoneNumber string, e RosterEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, ok := m.roster[e.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	role, err := m.orgRole(phoneNumber, old.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if e, err = rosterUpdate(old, e, phoneNumber, role); err != nil {
+		return err
+	}
+	m.roster[e.ID] = e
+	return nil
+}
+
+func (m *Memory) RemoveFromRoster(ctx context.Context, phoneNumber string, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.roster[id]
+	if !ok {
+		return ErrNotFound
+	}
+	role, err := m.orgRole(phoneNumber, e.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if role != OrgAdmin {
+		return ErrNotAdmin
+	}
+	if e.PhoneNumber == phoneNumber {
+		return errors.New("store: admins can't remove themselves")
+	}
+	delete(m.roster, id)
+	return nil
+}
+
+// orgLocale returns the locale of an organization's first admin with an
+// account.
+func (m *Memory) orgLocale(orgID int) string {
+	first := 0
+	locale := "en-US"
+	for id, e := range m.roster {
+		if e.OrganizationID != orgID || e.Role != OrgAdmin || first != 0 && id > first {
+			continue
+		}
+		if a, ok := m.accounts[e.PhoneNumber]; ok {
+			first, locale = id, a.settings.Locale
+		}
+	}
+	return locale
+}
+
+func (m *Memory) ListRosters(ctx context.Context) ([]Roster, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rosters := []Roster{}
+	for _, o := range m.orgs {
+		if o.WebhookURL != "" {
+			rosters = append(rosters, Roster{Organization: o, Members: m.rosterOf(o.ID, ""), Locale: m.orgLocale(o.ID)})
+		}
+	}
+	slices.SortFunc(rosters, func(a, b Roster) int { return cmp.Compare(a.ID, b.ID) })
+	return rosters, nil
+}
+
+// API TOKENS
+
+func (m *Memory) CreateAPIToken(ctx context.Context, phoneNumber string, name string) (APIToken, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.account(phoneNumber); err != nil {
+		return APIToken{}, "", err
+	}
+	secret, hash, err := newAPIToken()
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	t := APIToken{ID: m.id(), Name: name, CreatedAt: m.timestamp()}
+	m.tokens[t.ID] = memoryToken{token: t, phoneNumber: phoneNumber, hash: hash}
+	return t, secret, m.record(ctx, phoneNumber, AuditCreate, "api_token", t.ID, nil, tokenRecord(t))
+}
+
+func (m *Memory) ListAPITokens(ctx context.Context, phoneNumber string) ([]APIToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tokens := []APIToken{}
+	for _, t := range m.tokens {
+		if t.phoneNumber == phoneNumber {
+			tokens = append(tokens, t.token)
+		}
+	}
+	slices.SortFunc(tokens, func(a, b APIToken) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return tokens, nil
+}
+
+func (m *Memory) RevokeAPIToken(ctx context.Context, phoneNumber string, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tokens[id]
+	if !ok || t.phoneNumber != phoneNumber {
+		return ErrNotFound
+	}
+	delete(m.tokens, id)
+	return m.record(ctx, phoneNumber, AuditDelete, "api_token", id, tokenRecord(t.token), nil)
+}
+
+func (m *Memory) AuthenticateAPIToken(ctx context.Context, secret string) (string, APIToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash := hashAPIToken(secret)
+	for id, t := range m.tokens {
+		if t.hash == hash {
+			t.token.LastUsedAt = m.timestamp()
+			m.tokens[id] = t
+			return t.phoneNumber, t.token, nil
+		}
+	}
+	return "", APIToken{}, ErrNotFound
+}
+
+// AUDIT
+
+// record adds an audit event for a change made as the actor of ctx,
+// skipping updates that didn't change anything.
+func (m *Memory) record(ctx context.Context, phoneNumber string, action AuditAction, subject string, subjectID int, before, after map[string]any) error {
+	b, err := encodeRecord(before)
+	if err != nil {
+		return err
+	}
+	a, err := encodeRecord(after)
+	if err != nil {
+		return err
+	}
+	if action == AuditUpdate && b == a {
+		return nil
+	}
+	m.audit = append(m.audit, memoryAudit{
+		event: AuditEvent{
+			ID:          m.id(),
+			PhoneNumber: phoneNumber,
+			Actor:       actorFrom(ctx),
+			Action:      action,
+			Subject:     subject,
+			At:          m.timestamp(),
+		},
+		subject:   subject,
+		subjectID: subjectID,
+		before:    b,
+		after:     a,
+	})
+	return nil
+}
+
+func (m *Memory) EventHistory(ctx context.Context, phoneNumber string, eventID int) ([]AuditEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	history, err := m.history(func(a memoryAudit) bool { return a.subject == "event" && a.subjectID == eventID })
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, ErrNotFound
+	}
+	// Events don't move between lists, and the list is in every record of
+	// one, so the history outlives the event.
+	record := history[0].After
+	if record == nil {
+		record = history[0].Before
+	}
+	listID, _ := record["list_id"].(float64)
+	if m.role(phoneNumber, int(listID)) == "" {
+		return nil, ErrNotFound
+	}
+	return history, nil
+}
+
+func (m *Memory) AccountHistory(ctx context.Context, phoneNumber string) ([]AuditEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.history(func(a memoryAudit) bool {
+		return a.event.PhoneNumber == phoneNumber && a.subject != "event"
+	})
+}
+
+// history decodes the audit events that match, newest first.
+func (m *Memory) history(match func(memoryAudit) bool) ([]AuditEvent, error) {
+	history := []AuditEvent{}
+	for _, a := range slices.Backward(m.audit) {
+		if !match(a) {
+			continue
+		}
+		event := a.event
+		var err error
+		if event.Before, err = decodeRecord(a.before); err != nil {
+			return nil, err
+		}
+		if event.After, err = decodeRecord(a.after); err != nil {
+			return nil, err
+		}
+		history = append(history, event)
+	}
+	return history, nil
+}
+
+// DELIVERIES
+
+func (m *Memory) RecordDelivery(ctx context.Context, d Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d.ID = m.id()
+	d.At = m.timestamp()
+	m.deliveries = append(m.deliveries, d)
+	return nil
+}
+
+func (m *Memory) ListDeliveries(ctx context.Context, phoneNumber string) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deliveries := []Delivery{}
+	for _, d := range slices.Backward(m.deliveries) {
+		if d.PhoneNumber == phoneNumber {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *Memory) PurgeDeliveries(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.deliveries)
+	m.deliveries = slices.DeleteFunc(m.deliveries, func(d Delivery) bool { return d.At.Before(before) })
+	return n - len(m.deliveries), nil
+}
+
+// ACCOUNT DELETION
+
+func (m *Memory) ScheduleDeletion(ctx context.Context, phoneNumber string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.accounts[phoneNumber]
+	if !ok {
+		return time.Time{}, ErrNotFound
+	}
+	if !a.deletionDue.IsZero() {
+		return a.deletionDue, nil
+	}
+	a.deletionDue = m.timestamp().Add(DeletionGracePeriod)
+	return a.deletionDue, m.record(ctx, phoneNumber, AuditCreate, "deletion", a.id, nil, deletionRecord(a.deletionDue))
+}
+
+func (m *Memory) CancelDeletion(ctx context.Context, phoneNumber string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.accounts[phoneNumber]
+	if !ok {
+		return ErrNotFound
+	}
+	if a.deletionDue.IsZero() {
+		return nil
+	}
+	before := deletionRecord(a.deletionDue)
+	a.deletionDue = time.Time{}
+	return m.record(ctx, phoneNumber, AuditDelete, "deletion", a.id, before, nil)
+}
+
+func (m *Memory) DeletionDue(ctx context.Context, phoneNumber string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.accounts[phoneNumber]
+	if !ok {
+		return time.Time{}, ErrNotFound
+	}
+	return a.deletionDue, nil
+}
+
+func (m *Memory) ListScheduledDeletions(ctx context.Context) ([]ScheduledDeletion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deletions := []ScheduledDeletion{}
+	for phoneNumber, a := range m.accounts {
+		if a.deletionDue.IsZero() {
+			continue
+		}
+		d := ScheduledDeletion{PhoneNumber: phoneNumber, DueAt: a.deletionDue}
+		for id, l := range m.lists {
+			if l.owner != phoneNumber {
+				continue
+			}
+			d.Lists++
+			for _, e := range m.events {
+				if e.event.ListID == id {
+					d.Events++
+				}
+			}
+		}
+		deletions = append(deletions, d)
+	}
+	slices.SortFunc(deletions, func(a, b ScheduledDeletion) int {
+		return cmp.Or(a.DueAt.Compare(b.DueAt), cmp.Compare(m.accounts[a.PhoneNumber].id, m.accounts[b.PhoneNumber].id))
+	})
+	return deletions, nil
+}
+
+func (m *Memory) DeleteDueAccounts(ctx context.Context, now time.Time) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []string
+	for phoneNumber, a := range m.accounts {
+		if !a.deletionDue.IsZero() && !a.deletionDue.After(now) {
+			due = append(due, phoneNumber)
+		}
+	}
+	slices.SortFunc(due, func(a, b string) int {
+		return cmp.Or(m.accounts[a].deletionDue.Compare(m.accounts[b].deletionDue), cmp.Compare(m.accounts[a].id, m.accounts[b].id))
+	})
+	for _, phoneNumber := range due {
+		m.deleteAccount(phoneNumber)
+	}
+	return append([]string{}, due...), nil
+}
+
+// deleteAccount deletes an account and everything that goes with it, as
+// DeleteDueAccounts describes.
+func (m *Memory) deleteAccount(phoneNumber string) {
+	accountID := m.accounts[phoneNumber].id
+	deletedEvents := map[int]bool{}
+	for id, l := range m.lists {
+		if l.owner != phoneNumber {
+			l.members = slices.DeleteFunc(l.members, func(member Member) bool { return member.PhoneNumber == phoneNumber })
+			continue
+		}
+		for eventID, e := range m.events {
+			if e.event.ListID == id {
+				deletedEvents[eventID] = true
+				delete(m.events, eventID)
+			}
+		}
+		for subID, sub := range m.submissions {
+			if sub.ListID == id {
+				delete(m.submissions, subID)
+			}
+		}
+		delete(m.lists, id)
+	}
+	for giftID, g := range m.gifts {
+		if deletedEvents[g.EventID] {
+			delete(m.gifts, giftID)
+		}
+	}
+	for _, e := range m.events {
+		delete(e.tags, phoneNumber)
+	}
+	for id, inv := range m.invitations {
+		if _, ok := m.lists[inv.ListID]; !ok || inv.To == phoneNumber {
+			delete(m.invitations, id)
+		}
+	}
+	for id, t := range m.tokens {
+		if t.phoneNumber == phoneNumber {
+			delete(m.tokens, id)
+		}
+	}
+	// An organization the account was the only admin of passes to its
+	// longest-standing member, so somebody can still manage it.
+	for orgID := range m.orgs {
+		if role, _ := m.orgRole(phoneNumber, orgID); role != OrgAdmin {
+			continue
+		}
+		successor, hasAdmin := 0, false
+		for id, e := range m.roster {
+			if e.OrganizationID != orgID || e.PhoneNumber == phoneNumber {
+				continue
+			}
+			hasAdmin = hasAdmin || e.Role == OrgAdmin
+			if successor == 0 || id < successor {
+				successor = id
+			}
+		}
+		if successor != 0 && !hasAdmin {
+			e := m.roster[successor]
+			e.Role = OrgAdmin
+			m.roster[successor] = e
+		}
+	}
+	for id, e := range m.roster {
+		if e.PhoneNumber == phoneNumber {
+			delete(m.roster, id)
+		}
+	}
+	// An organization whose last member left has nobody to announce.
+	staffed := map[int]bool{}
+	for _, e := range m.roster {
+		staffed[e.OrganizationID] = true
+	}
+	for orgID := range m.orgs {
+		if !staffed[orgID] {
+			delete(m.orgs, orgID)
+		}
+	}
+	m.deliveries = slices.DeleteFunc(m.deliveries, func(d Delivery) bool {
+		return d.PhoneNumber == phoneNumber || d.OrganizationID != 0 && !staffed[d.OrganizationID]
+	})
+	m.audit = slices.DeleteFunc(m.audit, func(a memoryAudit) bool {
+		return a.subject == "event" && deletedEvents[a.subjectID] || a.subject == "settings" && a.subjectID == accountID
+	})
+	for i := range m.audit {
+		if m.audit[i].event.PhoneNumber == phoneNumber {
+			m.audit[i].event.PhoneNumber = ""
+		}
+	}
+	delete(m.accounts, phoneNumber)
+}
+
+// ACCOUNTS
+
+func (m *Memory) EnsureAccount(ctx context.Context, phoneNumber string, timezone string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.accounts[phoneNumber]; ok {
+		return nil
+	}
+	m.nextAccountId++
+	m.accounts[phoneNumber] = &memoryAccount{
+		id: m.nextAccountId,
+		settings: Settings{
+			NotificationDays: 14,
+			Timezone:         timezone,
+			NotificationHour: 9,
+			Enabled:          true,
+			Theme:            "default",
+			Locale:           "en-US",
+		},
+		tags: map[string]sql.NullInt64{},
+	}
+	m.createList(phoneNumber, PersonalList)
+	return nil
+}
+
+func (m *Memory) GetSettings(ctx context.Context, phoneNumber string) (Settings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.accounts[phoneNumber]
+	if !ok {
+		return Settings{}, ErrNotFound
+	}
+	return a.allSettings(), nil
+}
+
+// allSettings returns an account's settings with its tags' overrides.
+func (a *memoryAccount) allSettings() Settings {
+	s := a.settings
+	s.Tags = nil
+	for name, days := range a.tags {
+		s.Tags = append(s.Tags, TagSetting{Name: name, NotificationDays: days})
+	}
+	slices.SortFunc(s.Tags, func(a, b TagSetting) int { return cmp.Compare(a.Name, b.Name) })
+	return s
+}
+
+func (m *Memory) UpdateSettings(ctx context.Context, phoneNumber string, s Settings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, err := m.account(phoneNumber)
+	if err != nil {
+		return err
+	}
+	before := a.allSettings()
+	for _, tag := range s.Tags {
+		if _, ok := a.tags[tag.Name]; ok {
+			a.tags[tag.Name] = tag.NotificationDays
+		}
+	}
+	s.Tags = nil
+	a.settings = s
+	return m.record(ctx, phoneNumber, AuditUpdate, "settings", a.id, settingsRecord(before), settingsRecord(a.allSettings()))
+}
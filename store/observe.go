@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+)
+
+// QueryObserver is told how long each statement took to run. Op is "query"
+// or "exec", or "begin" for starting a transaction.
+type QueryObserver func(op string, took time.Duration)
+
+// OpenObserved opens the SQLite database at path like Open, reporting the
+// time every statement takes to observe.
+func OpenObserved(path string, observe QueryObserver) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// The driver is only needed to open connections of its own.
+	d := db.Driver()
+	db.Close()
+	return NewSQLite(sql.OpenDB(observedConnector{d, path, observe})), nil
+}
+
+type observedConnector struct {
+	driver  driver.Driver
+	dsn     string
+	observe QueryObserver
+}
+
+func (c observedConnector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return observedConn{conn, c.observe}, nil
+}
+
+func (c observedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// observedConn times the statements run on a connection. Statements that
+// were prepared aren't timed, as the store doesn't prepare any.
+type observedConn struct {
+	driver.Conn
+	observe QueryObserver
+}
+
+func (c observedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer c.since(time.Now(), "query")
+	return q.QueryContext(ctx, query, args)
+}
+
+func (c observedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer c.since(time.Now(), "exec")
+	return e.ExecContext(ctx, query, args)
+}
+
+func (c observedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	defer c.since(time.Now(), "begin")
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c observedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c observedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c observedConn) since(start time.Time, op string) {
+	c.observe(op, time.Since(start))
+}
@@ -0,0 +1,1742 @@
+package store
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/events"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	_ "modernc.org/sqlite"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SQLite is the Store backed by the database laid out by migrations/.
+type SQLite struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+func NewSQLite(db *sql.DB) *SQLite {
+	return &SQLite{db: db, clock: clock.System}
+}
+
+// SetClock sets the clock that created_at and updated_at are stamped from,
+// which is the system clock by default.
+func (s *SQLite) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// timestamp is the current time in the format of SQLite's
+// CURRENT_TIMESTAMP, which the columns' defaults use.
+func (s *SQLite) timestamp() string {
+	return s.clock.Now().UTC().Format(time.DateTime)
+}
+
+// Open opens the SQLite database at path.
+func Open(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	return NewSQLite(db), nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks that the database can be reached.
+func (s *SQLite) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// MigrationVersion returns the version of the last migration applied, and
+// whether it failed partway, as golang-migrate recorded them.
+func (s *SQLite) MigrationVersion(ctx context.Context) (version int, dirty bool, err error) {
+	var tables int
+	row := s.db.QueryRowContext(ctx, `select count(*) from sqlite_master where type = 'table' and name = 'schema_migrations';`)
+	if err := row.Scan(&tables); err != nil {
+		return 0, false, err
+	}
+	if tables == 0 {
+		return 0, false, errors.New("no schema_migrations table; apply migrations/ with golang-migrate")
+	}
+	row = s.db.QueryRowContext(ctx, `select version, dirty from schema_migrations limit 1;`)
+	if err := row.Scan(&version, &dirty); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("reading migration version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// splitTags undoes group_concat of tag names.
+func splitTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	split := strings.Split(tags, ",")
+	slices.Sort(split)
+	return split
+}
+
+// uniqueTags copies tags without repeats, since an event has each tag once.
+func uniqueTags(tags []string) []string {
+	var unique []string
+	for _, tag := range tags {
+		if !slices.Contains(unique, tag) {
+			unique = append(unique, tag)
+		}
+	}
+	return unique
+}
+
+// EVENTS
+
+func (s *SQLite) ListEvents(ctx context.Context, phoneNumber string) ([]Event, error) {
+	results, err := s.db.QueryContext(ctx, `
+select events.id, events.list_id, events.name, event_type, label, calendar, month, day, year, notes,
+       coalesce(group_concat(tags.name, ','), '')
+from events
+join list_members on list_members.list_id = events.list_id
+join phone_numbers on phone_numbers.id = list_members.phone_number_id
+left join event_tags on event_tags.event_id = events.id
+left join tags on tags.id = event_tags.tag_id and tags.phone_number_id = phone_numbers.id
+where phone_numbers.phone_number = ?
+group by events.id
+order by events.id;`, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	list := []Event{}
+	for results.Next() {
+		var e Event
+		var tags string
+		err := results.Scan(&e.ID, &e.ListID, &e.Name, &e.Type, &e.Label, &e.Calendar, &e.Month, &e.Day, &e.Year, &e.Notes, &tags)
+		if err != nil {
+			return nil, err
+		}
+		e.Tags = splitTags(tags)
+		list = append(list, e)
+	}
+	return list, results.Err()
+}
+
+func (s *SQLite) GetEvent(ctx context.Context, phoneNumber string, id int) (Event, error) {
+	var e Event
+	var tags string
+	row := s.db.QueryRowContext(ctx, `
+select events.id, events.list_id, events.name, event_type, label, calendar, month, day, year, notes,
+       coalesce(group_concat(tags.name, ','), '')
+from events
+left join event_tags on event_tags.event_id = events.id
+left join tags on tags.id = event_tags.tag_id
+    and tags.phone_number_id = (select id from phone_numbers where phone_number = ?)
+join list_members on list_members.list_id = events.list_id
+    and list_members.phone_number_id = (select id from phone_numbers where phone_number = ?)
+where events.id = ?
+group by events.id;`, phoneNumber, phoneNumber, id)
+	err := row.Scan(&e.ID, &e.ListID, &e.Name, &e.Type, &e.Label, &e.Calendar, &e.Month, &e.Day, &e.Year, &e.Notes, &tags)
+	if err != nil {
+		return Event{}, notFound(err)
+	}
+	e.Tags = splitTags(tags)
+	return e, nil
+}
+
+func (s *SQLite) CreateEvent(ctx context.Context, phoneNumber string, e Event) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if e.ListID == 0 {
+		row := tx.QueryRowContext(ctx, `
+select lists.id
+from lists
+join phone_numbers on phone_numbers.id = lists.owner_id
+where phone_numbers.phone_number = ?
+order by lists.id
+limit 1;`, phoneNumber)
+		if err := row.Scan(&e.ListID); err != nil {
+			return 0, notFound(err)
+		}
+	}
+	if err := canEdit(ctx, tx, phoneNumber, e.ListID); err != nil {
+		return 0, err
+	}
+	result, err := tx.ExecContext(ctx, `
+insert into events (list_id, phone_number_id, name, event_type, label, calendar, month, day, year, notes, created_at, updated_at)
+values (
+	?, (select owner_id from lists where id = ?),
+	?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);`, e.ListID, e.ListID, e.Name, e.Type, e.Label, e.Calendar, e.Month, e.Day, e.Year, e.Notes, s.timestamp(), s.timestamp())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := s.setEventTags(ctx, tx, phoneNumber, int(id), e.Tags); err != nil {
+		return 0, err
+	}
+	e.ID = int(id)
+	if err := s.audit(ctx, tx, phoneNumber, AuditCreate, "event", e.ID, nil, eventRecord(e)); err != nil {
+		return 0, err
+	}
+	return e.ID, tx.Commit()
+}
+
+func (s *SQLite) UpdateEvent(ctx context.Context, phoneNumber string, e Event) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	// Non-members are told the event doesn't exist, as GetEvent does.
+	role, err := eventRole(ctx, tx, phoneNumber, e.ID)
+	if err != nil {
+		return err
+	}
+	if !role.CanEdit() {
+		return ErrReadOnly
+	}
+	before, err := eventInTx(ctx, tx, e.ID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+update events
+set name = ?, event_type = ?, label = ?, calendar = ?, month = ?, day = ?, year = ?, notes = ?,
+    updated_at = ?
+where id = ?;`, e.Name, e.Type, e.Label, e.Calendar, e.Month, e.Day, e.Year, e.Notes, s.timestamp(), e.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.setEventTags(ctx, tx, phoneNumber, e.ID, e.Tags); err != nil {
+		return err
+	}
+	after, err := eventInTx(ctx, tx, e.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(ctx, tx, phoneNumber, AuditUpdate, "event", e.ID, eventRecord(before), eventRecord(after)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) DeleteEvent(ctx context.Context, phoneNumber string, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	// Non-members are told the event doesn't exist, as GetEvent does.
+	role, err := eventRole(ctx, tx, phoneNumber, id)
+	if err != nil {
+		return err
+	}
+	if !role.CanEdit() {
+		return ErrReadOnly
+	}
+	before, err := eventInTx(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	// Foreign keys aren't enforced, so the event's rows go explicitly.
+	for _, query := range []string{
+		`delete from gifts where event_id = ?;`,
+		`delete from event_tags where event_id = ?;`,
+		`delete from events where id = ?;`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+	if err := s.audit(ctx, tx, phoneNumber, AuditDelete, "event", id, eventRecord(before), nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// eventInTx returns an event without its tags.
+func eventInTx(ctx context.Context, tx *sql.Tx, id int) (Event, error) {
+	e := Event{ID: id}
+	row := tx.QueryRowContext(ctx, `
+select list_id, name, event_type, label, calendar, month, day, year, notes
+from events
+where id = ?;`, id)
+	if err := row.Scan(&e.ListID, &e.Name, &e.Type, &e.Label, &e.Calendar, &e.Month, &e.Day, &e.Year, &e.Notes); err != nil {
+		return Event{}, notFound(err)
+	}
+	return e, nil
+}
+
+// listRole returns an account's role on a list, or ErrNotFound when it
+// isn't a member.
+func listRole(ctx context.Context, q querier, phoneNumber string, listID int) (Role, error) {
+	var role Role
+	row := q.QueryRowContext(ctx, `
+select role
+from list_members
+join phone_numbers on phone_numbers.id = list_members.phone_number_id
+where phone_numbers.phone_number = ? and list_members.list_id = ?;`, phoneNumber, listID)
+	if err := row.Scan(&role); err != nil {
+		return "", notFound(err)
+	}
+	return role, nil
+}
+
+// canEdit returns ErrReadOnly unless an account is an owner or editor of a
+// list.
+func canEdit(ctx context.Context, tx *sql.Tx, phoneNumber string, listID int) error {
+	role, err := listRole(ctx, tx, phoneNumber, listID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if !role.CanEdit() {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// eventRole returns an account's role on the list of an event, or
+// ErrNotFound when there's no such event or the account isn't a member.
+func eventRole(ctx context.Context, q querier, phoneNumber string, eventID int) (Role, error) {
+	var role Role
+	row := q.QueryRowContext(ctx, `
+select list_members.role
+from events
+join list_members on list_members.list_id = events.list_id
+join phone_numbers on phone_numbers.id = list_members.phone_number_id
+where phone_numbers.phone_number = ? and events.id = ?;`, phoneNumber, eventID)
+	if err := row.Scan(&role); err != nil {
+		return "", notFound(err)
+	}
+	return role, nil
+}
+
+// canEditGift returns ErrNotFound unless there's a gift on an event the
+// account can see, and ErrReadOnly unless it can edit the event's list.
+func canEditGift(ctx context.Context, tx *sql.Tx, phoneNumber string, id int) error {
+	var eventID int
+	if err := tx.QueryRowContext(ctx, `select event_id from gifts where id = ?;`, id).Scan(&eventID); err != nil {
+		return notFound(err)
+	}
+	role, err := eventRole(ctx, tx, phoneNumber, eventID)
+	if err != nil {
+		return err
+	}
+	if !role.CanEdit() {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// setEventTags replaces the tags an account put on an event, creating any
+// tags the account doesn't have yet. Other members' tags are left alone.
+func (s *SQLite) setEventTags(ctx context.Context, tx *sql.Tx, phoneNumber string, eventId int, tags []string) error {
+	_, err := tx.ExecContext(ctx, `
+delete from event_tags
+where event_id = ? and tag_id in (
+    select tags.id
+    from tags
+    join phone_numbers on phone_numbers.id = tags.phone_number_id
+    where phone_numbers.phone_number = ?
+);`, eventId, phoneNumber)
+	if err != nil {
+		return err
+	}
+	for _, tag := range uniqueTags(tags) {
+		_, err := tx.ExecContext(ctx, `
+insert or ignore into tags (phone_number_id, name, created_at, updated_at)
+values ((select id from phone_numbers where phone_number = ?), ?, ?, ?);`, phoneNumber, tag, s.timestamp(), s.timestamp())
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+insert into event_tags (event_id, tag_id)
+select ?, tags.id
+from tags
+join phone_numbers on phone_numbers.id = tags.phone_number_id
+where phone_numbers.phone_number = ? and tags.name = ?;`, eventId, phoneNumber, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLite) ListTags(ctx context.Context, phoneNumber string) ([]string, error) {
+	results, err := s.db.QueryContext(ctx, `
+select tags.name
+from tags
+join phone_numbers on phone_numbers.id = tags.phone_number_id
+where phone_numbers.phone_number = ?
+order by tags.name;`, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	tags := []string{}
+	for results.Next() {
+		var tag string
+		if err := results.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, results.Err()
+}
+
+// GIFTS
+
+func (s *SQLite) ListGifts(ctx context.Context, phoneNumber string, eventID int) ([]Gift, error) {
+	if _, err := eventRole(ctx, s.db, phoneNumber, eventID); err != nil {
+		return nil, err
+	}
+	results, err := s.db.QueryContext(ctx, `
+select id, event_id, idea, status, year, price_cents, link
+from gifts
+where event_id = ?
+order by year desc nulls first, id;`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	gifts := []Gift{}
+	for results.Next() {
+		var g Gift
+		if err := results.Scan(&g.ID, &g.EventID, &g.Idea, &g.Status, &g.Year, &g.PriceCents, &g.Link); err != nil {
+			return nil, err
+		}
+		gifts = append(gifts, g)
+	}
+	return gifts, results.Err()
+}
+
+func (s *SQLite) GetGift(ctx context.Context, phoneNumber string, id int) (Gift, error) {
+	var g Gift
+	row := s.db.QueryRowContext(ctx, `
+select id, event_id, idea, status, year, price_cents, link
+from gifts
+where id = ?;`, id)
+	if err := row.Scan(&g.ID, &g.EventID, &g.Idea, &g.Status, &g.Year, &g.PriceCents, &g.Link); err != nil {
+		return Gift{}, notFound(err)
+	}
+	if _, err := eventRole(ctx, s.db, phoneNumber, g.EventID); err != nil {
+		return Gift{}, err
+	}
+	return g, nil
+}
+
+func (s *SQLite) CreateGift(ctx context.Context, phoneNumber string, g Gift) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	role, err := eventRole(ctx, tx, phoneNumber, g.EventID)
+	if err != nil {
+		return 0, err
+	}
+	if !role.CanEdit() {
+		return 0, ErrReadOnly
+	}
+	result, err := tx.ExecContext(ctx, `
+insert into gifts (event_id, idea, status, year, price_cents, link, created_at, updated_at)
+values (?, ?, ?, ?, ?, ?, ?, ?);`, g.EventID, g.Idea, g.Status, g.Year, g.PriceCents, g.Link, s.timestamp(), s.timestamp())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), tx.Commit()
+}
+
+func (s *SQLite) UpdateGift(ctx context.Context, phoneNumber string, g Gift) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := canEditGift(ctx, tx, phoneNumber, g.ID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+update gifts
+set idea = ?, status = ?, year = ?, price_cents = ?, link = ?, updated_at = ?
+where id = ?;`, g.Idea, g.Status, g.Year, g.PriceCents, g.Link, s.timestamp(), g.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) DeleteGift(ctx context.Context, phoneNumber string, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := canEditGift(ctx, tx, phoneNumber, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `delete from gifts where id = ?;`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// REMINDERS
+
+func (s *SQLite) ListCandidates(ctx context.Context) ([]Candidate, error) {
+	results, err := s.db.QueryContext(ctx, `
+WITH event_windows AS (
+    SELECT events.id AS event_id,
+           phone_numbers.id AS member_id,
+           COALESCE(MAX(tags.notification_days), phone_numbers.notification_days) AS notification_days
+    FROM events
+    JOIN list_members ON list_members.list_id = events.list_id
+    JOIN phone_numbers ON phone_numbers.id = list_members.phone_number_id
+    LEFT JOIN event_tags ON event_tags.event_id = events.id
+    LEFT JOIN tags ON tags.id = event_tags.tag_id AND tags.phone_number_id = phone_numbers.id
+    GROUP BY events.id, phone_numbers.id
+)
+SELECT events.id, events.list_id, events.event_type, events.label, events.name, events.calendar,
+       events.month, events.day, events.year, events.notes,
+       phone_numbers.phone_number, phone_numbers.timezone, phone_numbers.notification_hour,
+       phone_numbers.include_gift_ideas, phone_numbers.locale, event_windows.notification_days
+FROM events
+JOIN event_windows ON event_windows.event_id = events.id
+JOIN phone_numbers ON phone_numbers.id = event_windows.member_id
+WHERE phone_numbers.enabled = TRUE AND phone_numbers.deletion_due_at IS NULL
+ORDER BY events.id, phone_numbers.id;`)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	var candidates []Candidate
+	for results.Next() {
+		var c Candidate
+		err := results.Scan(&c.ID, &c.ListID, &c.Type, &c.Label, &c.Name, &c.Calendar, &c.Month, &c.Day, &c.Year, &c.Notes,
+			&c.PhoneNumber, &c.Timezone, &c.NotificationHour, &c.IncludeGiftIdeas, &c.Locale, &c.NotificationDays)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, results.Err()
+}
+
+// LISTS
+
+func (s *SQLite) ListLists(ctx context.Context, phoneNumber string) ([]List, error) {
+	results, err := s.db.QueryContext(ctx, `
+select lists.id, lists.name, owners.phone_number, list_members.role, lists.link_version
+from list_members
+join phone_numbers on phone_numbers.id = list_members.phone_number_id
+join lists on lists.id = list_members.list_id
+join phone_numbers owners on owners.id = lists.owner_id
+where phone_numbers.phone_number = ?
+order by lists.owner_id != phone_numbers.id, lists.id;`, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	lists := []List{}
+	for results.Next() {
+		var l List
+		if err := results.Scan(&l.ID, &l.Name, &l.Owner, &l.Role, &l.LinkVersion); err != nil {
+			return nil, err
+		}
+		lists = append(lists, l)
+	}
+	return lists, results.Err()
+}
+
+func (s *SQLite) CreateList(ctx context.Context, phoneNumber string, name string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	id, err := s.createList(ctx, tx, phoneNumber, name)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (s *SQLite) createList(ctx context.Context, tx *sql.Tx, phoneNumber string, name string) (int, error) {
+	result, err := tx.ExecContext(ctx, `
+insert into lists (name, owner_id, created_at, updated_at)
+values (?, (select id from phone_numbers where phone_number = ?), ?, ?);`, name, phoneNumber, s.timestamp(), s.timestamp())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `
+insert into list_members (list_id, phone_number_id, role, created_at)
+select ?, owner_id, 'owner', ? from lists where id = ?;`, id, s.timestamp(), id)
+	return int(id), err
+}
+
+func (s *SQLite) ListMembers(ctx context.Context, phoneNumber string, listID int) ([]Member, error) {
+	if _, err := listRole(ctx, s.db, phoneNumber, listID); err != nil {
+		return nil, err
+	}
+	results, err := s.db.QueryContext(ctx, `
+select phone_numbers.phone_number, list_members.role
+from list_members
+join phone_numbers on phone_numbers.id = list_members.phone_number_id
+where list_members.list_id = ?
+order by list_members.role != 'owner', list_members.created_at, phone_numbers.id;`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	members := []Member{}
+	for results.Next() {
+		var m Member
+		if err := results.Scan(&m.PhoneNumber, &m.Role); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, results.Err()
+}
+
+func (s *SQLite) Invite(ctx context.Context, phoneNumber string, listID int, invitee string, role Role) error {
+	if role != Editor && role != Viewer {
+		return fmt.Errorf("store: can't invite as %q", role)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := ownsList(ctx, tx, phoneNumber, listID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+insert into list_invitations (list_id, phone_number, role, invited_by, created_at)
+values (?, ?, ?, (select id from phone_numbers where phone_number = ?), ?)
+on conflict (list_id, phone_number) do update set role = excluded.role, created_at = excluded.created_at;`,
+		listID, invitee, role, phoneNumber, s.timestamp())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) ListInvitations(ctx context.Context, phoneNumber string) ([]Invitation, error) {
+	results, err := s.db.QueryContext(ctx, `
+select list_invitations.id, lists.id, lists.name, owners.phone_number, list_invitations.phone_number, list_invitations.role
+from list_invitations
+join lists on lists.id = list_invitations.list_id
+join phone_numbers owners on owners.id = lists.owner_id
+where list_invitations.phone_number = ?
+order by list_invitations.id;`, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	invitations := []Invitation{}
+	for results.Next() {
+		var i Invitation
+		if err := results.Scan(&i.ID, &i.ListID, &i.ListName, &i.From, &i.To, &i.Role); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, i)
+	}
+	return invitations, results.Err()
+}
+
+func (s *SQLite) RespondToInvitation(ctx context.Context, phoneNumber string, id int, accept bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var listID int
+	var role Role
+	row := tx.QueryRowContext(ctx, `
+select list_id, role
+from list_invitations
+where id = ? and phone_number = ?;`, id, phoneNumber)
+	if err := row.Scan(&listID, &role); err != nil {
+		return notFound(err)
+	}
+	if accept {
+		// Members invited again take the new role, but an owner stays one.
+		_, err := tx.ExecContext(ctx, `
+insert into list_members (list_id, phone_number_id, role, created_at)
+values (?, (select id from phone_numbers where phone_number = ?), ?, ?)
+on conflict (list_id, phone_number_id) do update set role = excluded.role
+where list_members.role != 'owner';`, listID, phoneNumber, role, s.timestamp())
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `delete from list_invitations where id = ?;`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SUBMISSIONS
+
+func (s *SQLite) GetList(ctx context.Context, listID int) (List, error) {
+	l := List{ID: listID}
+	row := s.db.QueryRowContext(ctx, `
+select lists.name, phone_numbers.phone_number, lists.link_version
+from lists
+join phone_numbers on phone_numbers.id = lists.owner_id
+where lists.id = ?;`, listID)
+	if err := row.Scan(&l.Name, &l.Owner, &l.LinkVersion); err != nil {
+		return List{}, notFound(err)
+	}
+	return l, nil
+}
+
+func (s *SQLite) SubmitBirthday(ctx context.Context, sub Submission) (int, error) {
+	result, err := s.db.ExecContext(ctx, `
+insert into birthday_submissions (list_id, name, month, day, year, created_at)
+select id, ?, ?, ?, ?, ? from lists where id = ?;`, sub.Name, sub.Month, sub.Day, sub.Year, s.timestamp(), sub.ListID)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return 0, err
+	} else if n == 0 {
+		return 0, ErrNotFound
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (s *SQLite) ListSubmissions(ctx context.Context, phoneNumber string, listID int) ([]Submission, error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := ownsList(ctx, tx, phoneNumber, listID); err != nil {
+		return nil, err
+	}
+	results, err := tx.QueryContext(ctx, `
+select id, list_id, name, month, day, year, created_at
+from birthday_submissions
+where list_id = ?
+order by created_at, id;`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	submissions := []Submission{}
+	for results.Next() {
+		var sub Submission
+		if err := results.Scan(&sub.ID, &sub.ListID, &sub.Name, &sub.Month, &sub.Day, &sub.Year, &sub.SubmittedAt); err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, sub)
+	}
+	return submissions, results.Err()
+}
+
+func (s *SQLite) ReviewSubmission(ctx context.Context, phoneNumber string, id int, approve bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var sub Submission
+	row := tx.QueryRowContext(ctx, `
+select list_id, name, month, day, year
+from birthday_submissions
+where id = ?;`, id)
+	if err := row.Scan(&sub.ListID, &sub.Name, &sub.Month, &sub.Day, &sub.Year); err != nil {
+		return notFound(err)
+	}
+	if err := ownsList(ctx, tx, phoneNumber, sub.ListID); err != nil {
+		return err
+	}
+	if approve {
+		e := Event{ListID: sub.ListID, Name: sub.Name, Type: events.Birthday, Calendar: calendar.Gregorian, Month: sub.Month, Day: sub.Day, Year: sub.Year}
+		result, err := tx.ExecContext(ctx, `
+insert into events (list_id, phone_number_id, name, event_type, label, calendar, month, day, year, notes, created_at, updated_at)
+values (?, (select owner_id from lists where id = ?), ?, ?, '', ?, ?, ?, ?, '', ?, ?);`,
+			e.ListID, e.ListID, e.Name, e.Type, e.Calendar, e.Month, e.Day, e.Year, s.timestamp(), s.timestamp())
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		e.ID = int(id)
+		if err := s.audit(ctx, tx, phoneNumber, AuditCreate, "event", e.ID, nil, eventRecord(e)); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `delete from birthday_submissions where id = ?;`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) RegenerateLink(ctx context.Context, phoneNumber string, listID int) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if err := ownsList(ctx, tx, phoneNumber, listID); err != nil {
+		return 0, err
+	}
+	var version int
+	row := tx.QueryRowContext(ctx, `
+update lists
+set link_version = link_version + 1, updated_at = ?
+where id = ?
+returning link_version;`, s.timestamp(), listID)
+	if err := row.Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
+}
+
+// ownsList returns ErrReadOnly unless an account owns a list, and
+// ErrNotFound when there's no such list.
+func ownsList(ctx context.Context, tx *sql.Tx, phoneNumber string, listID int) error {
+	var owner string
+	row := tx.QueryRowContext(ctx, `
+select phone_numbers.phone_number
+from lists
+join phone_numbers on phone_numbers.id = lists.owner_id
+where lists.id = ?;`, listID)
+	if err := row.Scan(&owner); err != nil {
+		return notFound(err)
+	}
+	if owner != phoneNumber {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// ORGANIZATIONS
+
+// orgRole returns an account's role in an organization, or ErrNotFound
+// when it isn't on the roster.
+func orgRole(ctx context.Context, tx *sql.Tx, phoneNumber string, orgID int) (OrgRole, error) {
+	var role OrgRole
+	row := tx.QueryRowContext(ctx, `
+select role from organization_members
+where organization_id = ? and phone_number = ?;`, orgID, phoneNumber)
+	if err := row.Scan(&role); err != nil {
+		return "", notFound(err)
+	}
+	return role, nil
+}
+
+func (s *SQLite) ListOrganizations(ctx context.Context, phoneNumber string) ([]Organization, error) {
+	results, err := s.db.QueryContext(ctx, `
+select organizations.id, organizations.name, organizations.webhook_url, organizations.timezone,
+       organizations.announcement_hour, organization_members.role
+from organization_members
+join organizations on organizations.id = organization_members.organization_id
+where organization_members.phone_number = ?
+order by organizations.id;`, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	orgs := []Organization{}
+	for results.Next() {
+		var o Organization
+		if err := results.Scan(&o.ID, &o.Name, &o.WebhookURL, &o.Timezone, &o.AnnouncementHour, &o.Role); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+	return orgs, results.Err()
+}
+
+func (s *SQLite) CreateOrganization(ctx context.Context, phoneNumber string, o Organization) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx, `
+insert into organizations (name, webhook_url, timezone, announcement_hour, created_at, updated_at)
+values (?, ?, ?, ?, ?, ?);`, o.Name, o.WebhookURL, o.Timezone, o.AnnouncementHour, s.timestamp(), s.timestamp())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `
+insert into organization_members (organization_id, phone_number, role, created_at, updated_at)
+values (?, ?, 'admin', ?, ?);`, id, phoneNumber, s.timestamp(), s.timestamp())
+	if err != nil {
+		return 0, err
+	}
+	return int(id), tx.Commit()
+}
+
+func (s *SQLite) UpdateOrganization(ctx context.Context, phoneNumber string, o Organization) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	role, err := orgRole(ctx, tx, phoneNumber, o.ID)
+	if err != nil {
+		return err
+	}
+	if role != OrgAdmin {
+		return ErrNotAdmin
+	}
+	_, err = tx.ExecContext(ctx, `
+update organizations
+set name = ?, webhook_url = ?, timezone = ?, announcement_hour = ?, updated_at = ?
+where id = ?;`, o.Name, o.WebhookURL, o.Timezone, o.AnnouncementHour, s.timestamp(), o.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// scanRoster reads organization_members rows selected as id,
+// organization_id, phone_number, name, role, month, day, year, share_year.
+func scanRoster(results *sql.Rows) ([]RosterEntry, error) {
+	defer results.Close()
+	roster := []RosterEntry{}
+	for results.Next() {
+		var e RosterEntry
+		if err := results.Scan(&e.ID, &e.OrganizationID, &e.PhoneNumber, &e.Name, &e.Role, &e.Month, &e.Day, &e.Year, &e.ShareYear); err != nil {
+			return nil, err
+		}
+		roster = append(roster, e)
+	}
+	return roster, results.Err()
+}
+
+func (s *SQLite) ListRoster(ctx context.Context, phoneNumber string, orgID int) ([]RosterEntry, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := orgRole(ctx, tx, phoneNumber, orgID); err != nil {
+		return nil, err
+	}
+	results, err := tx.QueryContext(ctx, `
+select id, organization_id, phone_number, name, role, month, day,
+       case when share_year or phone_number = ? then year else 0 end, share_year
+from organization_members
+where organization_id = ?
+order by name, id;`, phoneNumber, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return scanRoster(results)
+}
+
+func (s *SQLite) AddToRoster(ctx context.Context, phoneNumber string, e RosterEntry) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	role, err := orgRole(ctx, tx, phoneNumber, e.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	if role != OrgAdmin {
+		return 0, ErrNotAdmin
+	}
+	if _, err := orgRole(ctx, tx, e.PhoneNumber, e.OrganizationID); err == nil {
+		return 0, fmt.Errorf("store: %s is already on the roster", e.PhoneNumber)
+	}
+	result, err := tx.ExecContext(ctx, `
+insert into organization_members (organization_id, phone_number, name, role, month, day, year, share_year, created_at, updated_at)
+values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`, e.OrganizationID, e.PhoneNumber, e.Name, e.Role, e.Month, e.Day, e.Year, e.ShareYear,
+		s.timestamp(), s.timestamp())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), tx.Commit()
+}
+
+func (s *SQLite) UpdateRosterEntry(ctx context.Context, phoneNumber string, e RosterEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var old RosterEntry
+	row := tx.QueryRowContext(ctx, `
+select organization_id, phone_number, role, year, share_year
+from organization_members
+where id = ?;`, e.ID)
+	if err := row.Scan(&old.OrganizationID, &old.PhoneNumber, &old.Role, &old.Year, &old.ShareYear); err != nil {
+		return notFound(err)
+	}
+	role, err := orgRole(ctx, tx, phoneNumber, old.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if e, err = rosterUpdate(old, e, phoneNumber, role); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+update organization_members
+set name = ?, role = ?, month = ?, day = ?, year = ?, share_year = ?, updated_at = ?
+where id = ?;`, e.Name, e.Role, e.Month, e.Day, e.Year, e.ShareYear, s.timestamp(), e.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rosterUpdate applies the rules of UpdateRosterEntry to an update of old
+// to e by an account with role, returning what to save.
+func rosterUpdate(old RosterEntry, e RosterEntry, phoneNumber string, role OrgRole) (RosterEntry, error) {
+	self := old.PhoneNumber == phoneNumber
+	if !self && role != OrgAdmin {
+		return RosterEntry{}, ErrNotAdmin
+	}
+	e.OrganizationID, e.PhoneNumber = old.OrganizationID, old.PhoneNumber
+	if self {
+		e.Role = old.Role
+	} else {
+		e.ShareYear = old.ShareYear
+		if !old.ShareYear {
+			// Admins were never shown the year, so keep it.
+			e.Year = old.Year
+		}
+	}
+	return e, nil
+}
+
+func (s *SQLite) RemoveFromRoster(ctx context.Context, phoneNumber string, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var orgID int
+	var member string
+	row := tx.QueryRowContext(ctx, `select organization_id, phone_number from organization_members where id = ?;`, id)
+	if err := row.Scan(&orgID, &member); err != nil {
+		return notFound(err)
+	}
+	role, err := orgRole(ctx, tx, phoneNumber, orgID)
+	if err != nil {
+		return err
+	}
+	if role != OrgAdmin {
+		return ErrNotAdmin
+	}
+	if member == phoneNumber {
+		return errors.New("store: admins can't remove themselves")
+	}
+	if _, err := tx.ExecContext(ctx, `delete from organization_members where id = ?;`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) ListRosters(ctx context.Context) ([]Roster, error) {
+	results, err := s.db.QueryContext(ctx, `
+select id, name, webhook_url, timezone, announcement_hour,
+       coalesce((
+         select phone_numbers.locale
+         from organization_members
+         join phone_numbers on phone_numbers.phone_number = organization_members.phone_number
+         where organization_members.organization_id = organizations.id and organization_members.role = 'admin'
+         order by organization_members.id
+         limit 1
+       ), 'en-US')
+from organizations
+where webhook_url != ''
+order by id;`)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	rosters := []Roster{}
+	for results.Next() {
+		var r Roster
+		if err := results.Scan(&r.ID, &r.Name, &r.WebhookURL, &r.Timezone, &r.AnnouncementHour, &r.Locale); err != nil {
+			return nil, err
+		}
+		rosters = append(rosters, r)
+	}
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+	for i := range rosters {
+		members, err := s.db.QueryContext(ctx, `
+select id, organization_id, phone_number, name, role, month, day,
+       case when share_year then year else 0 end, share_year
+from organization_members
+where organization_id = ?
+order by name, id;`, rosters[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		if rosters[i].Members, err = scanRoster(members); err != nil {
+			return nil, err
+		}
+	}
+	return rosters, nil
+}
+
+// API TOKENS
+
+// newAPIToken returns a new token secret and the hash it's stored and looked
+// up by.
+func newAPIToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret := "bdb_" + base64.RawURLEncoding.EncodeToString(b)
+	return secret, hashAPIToken(secret), nil
+}
+
+func hashAPIToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *SQLite) CreateAPIToken(ctx context.Context, phoneNumber string, name string) (APIToken, string, error) {
+	secret, hash, err := newAPIToken()
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	defer tx.Rollback()
+	now := s.clock.Now().UTC().Truncate(time.Second)
+	result, err := tx.ExecContext(ctx, `
+insert into api_tokens (phone_number_id, name, token_hash, created_at)
+select id, ?, ?, ? from phone_numbers where phone_number = ?;`, name, hash, now.Format(time.DateTime), phoneNumber)
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return APIToken{}, "", err
+	} else if n == 0 {
+		return APIToken{}, "", fmt.Errorf("store: no account for %s", phoneNumber)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	t := APIToken{ID: int(id), Name: name, CreatedAt: now}
+	if err := s.audit(ctx, tx, phoneNumber, AuditCreate, "api_token", t.ID, nil, tokenRecord(t)); err != nil {
+		return APIToken{}, "", err
+	}
+	return t, secret, tx.Commit()
+}
+
+func (s *SQLite) ListAPITokens(ctx context.Context, phoneNumber string) ([]APIToken, error) {
+	results, err := s.db.QueryContext(ctx, `
+select api_tokens.id, api_tokens.name, api_tokens.created_at, api_tokens.last_used_at
+from api_tokens
+join phone_numbers on phone_numbers.id = api_tokens.phone_number_id
+where phone_numbers.phone_number = ?
+order by api_tokens.created_at, api_tokens.id;`, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	tokens := []APIToken{}
+	for results.Next() {
+		var t APIToken
+		var lastUsedAt sql.NullTime
+		if err := results.Scan(&t.ID, &t.Name, &t.CreatedAt, &lastUsedAt); err != nil {
+			return nil, err
+		}
+		t.LastUsedAt = lastUsedAt.Time
+		tokens = append(tokens, t)
+	}
+	return tokens, results.Err()
+}
+
+func (s *SQLite) RevokeAPIToken(ctx context.Context, phoneNumber string, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	t := APIToken{ID: id}
+	row := tx.QueryRowContext(ctx, `
+select api_tokens.name
+from api_tokens
+join phone_numbers on phone_numbers.id = api_tokens.phone_number_id
+where api_tokens.id = ? and phone_numbers.phone_number = ?;`, id, phoneNumber)
+	if err := row.Scan(&t.Name); err != nil {
+		return notFound(err)
+	}
+	if _, err := tx.ExecContext(ctx, `delete from api_tokens where id = ?;`, id); err != nil {
+		return err
+	}
+	if err := s.audit(ctx, tx, phoneNumber, AuditDelete, "api_token", id, tokenRecord(t), nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) AuthenticateAPIToken(ctx context.Context, secret string) (string, APIToken, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", APIToken{}, err
+	}
+	defer tx.Rollback()
+	var t APIToken
+	var phoneNumber string
+	row := tx.QueryRowContext(ctx, `
+select api_tokens.id, api_tokens.name, api_tokens.created_at, phone_numbers.phone_number
+from api_tokens
+join phone_numbers on phone_numbers.id = api_tokens.phone_number_id
+where api_tokens.token_hash = ?;`, hashAPIToken(secret))
+	if err := row.Scan(&t.ID, &t.Name, &t.CreatedAt, &phoneNumber); err != nil {
+		return "", APIToken{}, notFound(err)
+	}
+	t.LastUsedAt = s.clock.Now().UTC().Truncate(time.Second)
+	if _, err := tx.ExecContext(ctx, `update api_tokens set last_used_at = ? where id = ?;`, t.LastUsedAt.Format(time.DateTime), t.ID); err != nil {
+		return "", APIToken{}, err
+	}
+	return phoneNumber, t, tx.Commit()
+}
+
+// AUDIT
+
+// eventRecord is what the audit log keeps of an event.
+func eventRecord(e Event) map[string]any {
+	return map[string]any{
+		"list_id":  e.ListID,
+		"name":     e.Name,
+		"type":     e.Type,
+		"label":    e.Label,
+		"calendar": e.Calendar,
+		"month":    e.Month,
+		"day":      e.Day,
+		"year":     e.Year,
+		"notes":    e.Notes,
+	}
+}
+
+// deletionRecord is what the audit log keeps of an account's scheduled
+// deletion.
+func deletionRecord(due time.Time) map[string]any {
+	return map[string]any{"due_at": due.UTC().Format(time.DateTime)}
+}
+
+// tokenRecord is what the audit log keeps of an API token: never its
+// secret.
+func tokenRecord(t APIToken) map[string]any {
+	return map[string]any{"name": t.Name}
+}
+
+// settingsRecord is what the audit log keeps of an account's settings, with
+// a field for each tag's override.
+func settingsRecord(st Settings) map[string]any {
+	record := map[string]any{
+		"notification_days":  st.NotificationDays,
+		"timezone":           st.Timezone,
+		"notification_hour":  st.NotificationHour,
+		"enabled":            st.Enabled,
+		"include_gift_ideas": st.IncludeGiftIdeas,
+		"theme":              st.Theme,
+		"locale":             st.Locale,
+	}
+	for _, t := range st.Tags {
+		if t.NotificationDays.Valid {
+			record["notification_days:"+t.Name] = t.NotificationDays.Int64
+		}
+	}
+	return record
+}
+
+// encodeRecord turns a record into the JSON that's stored, or NULL for none.
+func encodeRecord(record map[string]any) (sql.NullString, error) {
+	if record == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(record)
+	return sql.NullString{String: string(b), Valid: true}, err
+}
+
+func decodeRecord(record sql.NullString) (map[string]any, error) {
+	if !record.Valid {
+		return nil, nil
+	}
+	var m map[string]any
+	return m, json.Unmarshal([]byte(record.String), &m)
+}
+
+// audit records a change made by an account as the actor of ctx. Updates
+// that didn't change anything aren't recorded.
+func (s *SQLite) audit(ctx context.Context, tx *sql.Tx, phoneNumber string, action AuditAction, subject string, subjectID int, before, after map[string]any) error {
+	b, err := encodeRecord(before)
+	if err != nil {
+		return err
+	}
+	a, err := encodeRecord(after)
+	if err != nil {
+		return err
+	}
+	if action == AuditUpdate && b == a {
+		return nil
+	}
+	actor := actorFrom(ctx)
+	_, err = tx.ExecContext(ctx, `
+insert into audit_events (phone_number, source, key_fingerprint, action, subject, subject_id, before, after, created_at)
+values (?, ?, ?, ?, ?, ?, ?, ?, ?);`, phoneNumber, actor.Source, actor.Key, action, subject, subjectID, b, a, s.timestamp())
+	return err
+}
+
+func (s *SQLite) EventHistory(ctx context.Context, phoneNumber string, eventID int) ([]AuditEvent, error) {
+	// Events don't move between lists, and the list is in every record of
+	// one, so the history outlives the event.
+	var listID int
+	row := s.db.QueryRowContext(ctx, `
+select coalesce(json_extract(after, '$.list_id'), json_extract(before, '$.list_id'))
+from audit_events
+where subject = 'event' and subject_id = ?
+order by created_at desc, id desc
+limit 1;`, eventID)
+	if err := row.Scan(&listID); err != nil {
+		return nil, notFound(err)
+	}
+	var role Role
+	row = s.db.QueryRowContext(ctx, `
+select role
+from list_members
+join phone_numbers on phone_numbers.id = list_members.phone_number_id
+where phone_numbers.phone_number = ? and list_members.list_id = ?;`, phoneNumber, listID)
+	if err := row.Scan(&role); err != nil {
+		return nil, notFound(err)
+	}
+	results, err := s.db.QueryContext(ctx, `
+select id, phone_number, source, key_fingerprint, action, subject, before, after, created_at
+from audit_events
+where subject = 'event' and subject_id = ?
+order by created_at desc, id desc;`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	return scanAudit(results)
+}
+
+func (s *SQLite) AccountHistory(ctx context.Context, phoneNumber string) ([]AuditEvent, error) {
+	results, err := s.db.QueryContext(ctx, `
+select id, phone_number, source, key_fingerprint, action, subject, before, after, created_at
+from audit_events
+where phone_number = ? and subject in ('settings', 'deletion', 'api_token')
+order by created_at desc, id desc;`, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	return scanAudit(results)
+}
+
+func scanAudit(results *sql.Rows) ([]AuditEvent, error) {
+	history := []AuditEvent{}
+	for results.Next() {
+		var a AuditEvent
+		var before, after sql.NullString
+		if err := results.Scan(&a.ID, &a.PhoneNumber, &a.Actor.Source, &a.Actor.Key, &a.Action, &a.Subject, &before, &after, &a.At); err != nil {
+			return nil, err
+		}
+		var err error
+		if a.Before, err = decodeRecord(before); err != nil {
+			return nil, err
+		}
+		if a.After, err = decodeRecord(after); err != nil {
+			return nil, err
+		}
+		history = append(history, a)
+	}
+	return history, results.Err()
+}
+
+// DELIVERIES
+
+func (s *SQLite) RecordDelivery(ctx context.Context, d Delivery) error {
+	orgID := sql.NullInt64{Int64: int64(d.OrganizationID), Valid: d.OrganizationID != 0}
+	_, err := s.db.ExecContext(ctx, `
+insert into deliveries (channel, phone_number, organization_id, text, error, created_at)
+values (?, ?, ?, ?, ?, ?);`, d.Channel, d.PhoneNumber, orgID, d.Text, d.Error, s.timestamp())
+	return err
+}
+
+func (s *SQLite) ListDeliveries(ctx context.Context, phoneNumber string) ([]Delivery, error) {
+	results, err := s.db.QueryContext(ctx, `
+select id, channel, phone_number, coalesce(organization_id, 0), text, error, created_at
+from deliveries
+where phone_number = ?
+order by created_at desc, id desc;`, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	deliveries := []Delivery{}
+	for results.Next() {
+		var d Delivery
+		if err := results.Scan(&d.ID, &d.Channel, &d.PhoneNumber, &d.OrganizationID, &d.Text, &d.Error, &d.At); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, results.Err()
+}
+
+func (s *SQLite) PurgeDeliveries(ctx context.Context, before time.Time) (int, error) {
+	result, err := s.db.ExecContext(ctx, `delete from deliveries where created_at < ?;`, before.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// ACCOUNT DELETION
+
+func (s *SQLite) ScheduleDeletion(ctx context.Context, phoneNumber string) (time.Time, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+	var id int
+	var due sql.NullTime
+	row := tx.QueryRowContext(ctx, `select id, deletion_due_at from phone_numbers where phone_number = ?;`, phoneNumber)
+	if err := row.Scan(&id, &due); err != nil {
+		return time.Time{}, notFound(err)
+	}
+	if due.Valid {
+		return due.Time, nil
+	}
+	dueAt := s.clock.Now().UTC().Add(DeletionGracePeriod).Truncate(time.Second)
+	_, err = tx.ExecContext(ctx, `
+update phone_numbers
+set deletion_due_at = ?, updated_at = ?
+where id = ?;`, dueAt.Format(time.DateTime), s.timestamp(), id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := s.audit(ctx, tx, phoneNumber, AuditCreate, "deletion", id, nil, deletionRecord(dueAt)); err != nil {
+		return time.Time{}, err
+	}
+	return dueAt, tx.Commit()
+}
+
+func (s *SQLite) CancelDeletion(ctx context.Context, phoneNumber string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var id int
+	var due sql.NullTime
+	row := tx.QueryRowContext(ctx, `select id, deletion_due_at from phone_numbers where phone_number = ?;`, phoneNumber)
+	if err := row.Scan(&id, &due); err != nil {
+		return notFound(err)
+	}
+	if !due.Valid {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, `
+update phone_numbers
+set deletion_due_at = null, updated_at = ?
+where id = ?;`, s.timestamp(), id)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(ctx, tx, phoneNumber, AuditDelete, "deletion", id, deletionRecord(due.Time), nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) DeletionDue(ctx context.Context, phoneNumber string) (time.Time, error) {
+	var due sql.NullTime
+	row := s.db.QueryRowContext(ctx, `select deletion_due_at from phone_numbers where phone_number = ?;`, phoneNumber)
+	if err := row.Scan(&due); err != nil {
+		return time.Time{}, notFound(err)
+	}
+	return due.Time, nil
+}
+
+func (s *SQLite) ListScheduledDeletions(ctx context.Context) ([]ScheduledDeletion, error) {
+	results, err := s.db.QueryContext(ctx, `
+select phone_numbers.phone_number, phone_numbers.deletion_due_at,
+       (select count(*) from lists where lists.owner_id = phone_numbers.id),
+       (select count(*) from events join lists on lists.id = events.list_id where lists.owner_id = phone_numbers.id)
+from phone_numbers
+where phone_numbers.deletion_due_at is not null
+order by phone_numbers.deletion_due_at, phone_numbers.id;`)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	deletions := []ScheduledDeletion{}
+	for results.Next() {
+		var d ScheduledDeletion
+		if err := results.Scan(&d.PhoneNumber, &d.DueAt, &d.Lists, &d.Events); err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, d)
+	}
+	return deletions, results.Err()
+}
+
+func (s *SQLite) DeleteDueAccounts(ctx context.Context, now time.Time) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	results, err := tx.QueryContext(ctx, `
+select id, phone_number
+from phone_numbers
+where deletion_due_at <= ?
+order by deletion_due_at, id;`, now.UTC().Format(time.DateTime))
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	deleted := []string{}
+	for results.Next() {
+		var id int
+		var phoneNumber string
+		if err := results.Scan(&id, &phoneNumber); err != nil {
+			results.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+		deleted = append(deleted, phoneNumber)
+	}
+	results.Close()
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		if err := deleteAccount(ctx, tx, id, deleted[i]); err != nil {
+			return nil, err
+		}
+	}
+	return deleted, tx.Commit()
+}
+
+// deleteAccount deletes an account and everything that goes with it, as
+// DeleteDueAccounts describes.
+func deleteAccount(ctx context.Context, tx *sql.Tx, id int, phoneNumber string) error {
+	const ownedEvents = `select events.id from events join lists on lists.id = events.list_id where lists.owner_id = ?`
+	const ownedLists = `select id from lists where owner_id = ?`
+	// Foreign keys aren't enforced, so the account's rows go explicitly.
+	for _, query := range []string{
+		`delete from gifts where event_id in (` + ownedEvents + `);`,
+		`delete from event_tags where event_id in (` + ownedEvents + `);`,
+		`delete from audit_events where subject = 'event' and subject_id in (` + ownedEvents + `);`,
+		`delete from events where list_id in (` + ownedLists + `);`,
+		`delete from list_members where list_id in (` + ownedLists + `);`,
+		`delete from list_invitations where list_id in (` + ownedLists + `);`,
+		`delete from birthday_submissions where list_id in (` + ownedLists + `);`,
+		`delete from lists where owner_id = ?;`,
+		`delete from list_members where phone_number_id = ?;`,
+		`delete from event_tags where tag_id in (select id from tags where phone_number_id = ?);`,
+		`delete from tags where phone_number_id = ?;`,
+		`delete from api_tokens where phone_number_id = ?;`,
+		`delete from audit_events where subject = 'settings' and subject_id = ?;`,
+		`delete from phone_numbers where id = ?;`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+	// An organization the account was the only admin of passes to its
+	// longest-standing member, so somebody can still manage it.
+	_, err := tx.ExecContext(ctx, `
+update organization_members
+set role = 'admin'
+where id in (
+    select min(successor.id)
+    from organization_members as leaving
+    join organization_members as successor on successor.organization_id = leaving.organization_id
+    where leaving.phone_number = ?1 and leaving.role = 'admin' and successor.phone_number != ?1
+      and not exists (
+        select 1 from organization_members as admin
+        where admin.organization_id = leaving.organization_id and admin.role = 'admin' and admin.phone_number != ?1
+      )
+    group by leaving.organization_id
+);`, phoneNumber)
+	if err != nil {
+		return err
+	}
+	for _, query := range []string{
+		`delete from list_invitations where phone_number = ?;`,
+		`delete from organization_members where phone_number = ?;`,
+		`delete from deliveries where phone_number = ?;`,
+		`update audit_events set phone_number = '' where phone_number = ?;`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, phoneNumber); err != nil {
+			return err
+		}
+	}
+	// An organization whose last member left has nobody to announce.
+	const unstaffed = `select id from organizations where not exists (select 1 from organization_members where organization_members.organization_id = organizations.id)`
+	if _, err := tx.ExecContext(ctx, `delete from deliveries where organization_id in (`+unstaffed+`);`); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `delete from organizations where id in (`+unstaffed+`);`)
+	return err
+}
+
+// ACCOUNTS
+
+func (s *SQLite) EnsureAccount(ctx context.Context, phoneNumber string, timezone string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO phone_numbers (phone_number, verified, timezone, created_at, updated_at)
+values (?, TRUE, ?, ?, ?);`, phoneNumber, timezone, s.timestamp(), s.timestamp())
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	if _, err := s.createList(ctx, tx, phoneNumber, PersonalList); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) GetSettings(ctx context.Context, phoneNumber string) (Settings, error) {
+	return getSettings(ctx, s.db, phoneNumber)
+}
+
+// querier runs queries on the database or in a transaction.
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func getSettings(ctx context.Context, q querier, phoneNumber string) (Settings, error) {
+	var st Settings
+	row := q.QueryRowContext(ctx, `
+select notification_days, timezone, notification_hour, enabled, include_gift_ideas, theme, locale
+from phone_numbers
+where phone_number = ?;`, phoneNumber)
+	if err := row.Scan(&st.NotificationDays, &st.Timezone, &st.NotificationHour, &st.Enabled, &st.IncludeGiftIdeas, &st.Theme, &st.Locale); err != nil {
+		return Settings{}, notFound(err)
+	}
+	results, err := q.QueryContext(ctx, `
+select tags.name, tags.notification_days
+from tags
+join phone_numbers on phone_numbers.id = tags.phone_number_id
+where phone_numbers.phone_number = ?
+order by tags.name;`, phoneNumber)
+	if err != nil {
+		return Settings{}, err
+	}
+	defer results.Close()
+	for results.Next() {
+		var t TagSetting
+		if err := results.Scan(&t.Name, &t.NotificationDays); err != nil {
+			return Settings{}, err
+		}
+		st.Tags = append(st.Tags, t)
+	}
+	return st, results.Err()
+}
+
+func (s *SQLite) UpdateSettings(ctx context.Context, phoneNumber string, st Settings) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	before, err := getSettings(ctx, tx, phoneNumber)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+update phone_numbers
+set notification_days = ?, timezone = ?, notification_hour = ?, enabled = ?, include_gift_ideas = ?,
+    theme = ?, locale = ?, updated_at = ?
+where phone_number = ?;`, st.NotificationDays, st.Timezone, st.NotificationHour, st.Enabled, st.IncludeGiftIdeas,
+		st.Theme, st.Locale, s.timestamp(), phoneNumber)
+	if err != nil {
+		return err
+	}
+	for _, tag := range st.Tags {
+		_, err = tx.ExecContext(ctx, `
+update tags
+set notification_days = ?, updated_at = ?
+where name = ? and phone_number_id = (select id from phone_numbers where phone_number = ?);`,
+			tag.NotificationDays, s.timestamp(), tag.Name, phoneNumber)
+		if err != nil {
+			return err
+		}
+	}
+	after, err := getSettings(ctx, tx, phoneNumber)
+	if err != nil {
+		return err
+	}
+	var accountID int
+	if err := tx.QueryRowContext(ctx, `select id from phone_numbers where phone_number = ?;`, phoneNumber).Scan(&accountID); err != nil {
+		return err
+	}
+	if err := s.audit(ctx, tx, phoneNumber, AuditUpdate, "settings", accountID, settingsRecord(before), settingsRecord(after)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
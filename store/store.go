@@ -0,0 +1,533 @@
+// Package store is bdaybot's persistence layer. The TUI and the notifier
+// talk to the BirthdayStore and AccountStore interfaces; SQLite backs them in
+// production and Memory stands in for it in tests.
+package store
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/events"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a looked-up record doesn't exist.
+var ErrNotFound = errors.New("store: not found")
+
+// ErrReadOnly is returned when an account tries to change a list it can only
+// view, or to share a list it doesn't own.
+var ErrReadOnly = errors.New("store: not allowed on this list")
+
+// Event is a recurring date the members of a list are reminded about.
+type Event struct {
+	ID int
+	// ListID is the list the event belongs to. Creating an event with no
+	// list puts it on the account's personal list.
+	ListID   int
+	Name     string
+	Type     events.Type
+	Label    string
+	Calendar calendar.System
+	Month    int
+	Day      int
+	Year     int
+	Notes    string
+	// Tags are the tags the account the event was looked up for gave it.
+	// Every member of a shared list tags its events in their own way.
+	Tags []string
+}
+
+// Role is what a member can do with a list.
+type Role string
+
+const (
+	// Owner can edit the list and invite people to it.
+	Owner  Role = "owner"
+	Editor Role = "editor"
+	Viewer Role = "viewer"
+)
+
+// CanEdit reports whether the role allows changing the list's events and
+// their gifts.
+func (r Role) CanEdit() bool {
+	return r == Owner || r == Editor
+}
+
+// PersonalList is the name of the list every account starts with.
+const PersonalList = "Personal"
+
+// List is a list of events shared by its members.
+type List struct {
+	ID   int
+	Name string
+	// Owner is the phone number of the account that owns the list.
+	Owner string
+	// Role is the role of the account the list was looked up for.
+	Role Role
+	// LinkVersion is signed into the list's collection link, and goes up
+	// each time its owner asks for a new link.
+	LinkVersion int
+}
+
+// Member is an account that belongs to a list.
+type Member struct {
+	PhoneNumber string
+	Role        Role
+}
+
+// Invitation asks a phone number to join a list.
+type Invitation struct {
+	ID       int
+	ListID   int
+	ListName string
+	// From is the phone number of the list's owner, who sent it.
+	From string
+	// To is the phone number invited, which may not have an account yet.
+	To   string
+	Role Role
+}
+
+// Submission is a birthday someone entered through a list's collection
+// link, waiting for the list's owner to review it.
+type Submission struct {
+	ID          int
+	ListID      int
+	Name        string
+	Month       int
+	Day         int
+	Year        int
+	SubmittedAt time.Time
+}
+
+// APIToken lets another tool use the API as an account. Its secret is only
+// known when it's created.
+type APIToken struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+	// LastUsedAt is zero until the token is first used.
+	LastUsedAt time.Time
+}
+
+// Source is how an account was connected when it made a change.
+type Source string
+
+const (
+	// SourceSSH is the app over SSH.
+	SourceSSH Source = "ssh"
+	// SourceLocal is the app run in the server's own terminal.
+	SourceLocal Source = "local"
+	// SourceAPI is cmd/server's JSON API.
+	SourceAPI Source = "api"
+)
+
+// Actor says how the account making changes is connected. Methods that
+// change events or settings record it in the audit log, taking it from
+// their context.
+type Actor struct {
+	Source Source
+	// Key identifies the credential used: the SSH key's fingerprint, or
+	// the API token's name and ID, like "Zapier #3".
+	Key string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes are recorded as made by a.
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// actorFrom returns the actor of ctx, which is zero when none was set.
+func actorFrom(ctx context.Context) Actor {
+	a, _ := ctx.Value(actorKey{}).(Actor)
+	return a
+}
+
+// AuditAction is what a change did.
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditEvent records a change to an event, or to an account's settings,
+// scheduled deletion or API tokens.
+type AuditEvent struct {
+	ID int
+	// PhoneNumber is the account that made the change, or empty once that
+	// account has been deleted.
+	PhoneNumber string
+	Actor       Actor
+	Action      AuditAction
+	// Subject is what changed: "event", "settings", "deletion" or
+	// "api_token".
+	Subject string
+	// Before and After are the values the record had, by field. Before is
+	// nil for a create and After for a delete. Tags are left out of events,
+	// as each member has their own.
+	Before map[string]any
+	After  map[string]any
+	At     time.Time
+}
+
+// FieldChange is a value a change set, or cleared.
+type FieldChange struct {
+	Field  string
+	Before any
+	After  any
+}
+
+// Changes lists the fields whose values differ before and after the
+// change, in alphabetical order. A create lists every field without a
+// before, and a delete every field without an after.
+func (a AuditEvent) Changes() []FieldChange {
+	fields := map[string]bool{}
+	for f := range a.Before {
+		fields[f] = true
+	}
+	for f := range a.After {
+		fields[f] = true
+	}
+	var changes []FieldChange
+	for f := range fields {
+		before, after := a.Before[f], a.After[f]
+		if fmt.Sprint(before) != fmt.Sprint(after) || a.Before == nil || a.After == nil {
+			changes = append(changes, FieldChange{f, before, after})
+		}
+	}
+	slices.SortFunc(changes, func(a, b FieldChange) int { return strings.Compare(a.Field, b.Field) })
+	return changes
+}
+
+// ErrNotAdmin is returned when a member of an organization tries something
+// only its admins can do.
+var ErrNotAdmin = errors.New("store: only organization admins can do that")
+
+// OrgRole is what a member can do in an organization.
+type OrgRole string
+
+const (
+	// OrgAdmin manages the roster and where birthdays are announced.
+	OrgAdmin OrgRole = "admin"
+	// OrgMember can enter their own birthday.
+	OrgMember OrgRole = "member"
+)
+
+// Organization is a workplace whose members' birthdays are announced to a
+// team channel.
+type Organization struct {
+	ID   int
+	Name string
+	// WebhookURL is where announcements are posted. Nothing is announced
+	// without one.
+	WebhookURL       string
+	Timezone         string
+	AnnouncementHour int
+	// Role is the role of the account the organization was looked up for.
+	Role OrgRole
+}
+
+// RosterEntry is a member of an organization and their birthday.
+type RosterEntry struct {
+	ID             int
+	OrganizationID int
+	// PhoneNumber is the number the member signs in with, which may not
+	// have an account yet.
+	PhoneNumber string
+	Name        string
+	Role        OrgRole
+	// Month and Day are 0 until the birthday is entered.
+	Month int
+	Day   int
+	// Year is 0 when it isn't known, or when the member doesn't share it
+	// and someone else looked the entry up.
+	Year      int
+	ShareYear bool
+}
+
+// Roster is an organization with its members, for announcing their
+// birthdays.
+type Roster struct {
+	Organization
+	Members []RosterEntry
+	// Locale is the locale of the organization's first admin to sign up,
+	// which announcements are written in.
+	Locale string
+}
+
+var GiftStatuses = []string{"idea", "bought", "given"}
+
+// Gift is a gift idea for an event, or a record of one bought or given.
+type Gift struct {
+	ID         int
+	EventID    int
+	Idea       string
+	Status     string
+	Year       sql.NullInt64
+	PriceCents sql.NullInt64
+	Link       string
+}
+
+// TagSetting is a tag's notification override. A null NotificationDays means
+// the tag falls back to the account-wide setting.
+type TagSetting struct {
+	Name             string
+	NotificationDays sql.NullInt64
+}
+
+// Settings are an account's preferences.
+type Settings struct {
+	NotificationDays int
+	Timezone         string
+	NotificationHour int
+	Enabled          bool
+	IncludeGiftIdeas bool
+	// Theme names the color theme the account picked for the TUI.
+	Theme string
+	// Locale is the language and date format of the TUI and of reminder
+	// messages, e.g. "en-US" or "es".
+	Locale string
+	Tags   []TagSetting
+}
+
+// Candidate is an event with one of the enabled accounts that are members
+// of its list, and what the notifier needs to decide whether to send that
+// account a reminder for it today.
+type Candidate struct {
+	Event
+	PhoneNumber      string
+	Timezone         string
+	NotificationHour int
+	IncludeGiftIdeas bool
+	Locale           string
+	// NotificationDays is the event's reminder window: the most generous
+	// of its tags' overrides, or the account's setting when none apply.
+	NotificationDays int
+}
+
+type BirthdayStore interface {
+	// ListEvents returns the events of every list an account is a member
+	// of, in the order they were added.
+	ListEvents(ctx context.Context, phoneNumber string) ([]Event, error)
+	// GetEvent returns an event with the tags phoneNumber gave it. Events
+	// on lists the account isn't a member of are reported missing.
+	GetEvent(ctx context.Context, phoneNumber string, id int) (Event, error)
+	// CreateEvent adds an event to e.ListID, creating any of its tags the
+	// account doesn't have yet, and returns its ID. It returns ErrReadOnly
+	// when the account can't edit the list.
+	CreateEvent(ctx context.Context, phoneNumber string, e Event) (int, error)
+	// UpdateEvent saves e over the event with e.ID, replacing the account's
+	// tags on it. The event stays on its list. Like GetEvent, it reports
+	// events on other accounts' lists missing, and it returns ErrReadOnly
+	// when the account can't edit the event's list.
+	UpdateEvent(ctx context.Context, phoneNumber string, e Event) error
+	// DeleteEvent deletes an event along with its gifts and every member's
+	// tags on it, checking the account's role as UpdateEvent does.
+	DeleteEvent(ctx context.Context, phoneNumber string, id int) error
+	// ListTags returns the names of an account's tags, sorted.
+	ListTags(ctx context.Context, phoneNumber string) ([]string, error)
+
+	// ListGifts returns an event's gifts, undated ones first, then the
+	// most recent. Like events, gifts on lists the account isn't a member
+	// of are reported missing, and the gift methods that write return
+	// ErrReadOnly when the account can't edit the event's list.
+	ListGifts(ctx context.Context, phoneNumber string, eventID int) ([]Gift, error)
+	GetGift(ctx context.Context, phoneNumber string, id int) (Gift, error)
+	CreateGift(ctx context.Context, phoneNumber string, g Gift) (int, error)
+	// UpdateGift saves g over the gift with g.ID. The gift stays on its
+	// event.
+	UpdateGift(ctx context.Context, phoneNumber string, g Gift) error
+	DeleteGift(ctx context.Context, phoneNumber string, id int) error
+
+	// ListCandidates returns every event once for each enabled member of
+	// its list, leaving out accounts scheduled to be deleted.
+	ListCandidates(ctx context.Context) ([]Candidate, error)
+}
+
+type ListStore interface {
+	// ListLists returns the lists an account is a member of, its personal
+	// list first.
+	ListLists(ctx context.Context, phoneNumber string) ([]List, error)
+	// CreateList creates a list owned by an account and returns its ID.
+	CreateList(ctx context.Context, phoneNumber string, name string) (int, error)
+	// ListMembers returns a list's members, its owner first. Only members
+	// can see them; anyone else gets ErrNotFound.
+	ListMembers(ctx context.Context, phoneNumber string, listID int) ([]Member, error)
+	// Invite invites a phone number to join a list as an editor or viewer,
+	// replacing any invitation it already has to the list. Only the list's
+	// owner can invite; anyone else gets ErrReadOnly.
+	Invite(ctx context.Context, phoneNumber string, listID int, invitee string, role Role) error
+	// ListInvitations returns the invitations waiting for a phone number.
+	ListInvitations(ctx context.Context, phoneNumber string) ([]Invitation, error)
+	// RespondToInvitation accepts or declines one of an account's
+	// invitations. Either way the invitation is used up.
+	RespondToInvitation(ctx context.Context, phoneNumber string, id int, accept bool) error
+}
+
+type SubmissionStore interface {
+	// GetList returns a list for someone who isn't on it, such as a person
+	// submitting their birthday, so Role is left empty.
+	GetList(ctx context.Context, listID int) (List, error)
+	// SubmitBirthday queues sub for review by its list's owner and returns
+	// its ID.
+	SubmitBirthday(ctx context.Context, sub Submission) (int, error)
+	// ListSubmissions returns the submissions waiting on a list, oldest
+	// first. Only the list's owner can see them; anyone else gets
+	// ErrReadOnly.
+	ListSubmissions(ctx context.Context, phoneNumber string, listID int) ([]Submission, error)
+	// ReviewSubmission approves a submission, adding it to its list as a
+	// birthday, or rejects it. Either way it leaves the queue. Only the
+	// list's owner can review.
+	ReviewSubmission(ctx context.Context, phoneNumber string, id int, approve bool) error
+	// RegenerateLink bumps a list's link version, so its old collection
+	// link stops working, and returns the new version. Only the list's
+	// owner can.
+	RegenerateLink(ctx context.Context, phoneNumber string, listID int) (int, error)
+}
+
+type OrgStore interface {
+	// ListOrganizations returns the organizations an account is on the
+	// roster of.
+	ListOrganizations(ctx context.Context, phoneNumber string) ([]Organization, error)
+	// CreateOrganization creates an organization with the account as its
+	// first admin and returns its ID.
+	CreateOrganization(ctx context.Context, phoneNumber string, o Organization) (int, error)
+	// UpdateOrganization saves the name and announcement settings of the
+	// organization with o.ID. Only admins can.
+	UpdateOrganization(ctx context.Context, phoneNumber string, o Organization) error
+	// ListRoster returns the members of an organization the account is on,
+	// by name. Years are left out where members don't share them, except
+	// the account's own.
+	ListRoster(ctx context.Context, phoneNumber string, orgID int) ([]RosterEntry, error)
+	// AddToRoster adds e to its organization and returns its ID. Only
+	// admins can.
+	AddToRoster(ctx context.Context, phoneNumber string, e RosterEntry) (int, error)
+	// UpdateRosterEntry saves e over the entry with e.ID. Admins can update
+	// anyone and members only themselves. Nobody can change their own role,
+	// and only members themselves choose whether their year is shared.
+	UpdateRosterEntry(ctx context.Context, phoneNumber string, e RosterEntry) error
+	// RemoveFromRoster removes someone else from an organization. Only
+	// admins can.
+	RemoveFromRoster(ctx context.Context, phoneNumber string, id int) error
+	// ListRosters returns every organization with a webhook and its roster,
+	// without the years members don't share.
+	ListRosters(ctx context.Context) ([]Roster, error)
+}
+
+type TokenStore interface {
+	// CreateAPIToken creates a token for an account and returns it with its
+	// secret, which isn't stored and can't be looked up again.
+	CreateAPIToken(ctx context.Context, phoneNumber string, name string) (APIToken, string, error)
+	// ListAPITokens returns an account's tokens, oldest first.
+	ListAPITokens(ctx context.Context, phoneNumber string) ([]APIToken, error)
+	// RevokeAPIToken deletes one of an account's tokens.
+	RevokeAPIToken(ctx context.Context, phoneNumber string, id int) error
+	// AuthenticateAPIToken returns the phone number of the account a secret
+	// belongs to, with its token, and marks the token used. Unknown and
+	// revoked secrets get ErrNotFound.
+	AuthenticateAPIToken(ctx context.Context, secret string) (string, APIToken, error)
+}
+
+type AuditStore interface {
+	// EventHistory returns the changes made to an event, newest first,
+	// including once it's been deleted. Only members of the event's list
+	// can see them.
+	EventHistory(ctx context.Context, phoneNumber string, eventID int) ([]AuditEvent, error)
+	// AccountHistory returns the changes an account made to its settings,
+	// its scheduled deletion and its API tokens, newest first.
+	AccountHistory(ctx context.Context, phoneNumber string) ([]AuditEvent, error)
+}
+
+// Delivery is a reminder or announcement that the notifier sent, or tried
+// to send.
+type Delivery struct {
+	ID int
+	// Channel is "sms" for reminders, texted to PhoneNumber, or "webhook"
+	// for announcements, posted to OrganizationID's team channel.
+	Channel        string
+	PhoneNumber    string
+	OrganizationID int
+	Text           string
+	// Error is why it couldn't be sent, or empty when it was.
+	Error string
+	At    time.Time
+}
+
+type DeliveryStore interface {
+	// RecordDelivery adds a delivery to the log, at the store's time.
+	RecordDelivery(ctx context.Context, d Delivery) error
+	// ListDeliveries returns the deliveries to a phone number, newest
+	// first.
+	ListDeliveries(ctx context.Context, phoneNumber string) ([]Delivery, error)
+	// PurgeDeliveries deletes the deliveries logged before a time, and
+	// returns how many there were.
+	PurgeDeliveries(ctx context.Context, before time.Time) (int, error)
+}
+
+// DeletionGracePeriod is how long an account that asked to be deleted has
+// to change its mind.
+const DeletionGracePeriod = 14 * 24 * time.Hour
+
+// ScheduledDeletion is an account waiting out its grace period before it's
+// deleted, and how much goes with it.
+type ScheduledDeletion struct {
+	PhoneNumber string
+	DueAt       time.Time
+	// Lists and Events count the lists the account owns and their events,
+	// which are deleted along with it.
+	Lists  int
+	Events int
+}
+
+type DeletionStore interface {
+	// ScheduleDeletion schedules an account to be deleted once
+	// DeletionGracePeriod has passed, and returns when it's due. An
+	// account already scheduled keeps its original date.
+	ScheduleDeletion(ctx context.Context, phoneNumber string) (time.Time, error)
+	// CancelDeletion keeps an account that was scheduled to be deleted.
+	CancelDeletion(ctx context.Context, phoneNumber string) error
+	// DeletionDue returns when an account is due to be deleted, or the zero
+	// time when it isn't scheduled to be.
+	DeletionDue(ctx context.Context, phoneNumber string) (time.Time, error)
+	// ListScheduledDeletions returns the accounts scheduled to be deleted,
+	// soonest first.
+	ListScheduledDeletions(ctx context.Context) ([]ScheduledDeletion, error)
+	// DeleteDueAccounts deletes the accounts whose deletion is due at now,
+	// and returns their phone numbers. Each goes with the lists it owns and
+	// their events, its tags, tokens, memberships, invitations and roster
+	// entries, its deliveries, and the audit log of its settings. Changes
+	// it made to other lists' events stay in their history, without its
+	// phone number. Organizations it was the only admin of pass to their
+	// longest-standing member.
+	DeleteDueAccounts(ctx context.Context, now time.Time) ([]string, error)
+}
+
+type AccountStore interface {
+	// EnsureAccount registers a phone number with the given timezone and
+	// gives it a personal list. An existing account is left as is.
+	EnsureAccount(ctx context.Context, phoneNumber string, timezone string) error
+	GetSettings(ctx context.Context, phoneNumber string) (Settings, error)
+	// UpdateSettings saves an account's settings, including the overrides
+	// of the tags listed in s.Tags.
+	UpdateSettings(ctx context.Context, phoneNumber string, s Settings) error
+}
+
+// Store is everything bdaybot persists.
+type Store interface {
+	BirthdayStore
+	ListStore
+	SubmissionStore
+	OrgStore
+	TokenStore
+	AuditStore
+	DeliveryStore
+	DeletionStore
+	AccountStore
+}
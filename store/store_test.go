@@ -0,0 +1,920 @@
+package store
+
+import (
+	"ashwindharne/bdaybot/calendar"
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/events"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// migratedSQLite returns a SQLite store with every up migration applied.
+func migratedSQLite(t *testing.T) *SQLite {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	files, err := filepath.Glob("../migrations/*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	for _, f := range files {
+		migration, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.db.Exec(string(migration)); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+	}
+	return s
+}
+
+// TestStores runs the same checks against both implementations, so the fake
+// can be trusted to behave like the database.
+func TestStores(t *testing.T) {
+	stores := map[string]func(*testing.T) Store{
+		"memory": func(*testing.T) Store { return NewMemory() },
+		"sqlite": func(t *testing.T) Store { return migratedSQLite(t) },
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Run("events", func(t *testing.T) { testEvents(t, open(t)) })
+			t.Run("gifts", func(t *testing.T) { testGifts(t, open(t)) })
+			t.Run("settings", func(t *testing.T) { testSettings(t, open(t)) })
+			t.Run("candidates", func(t *testing.T) { testCandidates(t, open(t)) })
+			t.Run("lists", func(t *testing.T) { testLists(t, open(t)) })
+			t.Run("submissions", func(t *testing.T) { testSubmissions(t, open(t)) })
+			t.Run("organizations", func(t *testing.T) { testOrganizations(t, open(t)) })
+			t.Run("tokens", func(t *testing.T) { testTokens(t, open(t)) })
+			t.Run("audit", func(t *testing.T) { testAudit(t, open(t)) })
+			t.Run("deletion", func(t *testing.T) { testDeletion(t, open(t)) })
+		})
+	}
+}
+
+const phoneNumber = "+15555550100"
+
+func testEvents(t *testing.T, s Store) {
+	ctx := context.Background()
+	if err := s.EnsureAccount(ctx, phoneNumber, "America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	e := Event{
+		Name:     "Ann",
+		Type:     events.Birthday,
+		Calendar: calendar.Gregorian,
+		Month:    3,
+		Day:      14,
+		Year:     1990,
+		Tags:     []string{"family", "close"},
+	}
+	id, err := s.CreateEvent(ctx, phoneNumber, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetEvent(ctx, phoneNumber, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Ann" || got.Month != 3 || !slices.Equal(got.Tags, []string{"close", "family"}) {
+		t.Errorf("GetEvent = %+v", got)
+	}
+
+	got.Name = "Annie"
+	got.Tags = []string{"friends", "friends"}
+	if err := s.UpdateEvent(ctx, phoneNumber, got); err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.ListEvents(ctx, phoneNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "Annie" || !slices.Equal(list[0].Tags, []string{"friends"}) {
+		t.Errorf("ListEvents = %+v", list)
+	}
+	tags, err := s.ListTags(ctx, phoneNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tags, []string{"close", "family", "friends"}) {
+		t.Errorf("ListTags = %v", tags)
+	}
+	if _, err := s.GetEvent(ctx, phoneNumber, id+100); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetEvent of a missing event: %v, want ErrNotFound", err)
+	}
+}
+
+func testGifts(t *testing.T, s Store) {
+	ctx := context.Background()
+	s.EnsureAccount(ctx, phoneNumber, "UTC")
+	eventId, err := s.CreateEvent(ctx, phoneNumber, Event{Name: "Bob", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 1, Day: 2, Year: 1980})
+	if err != nil {
+		t.Fatal(err)
+	}
+	year := func(y int64) sql.NullInt64 { return sql.NullInt64{Int64: y, Valid: true} }
+	for _, g := range []Gift{
+		{EventID: eventId, Idea: "Scarf", Status: "given", Year: year(2023)},
+		{EventID: eventId, Idea: "Book", Status: "idea"},
+		{EventID: eventId, Idea: "Watch", Status: "given", Year: year(2024)},
+	} {
+		if _, err := s.CreateGift(ctx, phoneNumber, g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gifts, err := s.ListGifts(ctx, phoneNumber, eventId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ideas []string
+	for _, g := range gifts {
+		ideas = append(ideas, g.Idea)
+	}
+	if !slices.Equal(ideas, []string{"Book", "Watch", "Scarf"}) {
+		t.Errorf("ListGifts order = %v", ideas)
+	}
+
+	book := gifts[0]
+	book.Status = "bought"
+	if err := s.UpdateGift(ctx, phoneNumber, book); err != nil {
+		t.Fatal(err)
+	}
+	if g, err := s.GetGift(ctx, phoneNumber, book.ID); err != nil || g.Status != "bought" {
+		t.Errorf("GetGift after update = %+v, %v", g, err)
+	}
+	if err := s.DeleteGift(ctx, phoneNumber, book.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetGift(ctx, phoneNumber, book.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetGift after delete: %v, want ErrNotFound", err)
+	}
+
+	// Deleting the event takes its gifts with it.
+	if err := s.DeleteEvent(ctx, phoneNumber, eventId); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetEvent(ctx, phoneNumber, eventId); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetEvent after delete: %v, want ErrNotFound", err)
+	}
+	if gifts, _ := s.ListGifts(ctx, phoneNumber, eventId); len(gifts) != 0 {
+		t.Errorf("gifts of a deleted event = %+v", gifts)
+	}
+	if err := s.DeleteEvent(ctx, phoneNumber, eventId); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting twice: %v, want ErrNotFound", err)
+	}
+}
+
+func testSettings(t *testing.T, s Store) {
+	ctx := context.Background()
+	s.EnsureAccount(ctx, phoneNumber, "Europe/London")
+	// A second registration doesn't reset anything.
+	s.EnsureAccount(ctx, phoneNumber, "Asia/Kolkata")
+	s.CreateEvent(ctx, phoneNumber, Event{Name: "Cy", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 5, Day: 6, Year: 2000, Tags: []string{"work"}})
+
+	got, err := s.GetSettings(ctx, phoneNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Timezone != "Europe/London" || got.NotificationDays != 14 || !got.Enabled || len(got.Tags) != 1 {
+		t.Errorf("GetSettings of a new account = %+v", got)
+	}
+
+	if got.Theme != "default" || got.Locale != "en-US" {
+		t.Errorf("theme and locale of a new account = %q, %q, want default, en-US", got.Theme, got.Locale)
+	}
+	got.NotificationDays = 3
+	got.NotificationHour = 18
+	got.Theme = "dracula"
+	got.Locale = "es"
+	got.Tags[0].NotificationDays = sql.NullInt64{Int64: 1, Valid: true}
+	if err := s.UpdateSettings(ctx, phoneNumber, got); err != nil {
+		t.Fatal(err)
+	}
+	again, err := s.GetSettings(ctx, phoneNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.NotificationDays != 3 || again.NotificationHour != 18 || again.Theme != "dracula" || again.Locale != "es" || again.Tags[0].NotificationDays.Int64 != 1 {
+		t.Errorf("GetSettings after update = %+v", again)
+	}
+	if _, err := s.GetSettings(ctx, "+15555550199"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSettings of a missing account: %v, want ErrNotFound", err)
+	}
+}
+
+func testCandidates(t *testing.T, s Store) {
+	ctx := context.Background()
+	s.EnsureAccount(ctx, phoneNumber, "UTC")
+	s.EnsureAccount(ctx, "+15555550101", "UTC")
+	s.CreateEvent(ctx, phoneNumber, Event{Name: "Di", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 7, Day: 8, Year: 1970, Tags: []string{"a", "b"}})
+	s.CreateEvent(ctx, phoneNumber, Event{Name: "Ed", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 9, Day: 10, Year: 1970})
+	s.CreateEvent(ctx, "+15555550101", Event{Name: "Flo", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 11, Day: 12, Year: 1970})
+
+	settings, _ := s.GetSettings(ctx, phoneNumber)
+	settings.Tags = []TagSetting{
+		{Name: "a", NotificationDays: sql.NullInt64{Int64: 30, Valid: true}},
+		{Name: "b", NotificationDays: sql.NullInt64{Int64: 2, Valid: true}},
+	}
+	s.UpdateSettings(ctx, phoneNumber, settings)
+	disabled, _ := s.GetSettings(ctx, "+15555550101")
+	disabled.Enabled = false
+	s.UpdateSettings(ctx, "+15555550101", disabled)
+
+	candidates, err := s.ListCandidates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	windows := map[string]int{}
+	for _, c := range candidates {
+		windows[c.Name] = c.NotificationDays
+	}
+	if len(windows) != 2 || windows["Di"] != 30 || windows["Ed"] != 14 {
+		t.Errorf("candidate windows = %v, want Di: 30, Ed: 14", windows)
+	}
+}
+
+func testLists(t *testing.T, s Store) {
+	ctx := context.Background()
+	const editor, viewer = "+15555550101", "+15555550102"
+	s.EnsureAccount(ctx, phoneNumber, "UTC")
+	s.EnsureAccount(ctx, editor, "UTC")
+
+	lists, err := s.ListLists(ctx, phoneNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 1 || lists[0].Name != PersonalList || lists[0].Role != Owner {
+		t.Fatalf("lists of a new account = %+v, want its personal list", lists)
+	}
+	family, err := s.CreateList(ctx, phoneNumber, "Family")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.CreateEvent(ctx, phoneNumber, Event{
+		ListID: family, Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 14, Tags: []string{"family"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Invite(ctx, editor, family, viewer, Viewer); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("invite by a non-owner: %v, want ErrReadOnly", err)
+	}
+	if err := s.Invite(ctx, phoneNumber, family, editor, Editor); err != nil {
+		t.Fatal(err)
+	}
+	// Invitations wait for numbers that haven't signed up yet.
+	if err := s.Invite(ctx, phoneNumber, family, viewer, Viewer); err != nil {
+		t.Fatal(err)
+	}
+	s.EnsureAccount(ctx, viewer, "UTC")
+	for _, invitee := range []string{editor, viewer} {
+		invitations, err := s.ListInvitations(ctx, invitee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(invitations) != 1 || invitations[0].ListName != "Family" || invitations[0].From != phoneNumber {
+			t.Fatalf("invitations of %s = %+v", invitee, invitations)
+		}
+		if err := s.RespondToInvitation(ctx, invitee, invitations[0].ID, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	members, err := s.ListMembers(ctx, phoneNumber, family)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Member{{phoneNumber, Owner}, {editor, Editor}, {viewer, Viewer}}
+	if !slices.Equal(members, want) {
+		t.Errorf("members = %+v, want %+v", members, want)
+	}
+	if lists, _ := s.ListLists(ctx, editor); len(lists) != 2 || lists[0].Name != PersonalList || lists[1].Role != Editor {
+		t.Errorf("lists of the editor = %+v, want their personal list then Family", lists)
+	}
+
+	// Each member tags shared events in their own way.
+	ann, err := s.GetEvent(ctx, editor, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ann.Tags) != 0 {
+		t.Errorf("the owner's tags show to the editor: %v", ann.Tags)
+	}
+	ann.Notes = "Likes tea"
+	ann.Tags = []string{"in-laws"}
+	if err := s.UpdateEvent(ctx, editor, ann); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.GetEvent(ctx, phoneNumber, id); got.Notes != "Likes tea" || !slices.Equal(got.Tags, []string{"family"}) {
+		t.Errorf("owner's view after the editor's update = %+v", got)
+	}
+	if list, _ := s.ListEvents(ctx, editor); len(list) != 1 || !slices.Equal(list[0].Tags, []string{"in-laws"}) {
+		t.Errorf("ListEvents of the editor = %+v", list)
+	}
+
+	if err := s.UpdateEvent(ctx, viewer, ann); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("update by a viewer: %v, want ErrReadOnly", err)
+	}
+	if err := s.DeleteEvent(ctx, viewer, id); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("delete by a viewer: %v, want ErrReadOnly", err)
+	}
+	if _, err := s.CreateEvent(ctx, viewer, Event{ListID: family, Name: "Bo", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 1, Day: 1}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("create by a viewer: %v, want ErrReadOnly", err)
+	}
+
+	// Gifts follow the roles of their event's list.
+	gift, err := s.CreateGift(ctx, editor, Gift{EventID: id, Idea: "Tea", Status: "idea"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gifts, err := s.ListGifts(ctx, viewer, id); err != nil || len(gifts) != 1 {
+		t.Errorf("gifts for a viewer = %+v, %v, want the editor's", gifts, err)
+	}
+	if _, err := s.CreateGift(ctx, viewer, Gift{EventID: id, Idea: "Mug", Status: "idea"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("gift created by a viewer: %v, want ErrReadOnly", err)
+	}
+	if err := s.UpdateGift(ctx, viewer, Gift{ID: gift, Idea: "Mug", Status: "idea"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("gift updated by a viewer: %v, want ErrReadOnly", err)
+	}
+	if err := s.DeleteGift(ctx, viewer, gift); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("gift deleted by a viewer: %v, want ErrReadOnly", err)
+	}
+	const outsider = "+15555550103"
+	s.EnsureAccount(ctx, outsider, "UTC")
+	if _, err := s.GetEvent(ctx, outsider, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("event for an outsider: %v, want ErrNotFound", err)
+	}
+	if _, err := s.ListGifts(ctx, outsider, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("gifts for an outsider: %v, want ErrNotFound", err)
+	}
+	if _, err := s.GetGift(ctx, outsider, gift); !errors.Is(err, ErrNotFound) {
+		t.Errorf("gift for an outsider: %v, want ErrNotFound", err)
+	}
+	if _, err := s.CreateGift(ctx, outsider, Gift{EventID: id, Idea: "Mug", Status: "idea"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("gift created by an outsider: %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteGift(ctx, outsider, gift); !errors.Is(err, ErrNotFound) {
+		t.Errorf("gift deleted by an outsider: %v, want ErrNotFound", err)
+	}
+	if err := s.UpdateEvent(ctx, outsider, Event{ID: id, Name: "Ann"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("update by an outsider: %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteEvent(ctx, outsider, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("delete by an outsider: %v, want ErrNotFound", err)
+	}
+	if _, err := s.ListMembers(ctx, outsider, family); !errors.Is(err, ErrNotFound) {
+		t.Errorf("members for an outsider: %v, want ErrNotFound", err)
+	}
+
+	// A declined invitation is used up and leaves the role as it was.
+	s.Invite(ctx, phoneNumber, family, viewer, Editor)
+	invitations, _ := s.ListInvitations(ctx, viewer)
+	if err := s.RespondToInvitation(ctx, viewer, invitations[0].ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if invitations, _ := s.ListInvitations(ctx, viewer); len(invitations) != 0 {
+		t.Errorf("invitations after declining = %+v", invitations)
+	}
+	if members, _ := s.ListMembers(ctx, phoneNumber, family); members[2].Role != Viewer {
+		t.Errorf("role after declining = %s, want viewer", members[2].Role)
+	}
+
+	candidates, err := s.ListCandidates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recipients []string
+	for _, c := range candidates {
+		recipients = append(recipients, c.PhoneNumber)
+	}
+	if !slices.Equal(recipients, []string{phoneNumber, editor, viewer}) {
+		t.Errorf("candidates go to %v, want every member", recipients)
+	}
+}
+
+func testSubmissions(t *testing.T, s Store) {
+	ctx := context.Background()
+	const editor = "+15555550101"
+	s.EnsureAccount(ctx, phoneNumber, "UTC")
+	s.EnsureAccount(ctx, editor, "UTC")
+	family, _ := s.CreateList(ctx, phoneNumber, "Family")
+	s.Invite(ctx, phoneNumber, family, editor, Editor)
+	invitations, _ := s.ListInvitations(ctx, editor)
+	s.RespondToInvitation(ctx, editor, invitations[0].ID, true)
+
+	if l, err := s.GetList(ctx, family); err != nil || l.Name != "Family" || l.Owner != phoneNumber || l.LinkVersion != 0 {
+		t.Errorf("GetList = %+v, %v", l, err)
+	}
+	if _, err := s.RegenerateLink(ctx, editor, family); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("link regenerated by an editor: %v, want ErrReadOnly", err)
+	}
+	if version, err := s.RegenerateLink(ctx, phoneNumber, family); err != nil || version != 1 {
+		t.Errorf("RegenerateLink = %d, %v, want 1", version, err)
+	}
+	if l, _ := s.GetList(ctx, family); l.LinkVersion != 1 {
+		t.Errorf("link version after regenerating = %d, want 1", l.LinkVersion)
+	}
+	if lists, _ := s.ListLists(ctx, editor); len(lists) != 2 || lists[1].LinkVersion != 1 {
+		t.Errorf("lists of the editor = %+v, want Family at link version 1", lists)
+	}
+	if _, err := s.SubmitBirthday(ctx, Submission{ListID: 999, Name: "Eve", Month: 1, Day: 1, Year: 2000}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("submitting to a missing list: %v, want ErrNotFound", err)
+	}
+	bob, err := s.SubmitBirthday(ctx, Submission{ListID: family, Name: "Bob", Month: 12, Day: 1, Year: 1985})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cat, _ := s.SubmitBirthday(ctx, Submission{ListID: family, Name: "Cat", Month: 7, Day: 4, Year: 1990})
+
+	// Editors can change the list, but reviewing is left to its owner.
+	if _, err := s.ListSubmissions(ctx, editor, family); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("submissions listed by an editor: %v, want ErrReadOnly", err)
+	}
+	if err := s.ReviewSubmission(ctx, editor, bob, true); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("review by an editor: %v, want ErrReadOnly", err)
+	}
+	submissions, err := s.ListSubmissions(ctx, phoneNumber, family)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submissions) != 2 || submissions[0].Name != "Bob" || submissions[1].Name != "Cat" || submissions[0].SubmittedAt.IsZero() {
+		t.Fatalf("submissions = %+v, want Bob then Cat", submissions)
+	}
+
+	if err := s.ReviewSubmission(ctx, phoneNumber, bob, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReviewSubmission(ctx, phoneNumber, cat, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReviewSubmission(ctx, phoneNumber, cat, true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("reviewing twice: %v, want ErrNotFound", err)
+	}
+	if submissions, _ := s.ListSubmissions(ctx, phoneNumber, family); len(submissions) != 0 {
+		t.Errorf("submissions after review = %+v, want none", submissions)
+	}
+	list, _ := s.ListEvents(ctx, editor)
+	if len(list) != 1 {
+		t.Fatalf("events after review = %+v, want only Bob", list)
+	}
+	if got := list[0]; got.ListID != family || got.Name != "Bob" || got.Type != events.Birthday || got.Calendar != calendar.Gregorian ||
+		got.Month != 12 || got.Day != 1 || got.Year != 1985 {
+		t.Errorf("approved event = %+v", got)
+	}
+}
+
+func testOrganizations(t *testing.T, s Store) {
+	ctx := context.Background()
+	const member, outsider = "+15555550101", "+15555550102"
+	orgID, err := s.CreateOrganization(ctx, phoneNumber, Organization{Name: "Acme", Timezone: "UTC", AnnouncementHour: 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orgs, _ := s.ListOrganizations(ctx, phoneNumber); len(orgs) != 1 || orgs[0].Name != "Acme" || orgs[0].Role != OrgAdmin {
+		t.Fatalf("organizations of the creator = %+v, want Acme as admin", orgs)
+	}
+	id, err := s.AddToRoster(ctx, phoneNumber, RosterEntry{
+		OrganizationID: orgID, PhoneNumber: member, Name: "Bo", Role: OrgMember, Month: 3, Day: 14, Year: 1990, ShareYear: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddToRoster(ctx, member, RosterEntry{OrganizationID: orgID, PhoneNumber: outsider, Role: OrgMember}); !errors.Is(err, ErrNotAdmin) {
+		t.Errorf("add by a member: %v, want ErrNotAdmin", err)
+	}
+	if _, err := s.ListRoster(ctx, outsider, orgID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("roster for an outsider: %v, want ErrNotFound", err)
+	}
+	if err := s.UpdateOrganization(ctx, member, Organization{ID: orgID, Name: "Mine"}); !errors.Is(err, ErrNotAdmin) {
+		t.Errorf("organization update by a member: %v, want ErrNotAdmin", err)
+	}
+
+	// Members enter their own birthday and choose whether to share the
+	// year, but can't promote themselves.
+	bo := RosterEntry{ID: id, Name: "Bo", Role: OrgAdmin, Month: 3, Day: 15, Year: 1991, ShareYear: false}
+	if err := s.UpdateRosterEntry(ctx, member, bo); err != nil {
+		t.Fatal(err)
+	}
+	roster, err := s.ListRoster(ctx, phoneNumber, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roster) != 2 || roster[0].Name != "" || roster[1].Day != 15 || roster[1].Year != 0 || roster[1].Role != OrgMember {
+		t.Fatalf("admin's roster = %+v, want Bo's new day without the year, still a member", roster)
+	}
+	if own, _ := s.ListRoster(ctx, member, orgID); own[1].Year != 1991 {
+		t.Errorf("member's own year = %d, want 1991", own[1].Year)
+	}
+	// An admin editing the entry can't see the year, so doesn't clear it.
+	roster[1].Name = "Bob"
+	if err := s.UpdateRosterEntry(ctx, phoneNumber, roster[1]); err != nil {
+		t.Fatal(err)
+	}
+	if own, _ := s.ListRoster(ctx, member, orgID); own[1].Name != "Bob" || own[1].Year != 1991 || own[1].ShareYear {
+		t.Errorf("entry after the admin's edit = %+v", own[1])
+	}
+	if err := s.UpdateRosterEntry(ctx, member, RosterEntry{ID: roster[0].ID, Name: "Boss"}); !errors.Is(err, ErrNotAdmin) {
+		t.Errorf("member editing someone else: %v, want ErrNotAdmin", err)
+	}
+
+	if rosters, _ := s.ListRosters(ctx); len(rosters) != 0 {
+		t.Errorf("rosters without a webhook = %+v, want none", rosters)
+	}
+	s.UpdateOrganization(ctx, phoneNumber, Organization{ID: orgID, Name: "Acme", WebhookURL: "https://hooks.example.com/1", Timezone: "UTC", AnnouncementHour: 10})
+	rosters, err := s.ListRosters(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rosters) != 1 || rosters[0].AnnouncementHour != 10 || len(rosters[0].Members) != 2 || rosters[0].Members[1].Year != 0 || rosters[0].Locale != "en-US" {
+		t.Errorf("rosters = %+v", rosters)
+	}
+	s.EnsureAccount(ctx, phoneNumber, "UTC")
+	settings, _ := s.GetSettings(ctx, phoneNumber)
+	settings.Locale = "es"
+	s.UpdateSettings(ctx, phoneNumber, settings)
+	if rosters, _ := s.ListRosters(ctx); len(rosters) != 1 || rosters[0].Locale != "es" {
+		t.Errorf("rosters once the admin picked Spanish = %+v", rosters)
+	}
+
+	if err := s.RemoveFromRoster(ctx, member, roster[0].ID); !errors.Is(err, ErrNotAdmin) {
+		t.Errorf("removal by a member: %v, want ErrNotAdmin", err)
+	}
+	if err := s.RemoveFromRoster(ctx, phoneNumber, id); err != nil {
+		t.Fatal(err)
+	}
+	if orgs, _ := s.ListOrganizations(ctx, member); len(orgs) != 0 {
+		t.Errorf("organizations after removal = %+v", orgs)
+	}
+}
+
+func testTokens(t *testing.T, s Store) {
+	ctx := context.Background()
+	const other = "+15555550101"
+	s.EnsureAccount(ctx, phoneNumber, "UTC")
+	s.EnsureAccount(ctx, other, "UTC")
+
+	zapier, secret, err := s.CreateAPIToken(ctx, phoneNumber, "Zapier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherSecret, _ := s.CreateAPIToken(ctx, phoneNumber, "Calendar sync")
+	if secret == "" || secret == otherSecret {
+		t.Fatalf("secrets %q and %q, want two different ones", secret, otherSecret)
+	}
+	if got, token, err := s.AuthenticateAPIToken(ctx, secret); err != nil || got != phoneNumber || token.Name != "Zapier" {
+		t.Errorf("AuthenticateAPIToken = %q, %+v, %v, want %s's Zapier token", got, token, err, phoneNumber)
+	}
+	if _, _, err := s.AuthenticateAPIToken(ctx, "bdb_nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown secret: %v, want ErrNotFound", err)
+	}
+	tokens, err := s.ListAPITokens(ctx, phoneNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0].Name != "Zapier" || tokens[0].LastUsedAt.IsZero() || !tokens[1].LastUsedAt.IsZero() {
+		t.Fatalf("tokens = %+v, want Zapier, used, then Calendar sync", tokens)
+	}
+
+	if err := s.RevokeAPIToken(ctx, other, zapier.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoking someone else's token: %v, want ErrNotFound", err)
+	}
+	if err := s.RevokeAPIToken(ctx, phoneNumber, zapier.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.AuthenticateAPIToken(ctx, secret); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoked secret: %v, want ErrNotFound", err)
+	}
+	if tokens, _ := s.ListAPITokens(ctx, phoneNumber); len(tokens) != 1 || tokens[0].Name != "Calendar sync" {
+		t.Errorf("tokens after revoking = %+v", tokens)
+	}
+}
+
+func testAudit(t *testing.T, s Store) {
+	const editor, stranger = "+15555550101", "+15555550102"
+	for _, p := range []string{phoneNumber, editor, stranger} {
+		s.EnsureAccount(context.Background(), p, "UTC")
+	}
+	family, _ := s.CreateList(context.Background(), phoneNumber, "Family")
+	s.Invite(context.Background(), phoneNumber, family, editor, Editor)
+	invitations, _ := s.ListInvitations(context.Background(), editor)
+	s.RespondToInvitation(context.Background(), editor, invitations[0].ID, true)
+
+	ssh := WithActor(context.Background(), Actor{Source: SourceSSH, Key: "SHA256:abc"})
+	api := WithActor(context.Background(), Actor{Source: SourceAPI, Key: "Zapier"})
+	id, err := s.CreateEvent(ssh, phoneNumber, Event{ListID: family, Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 14, Year: 1990})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := s.GetEvent(ssh, editor, id)
+	if err := s.UpdateEvent(api, editor, e); err != nil {
+		t.Fatal(err)
+	}
+	e.Day = 15
+	e.Tags = []string{"cousins"}
+	if err := s.UpdateEvent(api, editor, e); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.EventHistory(ssh, stranger, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("history for a stranger: %v, want ErrNotFound", err)
+	}
+	history, err := s.EventHistory(ssh, phoneNumber, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history = %+v, want the create and the change of day but not the update that changed nothing", history)
+	}
+	update, create := history[0], history[1]
+	if update.Action != AuditUpdate || update.PhoneNumber != editor || update.Actor != (Actor{SourceAPI, "Zapier"}) {
+		t.Errorf("update = %+v, want the editor's through the API", update)
+	}
+	if changes := update.Changes(); len(changes) != 1 || changes[0].Field != "day" || fmt.Sprint(changes[0].Before, changes[0].After) != "14 15" {
+		t.Errorf("update changes = %+v, want day from 14 to 15", changes)
+	}
+	if create.Action != AuditCreate || create.Before != nil || create.After["name"] != "Ann" || create.Actor.Source != SourceSSH {
+		t.Errorf("create = %+v, want Ann made over SSH", create)
+	}
+	if err := s.DeleteEvent(ssh, phoneNumber, id); err != nil {
+		t.Fatal(err)
+	}
+	history, err = s.EventHistory(ssh, editor, id)
+	if err != nil || len(history) != 3 || history[0].Action != AuditDelete || history[0].Before["name"] != "Ann" {
+		t.Errorf("history of a deleted event = %+v, %v, want its delete on top", history, err)
+	}
+	if _, err := s.EventHistory(ssh, stranger, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("history of a deleted event for a stranger: %v, want ErrNotFound", err)
+	}
+	if _, err := s.EventHistory(ssh, phoneNumber, id+1000); !errors.Is(err, ErrNotFound) {
+		t.Errorf("history of an event that never was: %v, want ErrNotFound", err)
+	}
+
+	token, _, _ := s.CreateAPIToken(api, phoneNumber, "Zapier")
+	s.RevokeAPIToken(ssh, phoneNumber, token.ID)
+	due, _ := s.ScheduleDeletion(ssh, phoneNumber)
+	s.ScheduleDeletion(ssh, phoneNumber)
+	s.CancelDeletion(ssh, phoneNumber)
+	s.CancelDeletion(ssh, phoneNumber)
+	account, err := s.AccountHistory(ssh, phoneNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, a := range account {
+		got = append(got, a.Subject+" "+string(a.Action))
+	}
+	if want := []string{"deletion delete", "deletion create", "api_token delete", "api_token create"}; !slices.Equal(got, want) {
+		t.Errorf("account history = %v, want %v", got, want)
+	}
+	if dueAt := account[1].After["due_at"]; dueAt != due.Format(time.DateTime) {
+		t.Errorf("scheduled deletion recorded as due %v, want %v", dueAt, due)
+	}
+	if account[3].After["name"] != "Zapier" || account[3].Actor.Source != SourceAPI {
+		t.Errorf("token creation = %+v, want Zapier made through the API", account[3])
+	}
+}
+
+func testDeletion(t *testing.T, s Store) {
+	ctx := context.Background()
+	const friend = "+15555550101"
+	s.EnsureAccount(ctx, phoneNumber, "UTC")
+	s.EnsureAccount(ctx, friend, "UTC")
+	ann, _ := s.CreateEvent(ctx, phoneNumber, Event{Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 14, Tags: []string{"family"}})
+	gift, _ := s.CreateGift(ctx, phoneNumber, Gift{EventID: ann, Idea: "Tea", Status: "idea"})
+	_, secret, _ := s.CreateAPIToken(ctx, phoneNumber, "Zapier")
+	friends, _ := s.CreateList(ctx, friend, "Friends")
+	s.Invite(ctx, friend, friends, phoneNumber, Editor)
+	invitations, _ := s.ListInvitations(ctx, phoneNumber)
+	s.RespondToInvitation(ctx, phoneNumber, invitations[0].ID, true)
+	cal, _ := s.CreateEvent(ctx, phoneNumber, Event{ListID: friends, Name: "Cal", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 5, Day: 6})
+	shared, _ := s.CreateOrganization(ctx, friend, Organization{Name: "Acme", Timezone: "UTC", AnnouncementHour: 9})
+	s.AddToRoster(ctx, friend, RosterEntry{OrganizationID: shared, PhoneNumber: phoneNumber, Name: "Pat", Role: OrgMember})
+	solo, _ := s.CreateOrganization(ctx, phoneNumber, Organization{Name: "Solo", Timezone: "UTC", AnnouncementHour: 9})
+	crew, _ := s.CreateOrganization(ctx, phoneNumber, Organization{Name: "Crew", Timezone: "UTC", AnnouncementHour: 9})
+	s.AddToRoster(ctx, phoneNumber, RosterEntry{OrganizationID: crew, PhoneNumber: friend, Name: "Fran", Role: OrgMember})
+	s.AddToRoster(ctx, phoneNumber, RosterEntry{OrganizationID: crew, PhoneNumber: "+15555550102", Name: "Gus", Role: OrgMember})
+	s.RecordDelivery(ctx, Delivery{Channel: "sms", PhoneNumber: phoneNumber, Text: "Reminder: Ann's birthday"})
+	s.RecordDelivery(ctx, Delivery{Channel: "webhook", OrganizationID: solo, Text: "Happy birthday to Pat"})
+	s.RecordDelivery(ctx, Delivery{Channel: "sms", PhoneNumber: friend, Text: "Reminder: Cal's birthday"})
+	if deliveries, err := s.ListDeliveries(ctx, phoneNumber); err != nil || len(deliveries) != 1 || deliveries[0].Text != "Reminder: Ann's birthday" {
+		t.Errorf("ListDeliveries = %+v, %v", deliveries, err)
+	}
+
+	if due, err := s.DeletionDue(ctx, phoneNumber); err != nil || !due.IsZero() {
+		t.Fatalf("DeletionDue before scheduling = %v, %v, want zero", due, err)
+	}
+	due, err := s.ScheduleDeletion(ctx, phoneNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait := time.Until(due); wait < DeletionGracePeriod-time.Minute || wait > DeletionGracePeriod {
+		t.Errorf("deletion due in %v, want %v", wait, DeletionGracePeriod)
+	}
+	if again, _ := s.ScheduleDeletion(ctx, phoneNumber); !again.Equal(due) {
+		t.Errorf("scheduling again moved the deletion from %v to %v", due, again)
+	}
+	candidates, _ := s.ListCandidates(ctx)
+	for _, c := range candidates {
+		if c.PhoneNumber == phoneNumber {
+			t.Errorf("account scheduled to be deleted is still reminded about %s", c.Name)
+		}
+	}
+	scheduled, err := s.ListScheduledDeletions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []ScheduledDeletion{{phoneNumber, due, 1, 1}}; !slices.Equal(scheduled, want) {
+		t.Errorf("scheduled deletions = %+v, want %+v", scheduled, want)
+	}
+	if err := s.CancelDeletion(ctx, phoneNumber); err != nil {
+		t.Fatal(err)
+	}
+	if scheduled, _ := s.ListScheduledDeletions(ctx); len(scheduled) != 0 {
+		t.Errorf("scheduled deletions after cancelling = %+v", scheduled)
+	}
+
+	due, _ = s.ScheduleDeletion(ctx, phoneNumber)
+	if deleted, err := s.DeleteDueAccounts(ctx, due.Add(-time.Second)); err != nil || len(deleted) != 0 {
+		t.Fatalf("DeleteDueAccounts before the grace period ended = %v, %v", deleted, err)
+	}
+	deleted, err := s.DeleteDueAccounts(ctx, due)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(deleted, []string{phoneNumber}) {
+		t.Fatalf("deleted = %v, want %s", deleted, phoneNumber)
+	}
+	if _, err := s.GetSettings(ctx, phoneNumber); !errors.Is(err, ErrNotFound) {
+		t.Errorf("settings of a deleted account: %v, want ErrNotFound", err)
+	}
+	if _, err := s.GetGift(ctx, phoneNumber, gift); !errors.Is(err, ErrNotFound) {
+		t.Errorf("gift of a deleted account: %v, want ErrNotFound", err)
+	}
+	if _, _, err := s.AuthenticateAPIToken(ctx, secret); !errors.Is(err, ErrNotFound) {
+		t.Errorf("token of a deleted account: %v, want ErrNotFound", err)
+	}
+	if members, _ := s.ListMembers(ctx, friend, friends); len(members) != 1 || members[0].PhoneNumber != friend {
+		t.Errorf("members of the friend's list = %+v, want only the friend", members)
+	}
+	if roster, _ := s.ListRoster(ctx, friend, shared); len(roster) != 1 || roster[0].PhoneNumber != friend {
+		t.Errorf("roster = %+v, want only the friend", roster)
+	}
+	// The organization the account ran passes to its first member.
+	if roster, _ := s.ListRoster(ctx, friend, crew); len(roster) != 2 || roster[0].Name != "Fran" || roster[0].Role != OrgAdmin || roster[1].Role != OrgMember {
+		t.Errorf("roster of the account's organization = %+v, want Fran promoted to admin", roster)
+	}
+	history, err := s.EventHistory(ctx, friend, cal)
+	if err != nil || len(history) != 1 || history[0].PhoneNumber != "" {
+		t.Errorf("history of the friend's event = %+v, %v, want its create without the deleted account", history, err)
+	}
+
+	// The number can sign up again from scratch.
+	s.EnsureAccount(ctx, phoneNumber, "UTC")
+	if evs, _ := s.ListEvents(ctx, phoneNumber); len(evs) != 0 {
+		t.Errorf("events after signing up again = %+v, want none", evs)
+	}
+	if orgs, _ := s.ListOrganizations(ctx, phoneNumber); len(orgs) != 0 {
+		t.Errorf("organizations after signing up again = %+v, want none", orgs)
+	}
+
+	if deliveries, _ := s.ListDeliveries(ctx, phoneNumber); len(deliveries) != 0 {
+		t.Errorf("deliveries after signing up again = %+v, want none", deliveries)
+	}
+
+	if n, err := s.PurgeDeliveries(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("PurgeDeliveries of an hour ago = %d, %v, want 0", n, err)
+	}
+	if n, err := s.PurgeDeliveries(ctx, time.Now().Add(time.Hour)); err != nil || n != 1 {
+		t.Errorf("PurgeDeliveries of an hour from now = %d, %v, want the friend's 1", n, err)
+	}
+	if deliveries, _ := s.ListDeliveries(ctx, friend); len(deliveries) != 0 {
+		t.Errorf("deliveries after purging = %+v", deliveries)
+	}
+}
+
+// TestSQLiteClock checks that rows are stamped with the store's clock rather
+// than the database's.
+func TestSQLiteClock(t *testing.T) {
+	ctx := context.Background()
+	s := migratedSQLite(t)
+	created := time.Date(2024, time.December, 28, 9, 0, 0, 0, time.UTC)
+	s.SetClock(clock.Fixed(created))
+	if err := s.EnsureAccount(ctx, phoneNumber, "UTC"); err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.CreateEvent(ctx, phoneNumber, Event{Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 1, Day: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated := created.AddDate(0, 0, 3)
+	s.SetClock(clock.Fixed(updated))
+	e, err := s.GetEvent(ctx, phoneNumber, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Notes = "Likes tea"
+	if err := s.UpdateEvent(ctx, phoneNumber, e); err != nil {
+		t.Fatal(err)
+	}
+
+	var createdAt, updatedAt time.Time
+	row := s.db.QueryRow(`select created_at, updated_at from events where id = ?;`, id)
+	if err := row.Scan(&createdAt, &updatedAt); err != nil {
+		t.Fatal(err)
+	}
+	if !createdAt.Equal(created) {
+		t.Errorf("created_at = %v, want %v", createdAt, created)
+	}
+	if !updatedAt.Equal(updated) {
+		t.Errorf("updated_at = %v, want %v", updatedAt, updated)
+	}
+}
+
+func TestSQLiteBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	s := migratedSQLite(t)
+	s.EnsureAccount(ctx, phoneNumber, "UTC")
+	s.CreateEvent(ctx, phoneNumber, Event{Name: "Ann", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 1, Day: 2})
+	path := filepath.Join(t.TempDir(), "backup.sqlite")
+	if err := s.Backup(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Backup(ctx, path); err == nil {
+		t.Error("backed up over an existing file")
+	}
+	b, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if err := b.IntegrityCheck(ctx); err != nil {
+		t.Error(err)
+	}
+
+	s.CreateEvent(ctx, phoneNumber, Event{Name: "Bob", Type: events.Birthday, Calendar: calendar.Gregorian, Month: 3, Day: 4})
+	if err := s.Restore(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.ListEvents(ctx, phoneNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "Ann" {
+		t.Errorf("events after restoring = %+v, want only Ann", got)
+	}
+}
+
+// TestStoreClocks checks that both stores stamp records with their clock,
+// so that they agree under a fixed clock.
+func TestStoreClocks(t *testing.T) {
+	type clockedStore interface {
+		Store
+		SetClock(clock.Clock)
+	}
+	stores := map[string]func(*testing.T) clockedStore{
+		"memory": func(*testing.T) clockedStore { return NewMemory() },
+		"sqlite": func(t *testing.T) clockedStore { return migratedSQLite(t) },
+	}
+	now := time.Date(2024, time.December, 28, 9, 0, 0, 0, time.UTC)
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := open(t)
+			s.SetClock(clock.Fixed(now))
+			s.EnsureAccount(ctx, phoneNumber, "UTC")
+			lists, _ := s.ListLists(ctx, phoneNumber)
+			s.SubmitBirthday(ctx, Submission{ListID: lists[0].ID, Name: "Bob", Month: 12, Day: 1})
+			if subs, err := s.ListSubmissions(ctx, phoneNumber, lists[0].ID); err != nil || len(subs) != 1 || !subs[0].SubmittedAt.Equal(now) {
+				t.Errorf("submissions = %+v, %v, want one submitted at %v", subs, err, now)
+			}
+			token, secret, _ := s.CreateAPIToken(ctx, phoneNumber, "Zapier")
+			if !token.CreatedAt.Equal(now) {
+				t.Errorf("token created at %v, want %v", token.CreatedAt, now)
+			}
+			s.AuthenticateAPIToken(ctx, secret)
+			if tokens, err := s.ListAPITokens(ctx, phoneNumber); err != nil || len(tokens) != 1 || !tokens[0].LastUsedAt.Equal(now) {
+				t.Errorf("tokens = %+v, %v, want one last used at %v", tokens, err, now)
+			}
+			if history, err := s.AccountHistory(ctx, phoneNumber); err != nil || len(history) == 0 || !history[0].At.Equal(now) {
+				t.Errorf("history = %+v, %v, want changes at %v", history, err, now)
+			}
+			s.RecordDelivery(ctx, Delivery{Channel: "sms", PhoneNumber: phoneNumber, Text: "Reminder"})
+			if deliveries, err := s.ListDeliveries(ctx, phoneNumber); err != nil || len(deliveries) != 1 || !deliveries[0].At.Equal(now) {
+				t.Errorf("deliveries = %+v, %v, want one at %v", deliveries, err, now)
+			}
+			if due, err := s.ScheduleDeletion(ctx, phoneNumber); err != nil || !due.Equal(now.Add(DeletionGracePeriod)) {
+				t.Errorf("deletion due %v, %v, want %v", due, err, now.Add(DeletionGracePeriod))
+			}
+		})
+	}
+}
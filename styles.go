@@ -1,11 +1,13 @@
 package main
 
-import "github.com/charmbracelet/lipgloss"
-
-var (
-	red    = lipgloss.AdaptiveColor{Light: "#FE5F86", Dark: "#FE5F86"}
-	indigo = lipgloss.AdaptiveColor{Light: "#5A56E0", Dark: "#7571F9"}
-	green  = lipgloss.AdaptiveColor{Light: "#02BA84", Dark: "#02BF87"}
+import (
+	"ashwindharne/bdaybot/i18n"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"reflect"
+	"strings"
 )
 
 type Styles struct {
@@ -15,30 +17,112 @@ type Styles struct {
 	StatusHeader,
 	Highlight,
 	ErrorHeaderText,
-	Help lipgloss.Style
+	Help,
+	CalendarCell,
+	CalendarBirthday,
+	CalendarSelected lipgloss.Style
+	Table   table.Styles
+	KeyHelp help.Styles
+	Form    *huh.Theme
+	Theme   Theme
 }
 
-func NewStyles(lg *lipgloss.Renderer) *Styles {
-	s := Styles{}
+func NewStyles(lg *lipgloss.Renderer, t Theme) *Styles {
+	s := Styles{Theme: t}
 	s.Base = lg.NewStyle().
 		Padding(1, 4, 0, 1)
 	s.HeaderText = lg.NewStyle().
-		Foreground(indigo).
+		Foreground(t.Header).
 		Bold(true).
 		Padding(0, 1, 0, 2)
 	s.Status = lg.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(indigo).
+		BorderForeground(t.Header).
 		PaddingLeft(1).
 		MarginTop(1)
 	s.StatusHeader = lg.NewStyle().
-		Foreground(green).
+		Foreground(t.Accent).
 		Bold(true)
 	s.Highlight = lg.NewStyle().
-		Foreground(lipgloss.Color("212"))
+		Foreground(t.Highlight)
 	s.ErrorHeaderText = s.HeaderText.
-		Foreground(red)
+		Foreground(t.Error)
 	s.Help = lg.NewStyle().
-		Foreground(lipgloss.Color("240"))
+		Foreground(t.Muted)
+	s.CalendarCell = lg.NewStyle().
+		Width(6).
+		Align(lipgloss.Right).
+		PaddingRight(1)
+	s.CalendarBirthday = s.CalendarCell.
+		Foreground(t.Accent).
+		Bold(true)
+	s.CalendarSelected = s.CalendarCell.
+		Foreground(t.SelectedText).
+		Background(t.Header).
+		Bold(true)
+
+	// Bubbles and huh build their styles from the global renderer, which over
+	// SSH describes the server's terminal, so they're rebound to lg.
+	s.Table = table.DefaultStyles()
+	s.Table.Header = s.Table.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(t.Muted).
+		BorderBottom(true).
+		Bold(false)
+	s.Table.Selected = s.Table.Selected.
+		Foreground(t.SelectedText).
+		Background(t.Selected).
+		Bold(false)
+	withRenderer(&s.Table, lg)
+	s.KeyHelp = help.New().Styles
+	for _, style := range []*lipgloss.Style{&s.KeyHelp.ShortKey, &s.KeyHelp.FullKey, &s.KeyHelp.ShortDesc, &s.KeyHelp.FullDesc} {
+		*style = style.Foreground(t.Muted)
+	}
+	withRenderer(&s.KeyHelp, lg)
+	s.Form = t.Form()
+	withRenderer(s.Form, lg)
 	return &s
 }
+
+// FormErrors renders a form's validation errors, translated by p. huh draws
+// them with its default theme on the global renderer, so forms are built with
+// WithShowErrors(false) and screens show the errors with this instead.
+func (s *Styles) FormErrors(form *huh.Form, p i18n.Printer) string {
+	var b strings.Builder
+	for _, err := range form.Errors() {
+		b.WriteString("\n" + s.Form.Focused.ErrorMessage.Render(p.Error(err)))
+	}
+	return b.String()
+}
+
+// NewHelp returns a help view drawn with the session's renderer.
+func (s *Styles) NewHelp() help.Model {
+	h := help.New()
+	h.Styles = s.KeyHelp
+	return h
+}
+
+// withRenderer rebinds every lipgloss.Style in the struct v points to, at
+// any depth, to lg.
+func withRenderer(v any, lg *lipgloss.Renderer) {
+	bindStyles(reflect.ValueOf(v).Elem(), lg)
+}
+
+func bindStyles(v reflect.Value, lg *lipgloss.Renderer) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if style, ok := v.Addr().Interface().(*lipgloss.Style); ok {
+			*style = style.Renderer(lg)
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				bindStyles(v.Field(i), lg)
+			}
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			bindStyles(v.Elem(), lg)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+	"time"
+)
+
+// SUBMISSIONS KEYMAPS
+type sbKeyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	Approve key.Binding
+	Reject  key.Binding
+	NewLink key.Binding
+	Back    key.Binding
+	Help    key.Binding
+	Quit    key.Binding
+}
+
+func (k sbKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Approve, k.Reject, k.NewLink, k.Back, k.Help, k.Quit}
+}
+
+func (k sbKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Approve},             // first column
+		{k.Reject, k.NewLink, k.Back, k.Quit}, // second column
+	}
+}
+
+var sbKeys = sbKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Approve: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "approve"),
+	),
+	Reject: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "reject"),
+	),
+	NewLink: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new link"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Help: rootKeys.Help,
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c", "q"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// SUBMISSIONS MODEL
+
+// SbModel shows a list's collection link and the birthdays submitted
+// through it, for the list's owner to approve or reject.
+type SbModel struct {
+	session     *session
+	list        store.List
+	table       table.Model
+	submissions []store.Submission
+	loaded      bool
+	width       int
+	help        help.Model
+	km          sbKeyMap
+	banner      errorBanner
+}
+
+// SUBMISSIONS INITIALIZATION
+
+func sbColumns(p i18n.Printer) []table.Column {
+	return []table.Column{
+		{Title: p.T("Name"), Width: 30},
+		{Title: p.T("Birthday"), Width: 16},
+		{Title: p.T("Submitted"), Width: 16},
+	}
+}
+
+func EmptySubmissions(s *session, l store.List) SbModel {
+	t := table.New(
+		table.WithColumns(sbColumns(s.printer)),
+		table.WithFocused(true),
+		table.WithHeight(10),
+	)
+	t.SetStyles(s.styles.Table)
+
+	return SbModel{
+		session: s,
+		list:    l,
+		table:   t,
+		help:    s.styles.NewHelp(),
+		km:      localizeKeys(s.printer, sbKeys),
+	}
+}
+
+// SUBMISSIONS COMMANDS
+
+type submissionsRetrievalMsg struct {
+	submissions []store.Submission
+}
+
+type submissionReviewedMsg struct{}
+
+type linkRegeneratedMsg struct {
+	version int
+}
+
+func getSubmissions(st store.SubmissionStore, phoneNumber string, listID int) tea.Cmd {
+	return func() tea.Msg {
+		submissions, err := st.ListSubmissions(context.Background(), phoneNumber, listID)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return submissionsRetrievalMsg{submissions}
+	}
+}
+
+func reviewSubmission(ctx context.Context, st store.SubmissionStore, phoneNumber string, id int, approve bool) tea.Cmd {
+	return func() tea.Msg {
+		if err := st.ReviewSubmission(ctx, phoneNumber, id, approve); err != nil {
+			return dbErrMsg{err}
+		}
+		return submissionReviewedMsg{}
+	}
+}
+
+func regenerateLink(st store.SubmissionStore, phoneNumber string, listID int) tea.Cmd {
+	return func() tea.Msg {
+		version, err := st.RegenerateLink(context.Background(), phoneNumber, listID)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return linkRegeneratedMsg{version}
+	}
+}
+
+func (m *SbModel) setRows() {
+	p := m.session.printer
+	var rows []table.Row
+	for _, sub := range m.submissions {
+		submitted := sub.SubmittedAt
+		rows = append(rows, []string{
+			sub.Name,
+			p.Date(sub.Year, time.Month(sub.Month), sub.Day),
+			p.Date(submitted.Year(), submitted.Month(), submitted.Day()),
+		})
+	}
+	m.table.SetRows(rows)
+}
+
+// selected returns the highlighted submission, if there is one.
+func (m *SbModel) selected() (store.Submission, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.submissions) {
+		return store.Submission{}, false
+	}
+	return m.submissions[i], true
+}
+
+// SUBMISSIONS UPDATE-VIEW LOOP
+
+func (m *SbModel) Init() tea.Cmd {
+	m.table.SetStyles(m.session.styles.Table)
+	m.help.Styles = m.session.styles.KeyHelp
+	return getSubmissions(m.session.store, m.session.phoneNumber, m.list.ID)
+}
+
+func (m *SbModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 120) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.km.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.km.Back):
+			return m, popScreen()
+		case key.Matches(msg, m.km.Approve, m.km.Reject):
+			sub, ok := m.selected()
+			if !ok {
+				return m, nil
+			}
+			approve := key.Matches(msg, m.km.Approve)
+			return m, reviewSubmission(m.session.ctx(), m.session.store, m.session.phoneNumber, sub.ID, approve)
+		case key.Matches(msg, m.km.NewLink):
+			return m, regenerateLink(m.session.store, m.session.phoneNumber, m.list.ID)
+		}
+	case submissionsRetrievalMsg:
+		m.submissions = msg.submissions
+		m.loaded = true
+		m.setRows()
+		return m, nil
+	case submissionReviewedMsg:
+		return m, getSubmissions(m.session.store, m.session.phoneNumber, m.list.ID)
+	case linkRegeneratedMsg:
+		m.list.LinkVersion = msg.version
+		return m, nil
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m *SbModel) KeyMap() help.KeyMap {
+	return m.km
+}
+
+func (m *SbModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("Birthday Submissions · %s", listTitle(m.session.printer, m.list))))
+	var b strings.Builder
+	link := m.session.styles.StatusHeader.Render(m.session.T("Collection Link")) + "\n"
+	if m.session.links != nil {
+		link += m.session.links.URL(m.list.ID, m.list.LinkVersion) + "\n" +
+			m.session.styles.Help.Render(m.session.T("Anyone with the link can submit their birthday. Nothing is added until you approve it. A new link stops the old one from working."))
+	} else {
+		link += m.session.styles.Help.Render(m.session.T("Collection links aren't set up on this server."))
+	}
+	b.WriteString(m.session.styles.Status.Width(72).Render(link) + "\n\n")
+	if m.loaded && len(m.submissions) == 0 {
+		b.WriteString(m.session.styles.Help.Render(m.session.T("No birthdays are waiting for review.")) + "\n")
+	} else {
+		b.WriteString(m.table.View() + "\n")
+	}
+	body := m.session.styles.Base.Render(b.String())
+	footer := m.appBoundaryView(m.help.ShortHelpView(m.km.ShortHelp()))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *SbModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Left,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}
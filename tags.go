@@ -0,0 +1,32 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/store"
+	"context"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+)
+
+func tagBadges(tags []string) string {
+	badges := make([]string, len(tags))
+	for i, tag := range tags {
+		badges[i] = "#" + tag
+	}
+	return strings.Join(badges, " ")
+}
+
+// TAG COMMANDS
+
+type tagsRetrievalMsg struct {
+	tags []string
+}
+
+func getTags(st store.BirthdayStore, phoneNumber string) tea.Cmd {
+	return func() tea.Msg {
+		tags, err := st.ListTags(context.Background(), phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return tagsRetrievalMsg{tags}
+	}
+}
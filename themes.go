@@ -0,0 +1,111 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"fmt"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"slices"
+)
+
+// Theme is a palette covering every color the app draws with.
+type Theme struct {
+	Name  string
+	Title string
+	// Header colors screen titles, boxes and the "/" fill around titles.
+	Header lipgloss.TerminalColor
+	// Accent marks good news: section headings and days with birthdays.
+	Accent    lipgloss.TerminalColor
+	Error     lipgloss.TerminalColor
+	Highlight lipgloss.TerminalColor
+	// Muted is for help text and table rules.
+	Muted lipgloss.TerminalColor
+	// SelectedText and Selected are the foreground and background of the
+	// highlighted table row and calendar day.
+	SelectedText lipgloss.TerminalColor
+	Selected     lipgloss.TerminalColor
+	// Form is the huh theme forms are drawn with.
+	Form func() *huh.Theme
+}
+
+// Themes lists the built-in themes in the order they're offered in settings.
+// The first is the default.
+var Themes = []Theme{
+	{
+		Name:         "default",
+		Title:        "Default",
+		Header:       lipgloss.AdaptiveColor{Light: "#5A56E0", Dark: "#7571F9"},
+		Accent:       lipgloss.AdaptiveColor{Light: "#02BA84", Dark: "#02BF87"},
+		Error:        lipgloss.AdaptiveColor{Light: "#FE5F86", Dark: "#FE5F86"},
+		Highlight:    lipgloss.Color("212"),
+		Muted:        lipgloss.Color("240"),
+		SelectedText: lipgloss.Color("229"),
+		Selected:     lipgloss.Color("57"),
+		Form:         huh.ThemeCharm,
+	},
+	{
+		Name:         "high-contrast",
+		Title:        "High contrast",
+		Header:       lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		Accent:       lipgloss.AdaptiveColor{Light: "#005F00", Dark: "#5FFF5F"},
+		Error:        lipgloss.AdaptiveColor{Light: "#AF0000", Dark: "#FF5F5F"},
+		Highlight:    lipgloss.AdaptiveColor{Light: "#00005F", Dark: "#FFFF00"},
+		Muted:        lipgloss.AdaptiveColor{Light: "#303030", Dark: "#D0D0D0"},
+		SelectedText: lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#000000"},
+		Selected:     lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		Form:         huh.ThemeBase16,
+	},
+	{
+		// Colors from the Okabe-Ito palette, which stays distinguishable with
+		// the common kinds of color blindness. Nothing relies on red against
+		// green.
+		Name:         "colorblind",
+		Title:        "Colorblind safe",
+		Header:       lipgloss.Color("#0072B2"),
+		Accent:       lipgloss.Color("#56B4E9"),
+		Error:        lipgloss.Color("#E69F00"),
+		Highlight:    lipgloss.Color("#F0E442"),
+		Muted:        lipgloss.Color("244"),
+		SelectedText: lipgloss.Color("#000000"),
+		Selected:     lipgloss.Color("#E69F00"),
+		Form:         huh.ThemeBase,
+	},
+	{
+		Name:         "dracula",
+		Title:        "Dracula",
+		Header:       lipgloss.Color("#BD93F9"),
+		Accent:       lipgloss.Color("#50FA7B"),
+		Error:        lipgloss.Color("#FF5555"),
+		Highlight:    lipgloss.Color("#FF79C6"),
+		Muted:        lipgloss.Color("#6272A4"),
+		SelectedText: lipgloss.Color("#F8F8F2"),
+		Selected:     lipgloss.Color("#44475A"),
+		Form:         huh.ThemeDracula,
+	},
+}
+
+// ThemeNamed returns the built-in theme called name.
+func ThemeNamed(name string) (Theme, error) {
+	i := slices.IndexFunc(Themes, func(t Theme) bool { return t.Name == name })
+	if i < 0 {
+		return Theme{}, fmt.Errorf("unknown theme %q", name)
+	}
+	return Themes[i], nil
+}
+
+// themeOrDefault returns the theme called name, falling back to the default
+// for names that are no longer built in.
+func themeOrDefault(name string) Theme {
+	if t, err := ThemeNamed(name); err == nil {
+		return t
+	}
+	return Themes[0]
+}
+
+func themeOptions(p i18n.Printer) []huh.Option[string] {
+	var options []huh.Option[string]
+	for _, t := range Themes {
+		options = append(options, huh.NewOption(p.T(t.Title), t.Name))
+	}
+	return options
+}
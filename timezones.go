@@ -0,0 +1,102 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"slices"
+	"time"
+)
+
+// timezoneChoices are the IANA zones offered in the settings form. The list
+// is filterable, so it leans towards breadth over brevity.
+var timezoneChoices = []string{
+	"Pacific/Honolulu",
+	"America/Anchorage",
+	"America/Los_Angeles",
+	"America/Vancouver",
+	"America/Phoenix",
+	"America/Denver",
+	"America/Edmonton",
+	"America/Chicago",
+	"America/Mexico_City",
+	"America/Winnipeg",
+	"America/New_York",
+	"America/Toronto",
+	"America/Halifax",
+	"America/St_Johns",
+	"America/Bogota",
+	"America/Lima",
+	"America/Santiago",
+	"America/Sao_Paulo",
+	"America/Argentina/Buenos_Aires",
+	"UTC",
+	"Europe/London",
+	"Europe/Dublin",
+	"Europe/Lisbon",
+	"Europe/Paris",
+	"Europe/Berlin",
+	"Europe/Madrid",
+	"Europe/Rome",
+	"Europe/Amsterdam",
+	"Europe/Stockholm",
+	"Europe/Warsaw",
+	"Europe/Athens",
+	"Europe/Istanbul",
+	"Africa/Lagos",
+	"Africa/Cairo",
+	"Africa/Johannesburg",
+	"Africa/Nairobi",
+	"Asia/Dubai",
+	"Asia/Karachi",
+	"Asia/Kolkata",
+	"Asia/Dhaka",
+	"Asia/Bangkok",
+	"Asia/Singapore",
+	"Asia/Shanghai",
+	"Asia/Hong_Kong",
+	"Asia/Manila",
+	"Asia/Seoul",
+	"Asia/Tokyo",
+	"Australia/Perth",
+	"Australia/Adelaide",
+	"Australia/Brisbane",
+	"Australia/Sydney",
+	"Pacific/Auckland",
+}
+
+// timezoneOptions returns timezoneChoices, adding current if the account
+// was set up with a zone that isn't in the list.
+func timezoneOptions(current string) []string {
+	if current == "" || slices.Contains(timezoneChoices, current) {
+		return timezoneChoices
+	}
+	return append([]string{current}, timezoneChoices...)
+}
+
+// formatHour renders an hour of the day as e.g. "9:00 AM" or "09:00",
+// depending on the locale.
+func formatHour(p i18n.Printer, hour int) string {
+	return p.Clock(time.Date(2000, 1, 1, hour, 0, 0, 0, time.UTC))
+}
+
+func validateTimezone(name string) error {
+	if _, err := time.LoadLocation(name); err != nil || name == "" {
+		return i18n.Errorf("must be a timezone like America/New_York")
+	}
+	return nil
+}
+
+// userLocation loads the account's timezone. Unknown zones fall back to UTC
+// so a bad value can't lock anyone out of their reminders.
+func userLocation(ctx context.Context, st store.AccountStore, phoneNumber string) (*time.Location, error) {
+	settings, err := st.GetSettings(ctx, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return time.UTC, nil
+	}
+	return loc, nil
+}
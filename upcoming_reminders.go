@@ -0,0 +1,259 @@
+package main
+
+import (
+	"ashwindharne/bdaybot/clock"
+	"ashwindharne/bdaybot/events"
+	"ashwindharne/bdaybot/i18n"
+	"ashwindharne/bdaybot/notifier"
+	"ashwindharne/bdaybot/phone"
+	"ashwindharne/bdaybot/store"
+	"context"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strconv"
+	"strings"
+)
+
+// upcomingDays is how far ahead the upcoming reminders screen looks.
+const upcomingDays = 30
+
+// UPCOMING REMINDERS KEYMAPS
+type urKeyMap struct {
+	Up   key.Binding
+	Down key.Binding
+	Test key.Binding
+	Back key.Binding
+	Help key.Binding
+	Quit key.Binding
+}
+
+func (k urKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Test, k.Back, k.Help, k.Quit}
+}
+
+func (k urKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Test}, // first column
+		{k.Back, k.Quit},       // second column
+	}
+}
+
+var urKeys = urKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Test: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "send test message"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Help: rootKeys.Help,
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c", "q"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// UPCOMING REMINDERS MODEL
+
+// UrModel lists the reminders the notifier will send the account over the
+// next upcomingDays days, worked out the same way the notifier does.
+type UrModel struct {
+	session   *session
+	table     table.Model
+	reminders []notifier.Reminder
+	// enabled is false when the account has turned reminders off.
+	enabled bool
+	loaded  bool
+	// notice confirms that a test message was sent.
+	notice string
+	width  int
+	help   help.Model
+	km     urKeyMap
+	banner errorBanner
+}
+
+// UPCOMING REMINDERS INITIALIZATION
+
+func urColumns(p i18n.Printer) []table.Column {
+	return []table.Column{
+		{Title: p.T("When"), Width: 32},
+		{Title: p.T("Occasion"), Width: 30},
+		{Title: p.T("Channel"), Width: 14},
+		{Title: p.T("Days Before"), Width: 12},
+	}
+}
+
+func EmptyUpcomingReminders(s *session) UrModel {
+	t := table.New(
+		table.WithColumns(urColumns(s.printer)),
+		table.WithFocused(true),
+		table.WithHeight(12),
+	)
+	t.SetStyles(s.styles.Table)
+
+	return UrModel{
+		session: s,
+		table:   t,
+		help:    s.styles.NewHelp(),
+		km:      localizeKeys(s.printer, urKeys),
+	}
+}
+
+// UPCOMING REMINDERS COMMANDS
+
+type upcomingRemindersMsg struct {
+	reminders []notifier.Reminder
+	enabled   bool
+}
+
+// testMessageMsg reports how sending a test message went.
+type testMessageMsg struct {
+	err error
+}
+
+func getUpcomingReminders(st store.Store, phoneNumber string, c clock.Clock) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		settings, err := st.GetSettings(ctx, phoneNumber)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		reminders, err := notifier.PlanAccount(ctx, st, phoneNumber, c.Now(), upcomingDays)
+		if err != nil {
+			return dbErrMsg{err}
+		}
+		return upcomingRemindersMsg{reminders, settings.Enabled}
+	}
+}
+
+func sendTestMessage(sender notifier.Sender, phoneNumber string, p i18n.Printer) tea.Cmd {
+	return func() tea.Msg {
+		return testMessageMsg{sender.Send(context.Background(), phoneNumber, notifier.TestMessage(p))}
+	}
+}
+
+// channelName is how a delivery channel is shown.
+func channelName(p i18n.Printer, channel string) string {
+	if channel == notifier.Channel {
+		return p.T("Text message")
+	}
+	return channel
+}
+
+func (m *UrModel) setRows() {
+	p := m.session.printer
+	var rows []table.Row
+	for _, r := range m.reminders {
+		rows = append(rows, []string{
+			p.LongDate(r.At) + " · " + p.Clock(r.At),
+			events.Headline(p, r.Type, r.Label, r.Name, r.Year, r.Occurrence.Year()),
+			channelName(p, notifier.Channel),
+			strconv.Itoa(r.DaysBefore),
+		})
+	}
+	m.table.SetRows(rows)
+}
+
+// selected returns the highlighted reminder, if there is one.
+func (m *UrModel) selected() (notifier.Reminder, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.reminders) {
+		return notifier.Reminder{}, false
+	}
+	return m.reminders[i], true
+}
+
+// UPCOMING REMINDERS UPDATE-VIEW LOOP
+
+func (m *UrModel) Init() tea.Cmd {
+	m.table.SetStyles(m.session.styles.Table)
+	m.help.Styles = m.session.styles.KeyHelp
+	return getUpcomingReminders(m.session.store, m.session.phoneNumber, m.session.clock)
+}
+
+func (m *UrModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, 120) - m.session.styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.km.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.km.Back):
+			return m, popScreen()
+		case key.Matches(msg, m.km.Test):
+			m.notice = ""
+			return m, sendTestMessage(m.session.sender, m.session.phoneNumber, m.session.printer)
+		}
+	case upcomingRemindersMsg:
+		m.reminders = msg.reminders
+		m.enabled = msg.enabled
+		m.loaded = true
+		m.setRows()
+		return m, nil
+	case testMessageMsg:
+		if msg.err != nil {
+			return m, m.banner.Show(msg.err)
+		}
+		m.notice = m.session.T("Test message sent to %s.", phone.FormatNational(m.session.phoneNumber))
+		return m, nil
+	case dbErrMsg:
+		return m, m.banner.Show(msg.err)
+	case errorBannerExpiredMsg:
+		m.banner.Expire(msg)
+		return m, nil
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m *UrModel) KeyMap() help.KeyMap {
+	return m.km
+}
+
+func (m *UrModel) View() string {
+	header := m.banner.View(m.session, m.appBoundaryView(m.session.T("Upcoming Reminders")))
+	var b strings.Builder
+	switch {
+	case !m.loaded:
+	case !m.enabled:
+		b.WriteString(m.session.styles.Help.Render(m.session.T("Reminders are turned off in settings.")) + "\n")
+	case len(m.reminders) == 0:
+		b.WriteString(m.session.styles.Help.Render(m.session.T("No reminders in the next %d days.", upcomingDays)) + "\n")
+	default:
+		b.WriteString(m.table.View() + "\n")
+		if r, ok := m.selected(); ok {
+			preview := m.session.styles.StatusHeader.Render(m.session.T("Message")) + "\n" + r.Message()
+			b.WriteString(m.session.styles.Status.Width(72).Render(preview) + "\n")
+		}
+	}
+	if m.notice != "" {
+		b.WriteString("\n" + m.session.styles.Highlight.Render(m.notice) + "\n")
+	}
+	body := m.session.styles.Base.Render(b.String())
+	footer := m.appBoundaryView(m.help.ShortHelpView(m.km.ShortHelp()))
+	return header + "\n" + body + "\n" + footer
+}
+
+func (m *UrModel) appBoundaryView(text string) string {
+	return m.session.lg.PlaceHorizontal(
+		m.width,
+		lipgloss.Left,
+		m.session.styles.HeaderText.Render(text),
+		lipgloss.WithWhitespaceChars("/"),
+		lipgloss.WithWhitespaceForeground(m.session.styles.Theme.Header),
+	)
+}